@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//cliKinds are the kinds which can be used in apply manifests in addition to the ones provided by the SDK
+var cliKinds = map[string]reflect.Type{
+	"ExternalConnection": reflect.TypeOf(ExternalConnection{}),
+	"NetworkProfile":     reflect.TypeOf(NetworkProfile{}),
+}
+
+//getObjectByKind returns a pointer to a new object of the given kind
+func getObjectByKind(kind string) (reflect.Value, error) {
+	if t, ok := cliKinds[kind]; ok {
+		return reflect.New(t), nil
+	}
+
+	return metalcloud.GetObjectByKind(kind)
+}
+
+//NetworkProfile is the manifest representation of a metalcloud.NetworkProfile
+type NetworkProfile metalcloud.NetworkProfile
+
+//getServerSideObject returns the network profile with the same id or label
+func (np NetworkProfile) getServerSideObject(client metalcloud.MetalCloudClient) (*metalcloud.NetworkProfile, error) {
+	if np.NetworkProfileID != 0 {
+		return client.NetworkProfileGet(np.NetworkProfileID)
+	}
+	return client.NetworkProfileGetByLabel(np.NetworkProfileLabel)
+}
+
+//CreateOrUpdate implements interface Applier
+func (np NetworkProfile) CreateOrUpdate(client metalcloud.MetalCloudClient) error {
+	if err := np.Validate(); err != nil {
+		return err
+	}
+
+	result, err := np.getServerSideObject(client)
+
+	if err != nil || result == nil {
+		if np.DatacenterName == "" {
+			return fmt.Errorf("dc is required when creating a network profile")
+		}
+
+		_, err = client.NetworkProfileCreate(np.DatacenterName, metalcloud.NetworkProfile(np))
+		return err
+	}
+
+	_, err = client.NetworkProfileUpdate(result.NetworkProfileID, metalcloud.NetworkProfile(np))
+
+	return err
+}
+
+//Delete implements interface Applier
+func (np NetworkProfile) Delete(client metalcloud.MetalCloudClient) error {
+	if err := np.Validate(); err != nil {
+		return err
+	}
+
+	result, err := np.getServerSideObject(client)
+	if err != nil {
+		return err
+	}
+
+	return client.NetworkProfileDelete(result.NetworkProfileID)
+}
+
+//Validate implements interface Applier
+func (np NetworkProfile) Validate() error {
+	if np.NetworkProfileID == 0 && np.NetworkProfileLabel == "" {
+		return fmt.Errorf("id or label is required")
+	}
+
+	return nil
+}
+
+//ExternalConnection is the manifest representation of a metalcloud.ExternalConnection
+type ExternalConnection metalcloud.ExternalConnection
+
+//getServerSideObject returns the external connection with the same id or label
+func (ec ExternalConnection) getServerSideObject(client metalcloud.MetalCloudClient) (*metalcloud.ExternalConnection, error) {
+	if ec.ExternalConnectionID != 0 {
+		return client.ExternalConnectionGet(ec.ExternalConnectionID)
+	}
+	return client.ExternalConnectionGetByLabel(ec.ExternalConnectionLabel)
+}
+
+//CreateOrUpdate implements interface Applier
+func (ec ExternalConnection) CreateOrUpdate(client metalcloud.MetalCloudClient) error {
+	if err := ec.Validate(); err != nil {
+		return err
+	}
+
+	result, err := ec.getServerSideObject(client)
+
+	if err != nil || result == nil {
+		if ec.DatacenterName == "" {
+			return fmt.Errorf("dc is required when creating an external connection")
+		}
+
+		_, err = client.ExternalConnectionCreate(metalcloud.ExternalConnection(ec))
+		return err
+	}
+
+	_, err = client.ExternalConnectionEdit(result.ExternalConnectionID, metalcloud.ExternalConnection(ec))
+
+	return err
+}
+
+//Delete implements interface Applier
+func (ec ExternalConnection) Delete(client metalcloud.MetalCloudClient) error {
+	if err := ec.Validate(); err != nil {
+		return err
+	}
+
+	result, err := ec.getServerSideObject(client)
+	if err != nil {
+		return err
+	}
+
+	return client.ExternalConnectionDelete(result.ExternalConnectionID)
+}
+
+//Validate implements interface Applier
+func (ec ExternalConnection) Validate() error {
+	if ec.ExternalConnectionID == 0 && ec.ExternalConnectionLabel == "" {
+		return fmt.Errorf("id or label is required")
+	}
+
+	return nil
+}
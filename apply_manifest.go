@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//stdinManifestPath is the value of -f used to read the manifest from stdin
+const stdinManifestPath = "-"
+
+//manifestExtensions are the extensions of the files read when -f points to a directory
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+//manifestSource is the content of a manifest file
+type manifestSource struct {
+	name    string
+	content []byte
+}
+
+//manifestDocument is an object read from a manifest together with its position
+type manifestDocument struct {
+	source string
+	node   *yaml.Node
+}
+
+//manifestObject is an object decoded from a manifest together with the fields set in the manifest
+type manifestObject struct {
+	object metalcloud.Applier
+	fields []string
+}
+
+//fields returns the keys set in the document. Keys of nested objects are joined with dots. kind is not a field.
+func (d manifestDocument) fields() []string {
+	fields := []string{}
+
+	var addFields func(prefix string, node *yaml.Node)
+	addFields = func(prefix string, node *yaml.Node) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := prefix + node.Content[i].Value
+			value := node.Content[i+1]
+			if key == "kind" {
+				continue
+			}
+			if value.Kind == yaml.MappingNode && len(value.Content) > 0 {
+				addFields(key+".", value)
+				continue
+			}
+			fields = append(fields, key)
+		}
+	}
+	addFields("", d.node)
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+//errorf returns an error prefixed with the file:line:column of the given node
+func (d manifestDocument) errorf(node *yaml.Node, format string, a ...interface{}) error {
+	return fmt.Errorf("%s:%d:%d: %s", d.source, node.Line, node.Column, fmt.Sprintf(format, a...))
+}
+
+//readManifestSources reads a manifest file, all the manifest files in a directory (recursively) or stdin if path is "-"
+func readManifestSources(path string) ([]manifestSource, error) {
+	if path == stdinManifestPath {
+		content, err := readInputFromPipe()
+		if err != nil {
+			return nil, err
+		}
+		return []manifestSource{{name: "<stdin>", content: content}}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		content, err := readInputFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []manifestSource{{name: path, content: content}}, nil
+	}
+
+	sources := []manifestSource{}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isManifestFile(p) {
+			return nil
+		}
+
+		content, err := readInputFromFile(p)
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, manifestSource{name: p, content: content})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no manifest files (%s) found in %s", strings.Join(manifestExtensions, ", "), path)
+	}
+
+	return sources, nil
+}
+
+func isManifestFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range manifestExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+//parseManifestDocuments splits a manifest into documents. Supported formats are multi-document yaml,
+//a json object, a json array of objects and newline delimited json.
+func parseManifestDocuments(source manifestSource) ([]manifestDocument, error) {
+	if isNDJSON(source.content) {
+		return parseNDJSONDocuments(source)
+	}
+
+	documents := []manifestDocument{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(source.content))
+
+	for {
+		var node yaml.Node
+
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", source.name, err)
+		}
+
+		docs, err := getDocumentsFromNode(source.name, &node)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, docs...)
+	}
+
+	return documents, nil
+}
+
+//isNDJSON returns true if each non empty line of the content is a json object
+func isNDJSON(content []byte) bool {
+	lines := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' || line[len(line)-1] != '}' {
+			return false
+		}
+		lines++
+	}
+
+	return lines > 1
+}
+
+func parseNDJSONDocuments(source manifestSource) ([]manifestDocument, error) {
+	documents := []manifestDocument{}
+
+	for i, line := range bytes.Split(source.content, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var node yaml.Node
+		if err := yaml.Unmarshal(line, &node); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", source.name, i+1, err)
+		}
+
+		shiftNodeLines(&node, i)
+
+		docs, err := getDocumentsFromNode(source.name, &node)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, docs...)
+	}
+
+	return documents, nil
+}
+
+//shiftNodeLines adds offset to the line of the node and its children
+func shiftNodeLines(node *yaml.Node, offset int) {
+	node.Line += offset
+	for _, n := range node.Content {
+		shiftNodeLines(n, offset)
+	}
+}
+
+//getDocumentsFromNode returns the objects of a yaml document. A document can hold an object or a list of objects.
+func getDocumentsFromNode(sourceName string, node *yaml.Node) ([]manifestDocument, error) {
+	root := node
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, nil
+		}
+		root = root.Content[0]
+	}
+
+	doc := manifestDocument{source: sourceName, node: root}
+
+	switch root.Kind {
+	case yaml.MappingNode:
+		return []manifestDocument{doc}, nil
+
+	case yaml.SequenceNode:
+		documents := []manifestDocument{}
+		for _, item := range root.Content {
+			if item.Kind != yaml.MappingNode {
+				return nil, doc.errorf(item, "expected an object")
+			}
+			documents = append(documents, manifestDocument{source: sourceName, node: item})
+		}
+		return documents, nil
+
+	case yaml.ScalarNode:
+		//empty documents such as the one between two consecutive separators
+		if root.Tag == "!!null" {
+			return nil, nil
+		}
+	}
+
+	return nil, doc.errorf(root, "expected an object")
+}
+
+//getMappingValue returns the value node of a key of a mapping node
+func getMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+//decodeManifestDocument creates the object described by the document and validates it
+func decodeManifestDocument(doc manifestDocument) (metalcloud.Applier, error) {
+	kindNode := getMappingValue(doc.node, "kind")
+	if kindNode == nil {
+		return nil, doc.errorf(doc.node, "property kind is missing")
+	}
+
+	kind := strings.TrimSpace(kindNode.Value)
+
+	newType, err := getObjectByKind(kind)
+	if err != nil {
+		return nil, doc.errorf(kindNode, "%s", err)
+	}
+
+	if err := doc.node.Decode(newType.Interface()); err != nil {
+		return nil, doc.decodeError(err)
+	}
+
+	object, ok := newType.Elem().Interface().(metalcloud.Applier)
+	if !ok {
+		return nil, doc.errorf(kindNode, "kind %s cannot be applied", kind)
+	}
+
+	if err := object.Validate(); err != nil {
+		return nil, doc.errorf(doc.node, "invalid %s: %s", kind, err)
+	}
+
+	return object, nil
+}
+
+var yamlErrorLineRegex = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+//decodeError converts the errors returned by yaml, which only contain the line, into file:line:column errors
+func (d manifestDocument) decodeError(err error) error {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return d.errorf(d.node, "%s", err)
+	}
+
+	messages := []string{}
+	for _, e := range typeErr.Errors {
+		matches := yamlErrorLineRegex.FindStringSubmatch(e)
+		if matches == nil {
+			messages = append(messages, fmt.Sprintf("%s:%d:%d: %s", d.source, d.node.Line, d.node.Column, e))
+			continue
+		}
+
+		line, _ := strconv.Atoi(matches[1])
+		column := findColumnOfLine(d.node, line)
+
+		messages = append(messages, fmt.Sprintf("%s:%d:%d: %s", d.source, line, column, matches[2]))
+	}
+
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+//findColumnOfLine returns the column of the first node found on a line
+func findColumnOfLine(node *yaml.Node, line int) int {
+	if node.Line == line {
+		return node.Column
+	}
+	for _, n := range node.Content {
+		if c := findColumnOfLine(n, line); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseManifestDocuments(t *testing.T) {
+	RegisterTestingT(t)
+
+	//kind in comments and nested fields must be ignored
+	content := `# kind: Datacenter
+kind: Secret
+apiVersion: 1.0
+name: secret-test
+---
+---
+kind: SubnetPool
+id: 100
+tags:
+  kind: SwitchDevice
+`
+
+	documents, err := parseManifestDocuments(manifestSource{name: "test.yaml", content: []byte(content)})
+	Expect(err).To(BeNil())
+	Expect(documents).To(HaveLen(2))
+	Expect(documents[1].node.Line).To(Equal(7))
+
+	object, err := decodeManifestDocument(documents[0])
+	Expect(err).To(BeNil())
+	Expect(object).To(Equal(metalcloud.Secret{SecretName: "secret-test"}))
+
+	//json array
+	content = `[
+  {"kind": "SubnetPool", "id": 100},
+  {"kind": "SubnetPool", "id": 101}
+]`
+	documents, err = parseManifestDocuments(manifestSource{name: "test.json", content: []byte(content)})
+	Expect(err).To(BeNil())
+	Expect(documents).To(HaveLen(2))
+
+	object, err = decodeManifestDocument(documents[1])
+	Expect(err).To(BeNil())
+	Expect(object).To(Equal(_subnetPool2))
+
+	//newline delimited json
+	content = "{\"kind\": \"SubnetPool\", \"id\": 100}\n\n{\"kind\": \"SubnetPool\", \"id\": 101}\n"
+	documents, err = parseManifestDocuments(manifestSource{name: "test.json", content: []byte(content)})
+	Expect(err).To(BeNil())
+	Expect(documents).To(HaveLen(2))
+	Expect(documents[1].node.Line).To(Equal(3))
+
+	object, err = decodeManifestDocument(documents[0])
+	Expect(err).To(BeNil())
+	Expect(object).To(Equal(_subnetPool1))
+}
+
+func TestDecodeManifestDocumentErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		content string
+		prefix  string
+		message string
+	}{
+		{
+			content: "kind: SubnetPool\nid: 100\n---\napiVersion: 1.0\n\nid: 100",
+			prefix:  "test.yaml:4:1: ",
+			message: "property kind is missing",
+		},
+		{
+			content: "apiVersion: 1.0\nkind:   Unknown",
+			prefix:  "test.yaml:2:9: ",
+			message: "Unknown",
+		},
+		{
+			content: "kind: SubnetPool\nid: 100\n---\nkind: SubnetPool\nid: abc",
+			prefix:  "test.yaml:5:1: ",
+			message: "cannot unmarshal !!str `abc` into int",
+		},
+	}
+
+	for _, c := range cases {
+		documents, err := parseManifestDocuments(manifestSource{name: "test.yaml", content: []byte(c.content)})
+		Expect(err).To(BeNil())
+
+		for _, doc := range documents {
+			_, err = decodeManifestDocument(doc)
+			if err != nil {
+				break
+			}
+		}
+
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(HavePrefix(c.prefix))
+		Expect(err.Error()).To(ContainSubstring(c.message))
+	}
+
+	_, err := parseManifestDocuments(manifestSource{name: "test.yaml", content: []byte("[1, 2]")})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal("test.yaml:1:2: expected an object"))
+}
+
+func TestReadManifestSources(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "metalcloud-cli-manifests")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	Expect(os.MkdirAll(filepath.Join(dir, "network"), 0700)).To(BeNil())
+	Expect(ioutil.WriteFile(filepath.Join(dir, "dc.yaml"), []byte(_datacenterFixtureYaml1), 0600)).To(BeNil())
+	Expect(ioutil.WriteFile(filepath.Join(dir, "network", "pools.json"), []byte("[{\"kind\": \"SubnetPool\", \"id\": 100}]"), 0600)).To(BeNil())
+	Expect(ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0600)).To(BeNil())
+
+	sources, err := readManifestSources(dir)
+	Expect(err).To(BeNil())
+	Expect(sources).To(HaveLen(2))
+	Expect(sources[0].name).To(Equal(filepath.Join(dir, "dc.yaml")))
+	Expect(sources[1].name).To(Equal(filepath.Join(dir, "network", "pools.json")))
+
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": dir,
+	})
+
+	objects, err := readObjectsFromCommand(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(objects).To(Equal([]metalcloud.Applier{_datacenter1, _subnetPool1}))
+
+	_, err = readManifestSources(filepath.Join(dir, "network", "missing.yaml"))
+	Expect(err).NotTo(BeNil())
+
+	_, err = readManifestSources(filepath.Join(dir, "network", "pools.json"))
+	Expect(err).To(BeNil())
+
+	empty, err := ioutil.TempDir("", "metalcloud-cli-manifests")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(empty)
+
+	_, err = readManifestSources(empty)
+	Expect(err).NotTo(BeNil())
+
+	var stdin bytes.Buffer
+	var stdout bytes.Buffer
+
+	SetConsoleIOChannel(&stdin, &stdout)
+	defer SetConsoleIOChannel(os.Stdin, os.Stdout)
+
+	stdin.Write([]byte(_subnetPoolFixtureYaml2))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"read_config_from_file": "-",
+	})
+
+	objects, err = readObjectsFromCommand(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(objects).To(Equal([]metalcloud.Applier{_subnetPool2}))
+}
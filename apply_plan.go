@@ -0,0 +1,499 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	planActionCreate    = "create"
+	planActionUpdate    = "update"
+	planActionUnchanged = "unchanged"
+	planActionDelete    = "delete"
+)
+
+//planFieldChange is a field whose value differs between the manifest and the server
+type planFieldChange struct {
+	Field   string
+	Current interface{}
+	Desired interface{}
+}
+
+//planEntry is the outcome of comparing an object from a manifest with its server side counterpart
+type planEntry struct {
+	Kind       string
+	Identifier string
+	Action     string
+	Changes    []planFieldChange
+}
+
+//getObjectKind returns the kind of an object as used in the manifests
+func getObjectKind(object metalcloud.Applier) string {
+	return reflect.TypeOf(object).Name()
+}
+
+//getObjectIDAndLabel returns the id and the label (or name, identifier string etc.) used to look up an object
+func getObjectIDAndLabel(object metalcloud.Applier) (int, string) {
+	switch o := object.(type) {
+	case metalcloud.Datacenter:
+		return o.DatacenterID, o.DatacenterName
+	case metalcloud.DriveArray:
+		return o.DriveArrayID, o.DriveArrayLabel
+	case metalcloud.Infrastructure:
+		return o.InfrastructureID, o.InfrastructureLabel
+	case metalcloud.InstanceArray:
+		return o.InstanceArrayID, o.InstanceArrayLabel
+	case metalcloud.Network:
+		return o.NetworkID, o.NetworkLabel
+	case metalcloud.OSAsset:
+		return o.OSAssetID, o.OSAssetFileName
+	case metalcloud.OSTemplate:
+		return o.VolumeTemplateID, o.VolumeTemplateLabel
+	case metalcloud.Secret:
+		return o.SecretID, o.SecretName
+	case metalcloud.Server:
+		return o.ServerID, o.ServerUUID
+	case metalcloud.SharedDrive:
+		return o.SharedDriveID, o.SharedDriveLabel
+	case metalcloud.StageDefinition:
+		return o.StageDefinitionID, o.StageDefinitionLabel
+	case metalcloud.SubnetPool:
+		return o.SubnetPoolID, ""
+	case metalcloud.SwitchDevice:
+		return o.NetworkEquipmentID, o.NetworkEquipmentIdentifierString
+	case metalcloud.Variable:
+		return o.VariableID, o.VariableName
+	case metalcloud.Workflow:
+		return o.WorkflowID, o.WorkflowLabel
+	case NetworkProfile:
+		return o.NetworkProfileID, o.NetworkProfileLabel
+	case ExternalConnection:
+		return o.ExternalConnectionID, o.ExternalConnectionLabel
+	}
+
+	return 0, ""
+}
+
+//getObjectIdentifier returns a human readable identifier of an object: label, name or id
+func getObjectIdentifier(object metalcloud.Applier) string {
+	id, label := getObjectIDAndLabel(object)
+	if label != "" {
+		return label
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+//isNotFoundError returns true if the error returned by a get call means that the object does not exist
+func isNotFoundError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"not found", "could not be found", "does not exist", "no such"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+//notFoundAsNil returns nil for errors meaning that the object does not exist and the error otherwise
+func notFoundAsNil(err error) error {
+	if err == nil || isNotFoundError(err) {
+		return nil
+	}
+	return err
+}
+
+//hasPasswords returns true if the object sets any password field
+func hasPasswords(object metalcloud.Applier) bool {
+	fields, err := objectToFieldMap(object)
+	if err != nil {
+		return false
+	}
+	for k, v := range fields {
+		if strings.Contains(strings.ToLower(k), "password") && v != nil && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+//getServerSideObject retrieves the object that CreateOrUpdate would update, using the same lookup as the SDK.
+//Returns nil if the object does not exist and would be created. The passwords are decrypted if the object sets any
+//so that they can be compared.
+func getServerSideObject(object metalcloud.Applier, client metalcloud.MetalCloudClient) (interface{}, error) {
+	decrypt := hasPasswords(object)
+
+	switch o := object.(type) {
+	case metalcloud.Datacenter:
+		dc, err := client.DatacenterGet(o.DatacenterName)
+		if err != nil {
+			return nil, notFoundAsNil(err)
+		}
+
+		config, err := client.DatacenterConfigGet(o.DatacenterName)
+		if err != nil {
+			return nil, err
+		}
+		dc.DatacenterConfig = config
+
+		return dc, nil
+
+	case metalcloud.DriveArray:
+		var ret *metalcloud.DriveArray
+		var err error
+		if o.DriveArrayID != 0 {
+			ret, err = client.DriveArrayGet(o.DriveArrayID)
+		} else {
+			ret, err = client.DriveArrayGetByLabel(o.DriveArrayLabel)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.Infrastructure:
+		var ret *metalcloud.Infrastructure
+		var err error
+		if o.InfrastructureID != 0 {
+			ret, err = client.InfrastructureGet(o.InfrastructureID)
+		} else {
+			ret, err = client.InfrastructureGetByLabel(o.InfrastructureLabel)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.InstanceArray:
+		var ret *metalcloud.InstanceArray
+		var err error
+		if o.InstanceArrayID != 0 {
+			ret, err = client.InstanceArrayGet(o.InstanceArrayID)
+		} else {
+			ret, err = client.InstanceArrayGetByLabel(o.InstanceArrayLabel)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.Network:
+		var ret *metalcloud.Network
+		var err error
+		if o.NetworkID != 0 {
+			ret, err = client.NetworkGet(o.NetworkID)
+		} else {
+			ret, err = client.NetworkGetByLabel(o.NetworkLabel)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.SharedDrive:
+		var ret *metalcloud.SharedDrive
+		var err error
+		if o.SharedDriveID != 0 {
+			ret, err = client.SharedDriveGet(o.SharedDriveID)
+		} else {
+			ret, err = client.SharedDriveGetByLabel(o.SharedDriveLabel)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.Server:
+		var ret *metalcloud.Server
+		var err error
+		if o.ServerID != 0 {
+			ret, err = client.ServerGet(o.ServerID, decrypt)
+		} else {
+			ret, err = client.ServerGetByUUID(o.ServerUUID, decrypt)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.SwitchDevice:
+		var ret *metalcloud.SwitchDevice
+		var err error
+		if o.NetworkEquipmentIdentifierString != "" {
+			ret, err = client.SwitchDeviceGetByIdentifierString(o.NetworkEquipmentIdentifierString, decrypt)
+		} else {
+			ret, err = client.SwitchDeviceGet(o.NetworkEquipmentID, decrypt)
+		}
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.SubnetPool:
+		ret, err := client.SubnetPoolGet(o.SubnetPoolID)
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case NetworkProfile:
+		ret, err := o.getServerSideObject(client)
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case ExternalConnection:
+		ret, err := o.getServerSideObject(client)
+		if err != nil || ret == nil {
+			return nil, notFoundAsNil(err)
+		}
+		return ret, nil
+
+	case metalcloud.OSAsset:
+		if o.OSAssetID != 0 {
+			return client.OSAssetGet(o.OSAssetID)
+		}
+		list, err := client.OSAssets()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range *list {
+			if a.OSAssetFileName == o.OSAssetFileName {
+				return a, nil
+			}
+		}
+		return nil, nil
+
+	case metalcloud.OSTemplate:
+		if o.VolumeTemplateID != 0 {
+			return client.OSTemplateGet(o.VolumeTemplateID, false)
+		}
+		list, err := client.OSTemplates()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range *list {
+			if t.VolumeTemplateLabel == o.VolumeTemplateLabel {
+				return t, nil
+			}
+		}
+		return nil, nil
+
+	case metalcloud.Secret:
+		if o.SecretID != 0 {
+			return client.SecretGet(o.SecretID)
+		}
+		list, err := client.Secrets("")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range *list {
+			if s.SecretName == o.SecretName {
+				return s, nil
+			}
+		}
+		return nil, nil
+
+	case metalcloud.StageDefinition:
+		if o.StageDefinitionID != 0 {
+			return client.StageDefinitionGet(o.StageDefinitionID)
+		}
+		list, err := client.StageDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range *list {
+			if s.StageDefinitionLabel == o.StageDefinitionLabel {
+				return s, nil
+			}
+		}
+		return nil, nil
+
+	case metalcloud.Variable:
+		if o.VariableID != 0 {
+			return client.VariableGet(o.VariableID)
+		}
+		list, err := client.Variables("")
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range *list {
+			if v.VariableName == o.VariableName {
+				return v, nil
+			}
+		}
+		return nil, nil
+
+	case metalcloud.Workflow:
+		if o.WorkflowID != 0 {
+			return client.WorkflowGet(o.WorkflowID)
+		}
+		list, err := client.Workflows()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range *list {
+			if w.WorkflowLabel == o.WorkflowLabel {
+				return w, nil
+			}
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("kind %s is not supported in plan mode", getObjectKind(object))
+}
+
+//objectToFieldMap converts an object to a flat map using the yaml tags. Nested objects are flattened using dots.
+func objectToFieldMap(object interface{}) (map[string]interface{}, error) {
+	bytes, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(bytes, &m); err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+
+	var flattenMap func(prefix string, m map[string]interface{})
+	flattenMap = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if nested, ok := v.(map[string]interface{}); ok {
+				flattenMap(prefix+k+".", nested)
+				continue
+			}
+			ret[prefix+k] = v
+		}
+	}
+	flattenMap("", m)
+
+	return ret, nil
+}
+
+//planObject compares an object from a manifest with the one stored server-side.
+//Only the given fields, the ones set in the manifest, are compared. All the fields are compared if fields is nil.
+func planObject(object metalcloud.Applier, fields []string, client metalcloud.MetalCloudClient) (*planEntry, error) {
+	entry := planEntry{
+		Kind:       getObjectKind(object),
+		Identifier: getObjectIdentifier(object),
+	}
+
+	desired, err := objectToFieldMap(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields == nil {
+		fields = sortedKeys(desired)
+	}
+
+	current, err := getServerSideObject(object, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := reflect.ValueOf(current); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		entry.Action = planActionCreate
+		for _, k := range fields {
+			entry.Changes = append(entry.Changes, planFieldChange{
+				Field:   k,
+				Desired: desired[k],
+			})
+		}
+		return &entry, nil
+	}
+
+	currentFields, err := objectToFieldMap(current)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range fields {
+		if !reflect.DeepEqual(desired[k], currentFields[k]) {
+			entry.Changes = append(entry.Changes, planFieldChange{
+				Field:   k,
+				Current: currentFields[k],
+				Desired: desired[k],
+			})
+		}
+	}
+
+	entry.Action = planActionUnchanged
+	if len(entry.Changes) > 0 {
+		entry.Action = planActionUpdate
+	}
+
+	return &entry, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//formatPlanValue renders a field value. Passwords are never printed.
+func formatPlanValue(field string, v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	if strings.Contains(strings.ToLower(field), "password") {
+		return "(sensitive)"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+//renderPlan returns the colored, human readable representation of a plan
+func renderPlan(entries []planEntry) string {
+	var sb strings.Builder
+
+	counts := map[string]int{}
+
+	for _, e := range entries {
+		counts[e.Action]++
+
+		switch e.Action {
+		case planActionCreate:
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", green("+ create"), e.Kind, bold(e.Identifier)))
+			for _, ch := range e.Changes {
+				sb.WriteString(green(fmt.Sprintf("    + %s: %s", ch.Field, formatPlanValue(ch.Field, ch.Desired))) + "\n")
+			}
+		case planActionUpdate:
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", yellow("~ update"), e.Kind, bold(e.Identifier)))
+			for _, ch := range e.Changes {
+				sb.WriteString(fmt.Sprintf("    %s %s: %s => %s\n",
+					yellow("~"),
+					ch.Field,
+					red(formatPlanValue(ch.Field, ch.Current)),
+					green(formatPlanValue(ch.Field, ch.Desired))))
+			}
+		case planActionDelete:
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", red("- delete"), e.Kind, bold(e.Identifier)))
+		default:
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", "= unchanged", e.Kind, e.Identifier))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("Plan: %d to create, %d to update, %d unchanged",
+		counts[planActionCreate],
+		counts[planActionUpdate],
+		counts[planActionUnchanged]))
+
+	if counts[planActionDelete] > 0 {
+		sb.WriteString(fmt.Sprintf(", %d to delete", counts[planActionDelete]))
+	}
+
+	sb.WriteString(".\n")
+
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//getPruneCandidates returns the objects of a datacenter that are not in the manifest.
+//Only the kinds that appear in the manifest are considered so that a manifest with only switches
+//never deletes servers. A kind is not pruned if any of its manifest objects cannot be matched with
+//the existing ones (eg: a SubnetPool without an id), as all the existing objects would be deleted.
+//The objects are returned in the order in which they need to be deleted.
+func getPruneCandidates(objects []metalcloud.Applier, datacenterName string, client metalcloud.MetalCloudClient) ([]metalcloud.Applier, error) {
+	candidates := []metalcloud.Applier{}
+
+	for _, kind := range exportableKinds {
+		manifestObjects := []metalcloud.Applier{}
+		for _, object := range objects {
+			if getObjectKind(object) == kind {
+				manifestObjects = append(manifestObjects, object)
+			}
+		}
+
+		if len(manifestObjects) == 0 {
+			continue
+		}
+
+		for _, object := range manifestObjects {
+			if id, label := getObjectIDAndLabel(object); id == 0 && label == "" {
+				return nil, fmt.Errorf("cannot prune %s objects: the manifest contains a %s without an id so it cannot be matched with the existing ones", kind, kind)
+			}
+		}
+
+		serverObjects, err := getExportedObjects(kind, datacenterName, false, client)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, serverObject := range serverObjects {
+			if !isObjectInList(serverObject, manifestObjects) {
+				candidates = append(candidates, serverObject)
+			}
+		}
+	}
+
+	sortObjectsByKind(candidates, true)
+
+	return candidates, nil
+}
+
+//isObjectInList returns true if an object with the same id or label is in the list
+func isObjectInList(object metalcloud.Applier, list []metalcloud.Applier) bool {
+	id, label := getObjectIDAndLabel(object)
+
+	for _, o := range list {
+		oid, olabel := getObjectIDAndLabel(o)
+
+		if (id != 0 && id == oid) || (label != "" && label == olabel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//confirmPrune asks the user to confirm the deletion of the objects missing from the manifest
+func confirmPrune(c *Command, candidates []metalcloud.Applier, datacenterName string) (bool, error) {
+	return confirmCommand(c, func() string {
+
+		lines := []string{}
+		for _, object := range candidates {
+			lines = append(lines, fmt.Sprintf("  %s %s", getObjectKind(object), getObjectIdentifier(object)))
+		}
+
+		confirmationMessage := fmt.Sprintf("The following objects from datacenter %s are not in the manifest and will be deleted:\n%s\nAre you sure? Type \"yes\" to continue:",
+			datacenterName,
+			strings.Join(lines, "\n"))
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//kindRules holds the rules enforced by the Validate functions of each kind together with the values allowed for some fields.
+//Fields are given using their yaml path, eg: os.architecture.
+type kindRules struct {
+	//required fields must be set
+	required []string
+	//oneOfRequired fields identify the object, at least one of them must be set
+	oneOfRequired []string
+	//enums lists the allowed values of a field
+	enums map[string][]string
+}
+
+var storageTypes = []string{"iscsi_ssd", "iscsi_hdd"}
+
+var kindsRules = map[string]kindRules{
+	"Datacenter": {
+		required: []string{"name"},
+	},
+	"DriveArray": {
+		oneOfRequired: []string{"id", "label"},
+		enums: map[string][]string{
+			"storageType":           storageTypes,
+			"operation.storageType": storageTypes,
+		},
+	},
+	"ExternalConnection": {
+		oneOfRequired: []string{"id", "label"},
+	},
+	"Infrastructure": {
+		oneOfRequired: []string{"id", "label"},
+	},
+	"InstanceArray": {
+		oneOfRequired: []string{"id", "label"},
+	},
+	"Network": {
+		oneOfRequired: []string{"id", "label"},
+	},
+	"NetworkProfile": {
+		oneOfRequired: []string{"id", "label"},
+	},
+	"OSAsset": {
+		oneOfRequired: []string{"id", "fileName"},
+	},
+	"OSTemplate": {
+		oneOfRequired: []string{"id", "label"},
+		required:      []string{"name", "bootType", "os.type"},
+		enums: map[string][]string{
+			"bootType":        {"uefi_only", "legacy_only"},
+			"os.architecture": {"none", "unknown", "x86", "x86_64"},
+		},
+	},
+	"Secret": {
+		oneOfRequired: []string{"id", "name"},
+	},
+	"Server": {
+		oneOfRequired: []string{"id", "uuid"},
+	},
+	"SharedDrive": {
+		oneOfRequired: []string{"id", "label"},
+		enums: map[string][]string{
+			"storageType":           storageTypes,
+			"operation.storageType": storageTypes,
+		},
+	},
+	"StageDefinition": {
+		oneOfRequired: []string{"id", "label"},
+		required:      []string{"type", "title"},
+		enums: map[string][]string{
+			"type": {"HTTPRequest", "AnsibleBundle", "WorkflowReference"},
+		},
+	},
+	"SubnetPool": {
+		required: []string{"id"},
+	},
+	"SwitchDevice": {
+		oneOfRequired: []string{"id", "identifierString"},
+	},
+	"Variable": {
+		oneOfRequired: []string{"id", "name"},
+	},
+	"Workflow": {
+		oneOfRequired: []string{"id", "label"},
+		required:      []string{"usage"},
+	},
+}
+
+//yamlField is a field of a struct as seen by the yaml decoder
+type yamlField struct {
+	name string
+	t    reflect.Type
+}
+
+//getYAMLFields returns the fields of a struct using the same naming rules as the yaml decoder
+func getYAMLFields(t reflect.Type) []yamlField {
+	fields := []yamlField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		inline := false
+		for _, p := range parts[1:] {
+			if p == "inline" {
+				inline = true
+			}
+		}
+
+		if inline && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, getYAMLFields(f.Type)...)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		fields = append(fields, yamlField{name: name, t: f.Type})
+	}
+
+	return fields
+}
+
+//getKindType returns the type of the objects of a kind
+func getKindType(kind string) (reflect.Type, error) {
+	v, err := getObjectByKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	return v.Elem().Type(), nil
+}
+
+//getKindSchema returns the JSON Schema of a kind
+func getKindSchema(kind string) (map[string]interface{}, error) {
+	t, err := getKindType(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := getTypeSchema(t, map[reflect.Type]bool{})
+
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = kind
+
+	properties := schema["properties"].(map[string]interface{})
+	properties["kind"] = map[string]interface{}{
+		"const": kind,
+	}
+	properties["apiVersion"] = map[string]interface{}{
+		"type": []string{"string", "number"},
+	}
+
+	rules := kindsRules[kind]
+
+	required := []string{"kind"}
+
+	for _, path := range rules.required {
+		if !strings.Contains(path, ".") {
+			required = append(required, path)
+			continue
+		}
+
+		//nested required fields are declared on the nested object
+		if s := getPropertySchema(schema, path[:strings.LastIndex(path, ".")]); s != nil {
+			s["required"] = append(getSchemaRequired(s), path[strings.LastIndex(path, ".")+1:])
+		}
+	}
+	schema["required"] = required
+
+	if len(rules.oneOfRequired) > 0 {
+		anyOf := []interface{}{}
+		for _, f := range rules.oneOfRequired {
+			anyOf = append(anyOf, map[string]interface{}{
+				"required": []string{f},
+			})
+		}
+		schema["anyOf"] = anyOf
+	}
+
+	for path, values := range rules.enums {
+		if s := getPropertySchema(schema, path); s != nil {
+			s["enum"] = values
+		}
+	}
+
+	return schema, nil
+}
+
+func getSchemaRequired(s map[string]interface{}) []string {
+	if r, ok := s["required"].([]string); ok {
+		return r
+	}
+	return []string{}
+}
+
+//getPropertySchema returns the schema of a property given by its dotted path
+func getPropertySchema(schema map[string]interface{}, path string) map[string]interface{} {
+	s := schema
+	for _, p := range strings.Split(path, ".") {
+		properties, ok := s["properties"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		s, ok = properties[p].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+	}
+	return s
+}
+
+//getTypeSchema returns the JSON Schema of a go type
+func getTypeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return getTypeSchema(t.Elem(), visiting)
+
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": getTypeSchema(t.Elem(), visiting),
+		}
+
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": getTypeSchema(t.Elem(), visiting),
+		}
+
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		for _, f := range getYAMLFields(t) {
+			properties[f.name] = getTypeSchema(f.t, visiting)
+		}
+
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	}
+
+	//interface{} and other types accept any value
+	return map[string]interface{}{}
+}
+
+//validateManifestDocument strictly validates a document without calling the API.
+//Unknown fields, missing required fields and values not allowed for a field are reported.
+func validateManifestDocument(doc manifestDocument) []error {
+	kindNode := getMappingValue(doc.node, "kind")
+	if kindNode == nil {
+		return []error{doc.errorf(doc.node, "property kind is missing")}
+	}
+
+	kind := strings.TrimSpace(kindNode.Value)
+
+	t, err := getKindType(kind)
+	if err != nil {
+		return []error{doc.errorf(kindNode, "%s", err)}
+	}
+
+	errs := doc.checkUnknownFields(doc.node, t, "", true)
+
+	rules := kindsRules[kind]
+
+	for _, path := range rules.required {
+		if n := getNodeByPath(doc.node, path); n == nil || isEmptyNode(n) {
+			errs = append(errs, doc.errorf(doc.node, "%s is required", path))
+		}
+	}
+
+	if len(rules.oneOfRequired) > 0 {
+		found := false
+		for _, path := range rules.oneOfRequired {
+			if n := getNodeByPath(doc.node, path); n != nil && !isEmptyNode(n) {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, doc.errorf(doc.node, "one of %s is required", strings.Join(rules.oneOfRequired, ", ")))
+		}
+	}
+
+	paths := []string{}
+	for path := range rules.enums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		values := rules.enums[path]
+		n := getNodeByPath(doc.node, path)
+		if n == nil || isEmptyNode(n) {
+			continue
+		}
+		if !stringInSlice(n.Value, values) {
+			errs = append(errs, doc.errorf(n, "invalid value %s for %s. Possible values: %s", n.Value, path, strings.Join(values, ", ")))
+		}
+	}
+
+	//type errors
+	if len(errs) == 0 {
+		if _, err := decodeManifestDocument(doc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+//checkUnknownFields reports the keys of the mapping nodes that do not match a field of the type
+func (d manifestDocument) checkUnknownFields(node *yaml.Node, t reflect.Type, path string, root bool) []error {
+	errs := []error{}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return errs
+		}
+
+		fields := map[string]reflect.Type{}
+		for _, f := range getYAMLFields(t) {
+			fields[f.name] = f.t
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+
+			if root && (key.Value == "kind" || key.Value == "apiVersion") {
+				continue
+			}
+
+			ft, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, d.errorf(key, "unknown field %s%s", path, key.Value))
+				continue
+			}
+
+			errs = append(errs, d.checkUnknownFields(node.Content[i+1], ft, path+key.Value+".", false)...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return errs
+		}
+		for _, item := range node.Content {
+			errs = append(errs, d.checkUnknownFields(item, t.Elem(), path, false)...)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return errs
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, d.checkUnknownFields(node.Content[i+1], t.Elem(), path+node.Content[i].Value+".", false)...)
+		}
+	}
+
+	return errs
+}
+
+//getNodeByPath returns the node of a dotted path in a mapping node
+func getNodeByPath(node *yaml.Node, path string) *yaml.Node {
+	n := node
+	for _, p := range strings.Split(path, ".") {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		n = getMappingValue(n, p)
+	}
+	return n
+}
+
+func isEmptyNode(n *yaml.Node) bool {
+	if n.Kind == yaml.ScalarNode {
+		return n.Tag == "!!null" || n.Value == "" || (n.Tag == "!!int" && n.Value == "0")
+	}
+	return len(n.Content) == 0
+}
+
+//validateManifests validates all the documents and returns a report
+func validateManifests(sources []manifestSource) (string, error) {
+	errs := []string{}
+	count := 0
+
+	for _, source := range sources {
+		documents, err := parseManifestDocuments(source)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		for _, doc := range documents {
+			count++
+			for _, err := range validateManifestDocument(doc) {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return "", fmt.Errorf("%s\n%d errors found.", strings.Join(errs, "\n"), len(errs))
+	}
+
+	return fmt.Sprintf("%d objects are valid.\n", count), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateManifests(t *testing.T) {
+	RegisterTestingT(t)
+
+	content := `kind: SwitchDevice
+apiVersion: 1.0
+identifierString: sw1
+datacenterName: dc1
+provisionerTypo: x
+---
+kind: OSTemplate
+apiVersion: 1.0
+label: t
+bootType: bios
+os:
+  architecture: x86
+  foo: 1
+`
+
+	_, err := validateManifests([]manifestSource{{name: "test.yaml", content: []byte(content)}})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal(`test.yaml:5:1: unknown field provisionerTypo
+test.yaml:13:3: unknown field os.foo
+test.yaml:7:1: name is required
+test.yaml:7:1: os.type is required
+test.yaml:10:11: invalid value bios for bootType. Possible values: uefi_only, legacy_only
+5 errors found.`))
+
+	//identity fields
+	_, err = validateManifests([]manifestSource{{name: "test.yaml", content: []byte("kind: Server\nserverTypeID: 1\n")}})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("test.yaml:1:1: one of id, uuid is required"))
+
+	//type errors are reported after the strict checks pass
+	_, err = validateManifests([]manifestSource{{name: "test.yaml", content: []byte("kind: SubnetPool\nid: abc\n")}})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("test.yaml:2:1: cannot unmarshal"))
+
+	ret, err := validateManifests([]manifestSource{
+		{name: "switch.yaml", content: []byte(_switchDeviceFixtureYaml1)},
+		{name: "subnet.yaml", content: []byte(_subnetPoolFixtureYaml2)},
+	})
+	Expect(err).To(BeNil())
+	Expect(ret).To(Equal("2 objects are valid.\n"))
+}
+
+func TestGetKindSchema(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, kind := range kindsDependencyOrder {
+		schema, err := getKindSchema(kind)
+		Expect(err).To(BeNil())
+
+		_, err = json.Marshal(schema)
+		Expect(err).To(BeNil())
+	}
+
+	schema, err := getKindSchema("OSTemplate")
+	Expect(err).To(BeNil())
+	Expect(schema["title"]).To(Equal("OSTemplate"))
+	Expect(schema["required"]).To(Equal([]string{"kind", "name", "bootType"}))
+	Expect(schema["additionalProperties"]).To(Equal(false))
+	Expect(getPropertySchema(schema, "kind")["const"]).To(Equal("OSTemplate"))
+	Expect(getPropertySchema(schema, "bootType")["enum"]).To(Equal([]string{"uefi_only", "legacy_only"}))
+	Expect(getPropertySchema(schema, "os")["required"]).To(Equal([]string{"type"}))
+
+	schema, err = getKindSchema("SwitchDevice")
+	Expect(err).To(BeNil())
+	Expect(schema["anyOf"]).To(HaveLen(2))
+	Expect(getPropertySchema(schema, "identifierString")["type"]).To(Equal("string"))
+
+	kind := "Unknown"
+	_, err = schemaExportCmd(&Command{Arguments: map[string]interface{}{"kind": &kind}}, nil)
+	Expect(err).NotTo(BeNil())
+
+	kind = "SwitchDevice"
+	ret, err := schemaExportCmd(&Command{Arguments: map[string]interface{}{"kind": &kind}}, nil)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring(`"identifierString"`))
+}
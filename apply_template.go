@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//manifestTemplateFuncs are the functions available in manifest templates in addition to the go template builtins
+var manifestTemplateFuncs = template.FuncMap{
+	//env returns the value of an environment variable or an empty string
+	"env": os.Getenv,
+	//secretFile returns the content of a file without the trailing newline. Used to keep passwords out of the manifests.
+	"secretFile": func(path string) (string, error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	},
+	//quote returns a double quoted string that can be safely used as a yaml or json value
+	"quote": func(v interface{}) string {
+		return strconv.Quote(fmt.Sprintf("%v", v))
+	},
+}
+
+//isTemplatingEnabled returns true if the manifests need to be rendered before being parsed.
+//Rendering is opt-in as manifests can contain {{ }} that are not meant for us (OS templates, ansible etc.).
+func isTemplatingEnabled(c *Command) bool {
+	_, hasValues := getStringParamOk(c.Arguments["values_file"])
+	_, hasSet := getStringParamOk(c.Arguments["set_values"])
+
+	return hasValues || hasSet || getBoolParam(c.Arguments["template"]) || getBoolParam(c.Arguments["render_only"])
+}
+
+//getTemplateValuesFromCommand reads the values files and applies the --set overrides
+func getTemplateValuesFromCommand(c *Command) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	if files, ok := getStringParamOk(c.Arguments["values_file"]); ok {
+		for _, file := range strings.Split(files, ",") {
+			content, err := readInputFromFile(strings.TrimSpace(file))
+			if err != nil {
+				return nil, err
+			}
+
+			fileValues := map[string]interface{}{}
+			if err := yaml.Unmarshal(content, &fileValues); err != nil {
+				return nil, fmt.Errorf("could not parse values file %s: %s", file, err)
+			}
+
+			mergeTemplateValues(values, fileValues)
+		}
+	}
+
+	if set, ok := getStringParamOk(c.Arguments["set_values"]); ok {
+		for _, pair := range strings.Split(set, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, fmt.Errorf("invalid --set value %s. Expected key=value", pair)
+			}
+
+			setTemplateValue(values, strings.TrimSpace(kv[0]), kv[1])
+		}
+	}
+
+	return values, nil
+}
+
+//mergeTemplateValues copies the values from src into dst. Nested maps are merged, other values are overwritten.
+func mergeTemplateValues(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+
+		if srcIsMap && dstIsMap {
+			mergeTemplateValues(dstMap, srcMap)
+			continue
+		}
+
+		dst[k] = v
+	}
+}
+
+//setTemplateValue sets a value using a dotted key such as switch.password
+func setTemplateValue(values map[string]interface{}, key string, value string) {
+	parts := strings.Split(key, ".")
+
+	m := values
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[p] = next
+		}
+		m = next
+	}
+
+	m[parts[len(parts)-1]] = value
+}
+
+//renderManifestTemplate renders the manifest as a go template. The values are available as .Values
+//and the environment variables as .Env. Missing values are reported as errors.
+func renderManifestTemplate(source manifestSource, values map[string]interface{}) (manifestSource, error) {
+	t, err := template.New(source.name).
+		Option("missingkey=error").
+		Funcs(manifestTemplateFuncs).
+		Parse(string(source.content))
+
+	if err != nil {
+		return source, err
+	}
+
+	env := map[string]interface{}{}
+	for _, e := range os.Environ() {
+		kv := strings.SplitN(e, "=", 2)
+		env[kv[0]] = kv[1]
+	}
+
+	data := map[string]interface{}{
+		"Values": values,
+		"Env":    env,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return source, err
+	}
+
+	return manifestSource{name: source.name, content: buf.Bytes()}, nil
+}
+
+//renderManifestSources renders all the manifests if templating was requested
+func renderManifestSources(c *Command, sources []manifestSource) ([]manifestSource, error) {
+	if !isTemplatingEnabled(c) {
+		return sources, nil
+	}
+
+	values, err := getTemplateValuesFromCommand(c)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := []manifestSource{}
+	for _, source := range sources {
+		r, err := renderManifestTemplate(source, values)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, r)
+	}
+
+	return rendered, nil
+}
+
+//joinRenderedManifests returns the rendered manifests as a single multi-document manifest
+func joinRenderedManifests(sources []manifestSource) string {
+	var sb strings.Builder
+
+	for i, source := range sources {
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.WriteString(fmt.Sprintf("# Source: %s\n", source.name))
+		sb.WriteString(strings.TrimRight(string(source.content), "\n"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	. "github.com/onsi/gomega"
+)
+
+const _switchDeviceTemplateYaml = `kind: SwitchDevice
+apiVersion: 1.0
+identifierString: {{ .Values.switch.identifier }}
+datacenterName: {{ .Values.site }}
+managementUsername: {{ env "METALCLOUD_TEST_SWITCH_USER" }}
+managementPassword: {{ secretFile .Values.switch.passwordFile | quote }}
+`
+
+func TestApplyTemplating(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "metalcloud-cli-template")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	passwordFile := filepath.Join(dir, "password")
+	manifestFile := filepath.Join(dir, "switch.yaml")
+	valuesFile := filepath.Join(dir, "site.yaml")
+
+	Expect(ioutil.WriteFile(passwordFile, []byte("p@ss: word\n"), 0600)).To(BeNil())
+	Expect(ioutil.WriteFile(manifestFile, []byte(_switchDeviceTemplateYaml), 0600)).To(BeNil())
+	Expect(ioutil.WriteFile(valuesFile, []byte("site: dc1\nswitch:\n  identifier: sw1\n  passwordFile: "+passwordFile+"\n"), 0600)).To(BeNil())
+
+	os.Setenv("METALCLOUD_TEST_SWITCH_USER", "admin")
+	defer os.Unsetenv("METALCLOUD_TEST_SWITCH_USER")
+
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": manifestFile,
+		"values_file":           valuesFile,
+		"set_values":            "switch.identifier=sw2",
+	})
+
+	objects, err := readObjectsFromCommand(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(objects).To(HaveLen(1))
+
+	sw := objects[0].(metalcloud.SwitchDevice)
+	Expect(sw.NetworkEquipmentIdentifierString).To(Equal("sw2"))
+	Expect(sw.DatacenterName).To(Equal("dc1"))
+	Expect(sw.NetworkEquipmentManagementUsername).To(Equal("admin"))
+	Expect(sw.NetworkEquipmentManagementPassword).To(Equal("p@ss: word"))
+
+	bTrue := true
+	cmd.Arguments["render_only"] = &bTrue
+
+	ret, err := applyCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(ret).To(HavePrefix("# Source: " + manifestFile + "\n"))
+	Expect(ret).To(ContainSubstring("identifierString: sw2\n"))
+	Expect(ret).To(ContainSubstring("managementPassword: \"p@ss: word\"\n"))
+
+	//missing values are errors
+	cmd = MakeCommand(map[string]interface{}{
+		"read_config_from_file": manifestFile,
+		"set_values":            "site=dc1",
+	})
+
+	_, err = readObjectsFromCommand(&cmd, nil)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"read_config_from_file": manifestFile,
+		"set_values":            "site",
+	})
+
+	_, err = readObjectsFromCommand(&cmd, nil)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestApplyTemplatingOptIn(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "metalcloud-cli-template")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	manifestFile := filepath.Join(dir, "switch.yaml")
+
+	Expect(ioutil.WriteFile(manifestFile, []byte(`kind: SwitchDevice
+apiVersion: 1.0
+identifierString: sw1
+datacenterName: dc1
+managementPassword: "{{ .Env.METALCLOUD_TEST_SWITCH_PASSWORD }}"
+`), 0600)).To(BeNil())
+
+	os.Setenv("METALCLOUD_TEST_SWITCH_PASSWORD", "p@ss")
+	defer os.Unsetenv("METALCLOUD_TEST_SWITCH_PASSWORD")
+
+	//without --values, --set or --template the manifest is used as is
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": manifestFile,
+	})
+
+	objects, err := readObjectsFromCommand(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(objects).To(HaveLen(1))
+	Expect(objects[0].(metalcloud.SwitchDevice).NetworkEquipmentManagementPassword).To(Equal("{{ .Env.METALCLOUD_TEST_SWITCH_PASSWORD }}"))
+
+	bTrue := true
+	cmd.Arguments["template"] = &bTrue
+
+	objects, err = readObjectsFromCommand(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(objects).To(HaveLen(1))
+	Expect(objects[0].(metalcloud.SwitchDevice).NetworkEquipmentManagementPassword).To(Equal("p@ss"))
+}
+
+func TestMergeTemplateValues(t *testing.T) {
+	RegisterTestingT(t)
+
+	values := map[string]interface{}{
+		"site": "dc1",
+		"switch": map[string]interface{}{
+			"identifier": "sw1",
+			"vendor":     "hp",
+		},
+	}
+
+	mergeTemplateValues(values, map[string]interface{}{
+		"switch": map[string]interface{}{
+			"identifier": "sw2",
+		},
+	})
+
+	setTemplateValue(values, "switch.address.ip", "10.0.0.1")
+	setTemplateValue(values, "site", "dc2")
+
+	Expect(values).To(Equal(map[string]interface{}{
+		"site": "dc2",
+		"switch": map[string]interface{}{
+			"identifier": "sw2",
+			"vendor":     "hp",
+			"address": map[string]interface{}{
+				"ip": "10.0.0.1",
+			},
+		},
+	}))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//cablingLink is a cable between a server interface and a switch interface
+type cablingLink struct {
+	serverID        int
+	serialNumber    string
+	interfaceIndex  int
+	macAddress      string
+	switchID        int
+	switchName      string
+	switchInterface string
+	capacityMbps    int
+}
+
+//switchPort returns the switch end of the link as switch:interface
+func (l cablingLink) switchPort() string {
+	return fmt.Sprintf("%s:%s", l.switchName, l.switchInterface)
+}
+
+//cablingPlanColumns are the columns of a cabling plan file
+var cablingPlanColumns = []string{"serial_number", "interface", "switch", "switch_interface"}
+
+//getDatacenterServers returns the servers of a datacenter that are not decommissioned, indexed by id.
+//The search is a full text one so the datacenter of the results is checked as well.
+func getDatacenterServers(datacenter string, client metalcloud.MetalCloudClient) (map[int]metalcloud.ServerSearchResult, error) {
+	list, err := client.ServersSearch("+datacenter_name:" + datacenter)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := map[int]metalcloud.ServerSearchResult{}
+	for _, s := range *list {
+		if s.DatacenterName != datacenter || s.ServerStatus == "decommissioned" {
+			continue
+		}
+		servers[s.ServerID] = s
+	}
+
+	return servers, nil
+}
+
+//getDatacenterCabling returns the links of the servers of a datacenter as registered by the switches
+func getDatacenterCabling(servers map[int]metalcloud.ServerSearchResult, client metalcloud.MetalCloudClient) ([]cablingLink, error) {
+	list, err := client.SwitchInterfaceSearch("*")
+	if err != nil {
+		return nil, err
+	}
+
+	links := []cablingLink{}
+	for _, i := range *list {
+		s, ok := servers[i.ServerID]
+		if !ok {
+			continue
+		}
+
+		links = append(links, cablingLink{
+			serverID:        i.ServerID,
+			serialNumber:    s.ServerSerialNumber,
+			interfaceIndex:  i.ServerInterfaceIndex,
+			macAddress:      i.ServerInterfaceMACAddress,
+			switchID:        i.NetworkEquipmentID,
+			switchName:      i.NetworkEquipmentIdentifierString,
+			switchInterface: i.NetworkEquipmentInterfaceIdentifierString,
+			capacityMbps:    i.ServerInterfaceCapacityMBPs,
+		})
+	}
+
+	return links, nil
+}
+
+//getServerInterfacesWithoutLinks returns the interfaces of the servers that are not linked to a switch interface as links
+//without a switch end. The index of an interface is its position in the interfaces of the server.
+func getServerInterfacesWithoutLinks(servers map[int]metalcloud.ServerSearchResult, links []cablingLink, client metalcloud.MetalCloudClient) ([]cablingLink, error) {
+	linked := map[string]bool{}
+	for _, l := range links {
+		linked[fmt.Sprintf("%d:%d", l.serverID, l.interfaceIndex)] = true
+	}
+
+	ids := []int{}
+	for id := range servers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	ret := []cablingLink{}
+	for _, id := range ids {
+		server, err := client.ServerGet(id, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, intf := range server.ServerInterfaces {
+			if linked[fmt.Sprintf("%d:%d", id, i)] {
+				continue
+			}
+
+			ret = append(ret, cablingLink{
+				serverID:       id,
+				serialNumber:   servers[id].ServerSerialNumber,
+				interfaceIndex: i,
+				macAddress:     intf.ServerInterfaceMACAddress,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+//parseCablingPlan reads a csv cabling plan. The first line must be a header with the cablingPlanColumns in any order.
+func parseCablingPlan(content []byte) ([]cablingLink, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the cabling plan is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !stringInSlice(name, cablingPlanColumns) {
+			return nil, fmt.Errorf("unknown column %s. Supported columns are %s", name, strings.Join(cablingPlanColumns, ","))
+		}
+		columns[name] = i
+	}
+
+	for _, name := range cablingPlanColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is required", name)
+		}
+	}
+
+	links := []cablingLink{}
+	planned := map[string]int{}
+
+	for i, record := range records[1:] {
+		line := i + 2
+
+		l := cablingLink{
+			serialNumber:    strings.TrimSpace(record[columns["serial_number"]]),
+			switchName:      strings.TrimSpace(record[columns["switch"]]),
+			switchInterface: strings.TrimSpace(record[columns["switch_interface"]]),
+		}
+
+		if l.serialNumber == "" || l.switchName == "" || l.switchInterface == "" {
+			return nil, fmt.Errorf("line %d: serial_number, switch and switch_interface cannot be empty", line)
+		}
+
+		l.interfaceIndex, err = strconv.Atoi(strings.TrimSpace(record[columns["interface"]]))
+		if err != nil || l.interfaceIndex < 0 {
+			return nil, fmt.Errorf("line %d: invalid interface index %s", line, record[columns["interface"]])
+		}
+
+		key := cablingServerPortKey(l.serialNumber, l.interfaceIndex)
+		if prev, ok := planned[key]; ok {
+			return nil, fmt.Errorf("line %d: interface %d of server %s is already planned on line %d", line, l.interfaceIndex, l.serialNumber, prev)
+		}
+		planned[key] = line
+
+		links = append(links, l)
+	}
+
+	return links, nil
+}
+
+func cablingServerPortKey(serialNumber string, interfaceIndex int) string {
+	return fmt.Sprintf("%s/%d", strings.ToLower(serialNumber), interfaceIndex)
+}
+
+//cablingDifference is a link of the plan or of the datacenter that does not match the other side
+type cablingDifference struct {
+	status         string
+	serialNumber   string
+	serverID       int
+	interfaceIndex int
+	expected       string
+	actual         string
+}
+
+//compareCabling checks the actual links against the plan. Interfaces of servers that are not in the plan are not checked.
+//Links are ok, missing (planned but not cabled), extra (cabled but not planned) or mis-patched (cabled to another switch port).
+func compareCabling(plan []cablingLink, actual []cablingLink, servers map[int]metalcloud.ServerSearchResult) []cablingDifference {
+	serverIDs := map[string]int{}
+	for _, s := range servers {
+		serverIDs[strings.ToLower(s.ServerSerialNumber)] = s.ServerID
+	}
+
+	actualLinks := map[string]cablingLink{}
+	for _, l := range actual {
+		actualLinks[cablingServerPortKey(l.serialNumber, l.interfaceIndex)] = l
+	}
+
+	plannedServers := map[string]bool{}
+	plannedLinks := map[string]bool{}
+	ret := []cablingDifference{}
+
+	for _, p := range plan {
+		key := cablingServerPortKey(p.serialNumber, p.interfaceIndex)
+		plannedServers[strings.ToLower(p.serialNumber)] = true
+		plannedLinks[key] = true
+
+		d := cablingDifference{
+			serialNumber:   p.serialNumber,
+			serverID:       serverIDs[strings.ToLower(p.serialNumber)],
+			interfaceIndex: p.interfaceIndex,
+			expected:       p.switchPort(),
+		}
+
+		a, ok := actualLinks[key]
+
+		switch {
+		case d.serverID == 0:
+			d.status = "missing"
+			d.actual = "server not found"
+		case !ok:
+			d.status = "missing"
+		case strings.EqualFold(a.switchName, p.switchName) && strings.EqualFold(a.switchInterface, p.switchInterface):
+			d.status = "ok"
+			d.actual = a.switchPort()
+		default:
+			d.status = "mis-patched"
+			d.actual = a.switchPort()
+		}
+
+		ret = append(ret, d)
+	}
+
+	for _, a := range actual {
+		key := cablingServerPortKey(a.serialNumber, a.interfaceIndex)
+		if !plannedServers[strings.ToLower(a.serialNumber)] || plannedLinks[key] {
+			continue
+		}
+
+		ret = append(ret, cablingDifference{
+			status:         "extra",
+			serialNumber:   a.serialNumber,
+			serverID:       a.serverID,
+			interfaceIndex: a.interfaceIndex,
+			actual:         a.switchPort(),
+		})
+	}
+
+	return ret
+}
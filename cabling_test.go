@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseCablingPlan(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		name    string
+		content string
+		links   int
+		good    bool
+	}{
+		{
+			name:    "good",
+			content: "serial_number,interface,switch,switch_interface\nSN1,0,leaf01,Ethernet1/1\nSN1,1,leaf02,Ethernet1/1\n",
+			links:   2,
+			good:    true,
+		},
+		{
+			name:    "columns in another order",
+			content: "Switch, Switch_Interface, Serial_Number, Interface\nleaf01,Ethernet1/1,SN1,0\n",
+			links:   1,
+			good:    true,
+		},
+		{
+			name:    "header only",
+			content: "serial_number,interface,switch,switch_interface\n",
+			links:   0,
+			good:    true,
+		},
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name:    "missing column",
+			content: "serial_number,interface,switch\nSN1,0,leaf01\n",
+		},
+		{
+			name:    "unknown column",
+			content: "serial_number,interface,switch,switch_interface,rack\nSN1,0,leaf01,Ethernet1/1,R1\n",
+		},
+		{
+			name:    "invalid interface",
+			content: "serial_number,interface,switch,switch_interface\nSN1,eth0,leaf01,Ethernet1/1\n",
+		},
+		{
+			name:    "empty switch",
+			content: "serial_number,interface,switch,switch_interface\nSN1,0,,Ethernet1/1\n",
+		},
+		{
+			name:    "duplicate interface",
+			content: "serial_number,interface,switch,switch_interface\nSN1,0,leaf01,Ethernet1/1\nsn1,0,leaf02,Ethernet1/1\n",
+		},
+		{
+			name:    "wrong number of fields",
+			content: "serial_number,interface,switch,switch_interface\nSN1,0,leaf01\n",
+		},
+	}
+
+	for _, c := range cases {
+		links, err := parseCablingPlan([]byte(c.content))
+		if !c.good {
+			if err == nil {
+				t.Errorf("case %s: expected error", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("case %s: unexpected error %s", c.name, err)
+			continue
+		}
+
+		Expect(links).To(HaveLen(c.links))
+	}
+}
+
+func TestCompareCabling(t *testing.T) {
+	RegisterTestingT(t)
+
+	servers := map[int]metalcloud.ServerSearchResult{
+		1: {ServerID: 1, ServerSerialNumber: "SN1"},
+		2: {ServerID: 2, ServerSerialNumber: "SN2"},
+		3: {ServerID: 3, ServerSerialNumber: "SN3"},
+	}
+
+	actual := []cablingLink{
+		{serverID: 1, serialNumber: "SN1", interfaceIndex: 0, switchName: "leaf01", switchInterface: "Ethernet1/1"},
+		{serverID: 1, serialNumber: "SN1", interfaceIndex: 1, switchName: "leaf02", switchInterface: "Ethernet1/2"},
+		{serverID: 1, serialNumber: "SN1", interfaceIndex: 2, switchName: "leaf01", switchInterface: "Ethernet1/3"},
+		{serverID: 2, serialNumber: "SN2", interfaceIndex: 0, switchName: "leaf01", switchInterface: "Ethernet1/2"},
+		//server 3 is not in the plan so its links are not checked
+		{serverID: 3, serialNumber: "SN3", interfaceIndex: 0, switchName: "leaf01", switchInterface: "Ethernet1/9"},
+	}
+
+	plan := []cablingLink{
+		{serialNumber: "sn1", interfaceIndex: 0, switchName: "LEAF01", switchInterface: "ethernet1/1"},
+		{serialNumber: "SN1", interfaceIndex: 1, switchName: "leaf02", switchInterface: "Ethernet1/1"},
+		{serialNumber: "SN2", interfaceIndex: 0, switchName: "leaf01", switchInterface: "Ethernet1/2"},
+		{serialNumber: "SN2", interfaceIndex: 1, switchName: "leaf02", switchInterface: "Ethernet1/2"},
+		{serialNumber: "SN4", interfaceIndex: 0, switchName: "leaf02", switchInterface: "Ethernet1/4"},
+	}
+
+	expected := []cablingDifference{
+		{status: "ok", serialNumber: "sn1", serverID: 1, interfaceIndex: 0, expected: "LEAF01:ethernet1/1", actual: "leaf01:Ethernet1/1"},
+		{status: "mis-patched", serialNumber: "SN1", serverID: 1, interfaceIndex: 1, expected: "leaf02:Ethernet1/1", actual: "leaf02:Ethernet1/2"},
+		{status: "ok", serialNumber: "SN2", serverID: 2, interfaceIndex: 0, expected: "leaf01:Ethernet1/2", actual: "leaf01:Ethernet1/2"},
+		{status: "missing", serialNumber: "SN2", serverID: 2, interfaceIndex: 1, expected: "leaf02:Ethernet1/2"},
+		{status: "missing", serialNumber: "SN4", interfaceIndex: 0, expected: "leaf02:Ethernet1/4", actual: "server not found"},
+		{status: "extra", serialNumber: "SN1", serverID: 1, interfaceIndex: 2, actual: "leaf01:Ethernet1/3"},
+	}
+
+	Expect(compareCabling(plan, actual, servers)).To(Equal(expected))
+}
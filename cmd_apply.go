@@ -3,15 +3,13 @@ package main
 import (
 	"flag"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
-	"gopkg.in/yaml.v3"
+	"github.com/metalsoft-io/tableformatter"
 )
 
-const yamlSeparator = "\n---"
-
 //infrastructureCmds commands affecting infrastructures
 var applyCmds = []Command{
 
@@ -24,11 +22,31 @@ var applyCmds = []Command{
 		FlagSet:      flag.NewFlagSet("apply", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, "The file "),
+				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, red("(Required)")+" The manifest file. Multi-document yaml, json objects, json arrays and newline delimited json are supported. Use '-' to read from stdin. If a directory is given all the .yaml, .yml and .json files in it are read recursively."),
+				"values_file":           c.FlagSet.String("values", _nilDefaultStr, "Comma separated list of yaml files with values used to render the manifest as a go template. Values are available as {{ .Values.key }}, environment variables as {{ .Env.NAME }}. The {{ secretFile \"path\" }} function returns the content of a file."),
+				"set_values":            c.FlagSet.String("set", _nilDefaultStr, "Comma separated list of key=value pairs used to render the manifest. They override the values from the --values files. Eg: --set site.name=dc1,switch.password=pass"),
+				"template":              c.FlagSet.Bool("template", false, green("(Flag)")+" If set the manifest is rendered as a go template even if no --values or --set are given. Manifests are not rendered otherwise, so content such as {{ }} in OS templates is kept as is."),
+				"render_only":           c.FlagSet.Bool("render-only", false, green("(Flag)")+" If set the rendered manifest is printed and nothing is applied."),
+				"dry_run":               c.FlagSet.Bool("dry-run", false, green("(Flag)")+" If set the changes are not applied. Instead, each object is compared with the one stored server-side and the differences are printed."),
+				"validate_only":         c.FlagSet.Bool("validate-only", false, green("(Flag)")+" If set the manifest is validated without calling the API. Unknown fields, missing required fields and invalid values are reported. Use 'schema export' to get the JSON Schema of a kind."),
+				"continue_on_error":     c.FlagSet.Bool("continue-on-error", false, green("(Flag)")+" If set the remaining objects are applied even if some of them fail. A table with the result for each object is printed at the end."),
+				"format":                tableFormatFlag(c, _nilDefaultStr, "The output format used with --continue-on-error. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"prune":                 c.FlagSet.Bool("prune", false, green("(Flag)")+" If set the objects of the datacenter that are not in the manifest are deleted. Only the "+strings.Join(exportableKinds, ", ")+" kinds that appear in the manifest are pruned. Requires --datacenter."),
+				"datacenter_name":       c.FlagSet.String("datacenter", _nilDefaultStr, "The datacenter to prune. Required with --prune."),
+				"autoconfirm":           c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
 			}
 		},
 		ExecuteFunc: applyCmd,
 		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli apply -f resources.yaml
+metalcloud-cli apply -f resources.yaml --dry-run
+metalcloud-cli apply -f resources.yaml --validate-only
+metalcloud-cli apply -f datacenter.yaml --continue-on-error
+metalcloud-cli apply -f switch.yaml --values site1.yaml --set switch.identifier=sw1 --render-only
+metalcloud-cli apply -f switch.yaml --template
+metalcloud-cli apply -f uk-reading/ --prune --datacenter uk-reading --dry-run
+		`,
 	},
 
 	{
@@ -40,29 +58,110 @@ var applyCmds = []Command{
 		FlagSet:      flag.NewFlagSet("apply", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, "The file "),
+				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, red("(Required)")+" The manifest file. Multi-document yaml, json objects, json arrays and newline delimited json are supported. Use '-' to read from stdin. If a directory is given all the .yaml, .yml and .json files in it are read recursively."),
+				"values_file":           c.FlagSet.String("values", _nilDefaultStr, "Comma separated list of yaml files with values used to render the manifest as a go template. Values are available as {{ .Values.key }}, environment variables as {{ .Env.NAME }}. The {{ secretFile \"path\" }} function returns the content of a file."),
+				"set_values":            c.FlagSet.String("set", _nilDefaultStr, "Comma separated list of key=value pairs used to render the manifest. They override the values from the --values files. Eg: --set site.name=dc1,switch.password=pass"),
+				"template":              c.FlagSet.Bool("template", false, green("(Flag)")+" If set the manifest is rendered as a go template even if no --values or --set are given. Manifests are not rendered otherwise, so content such as {{ }} in OS templates is kept as is."),
+				"continue_on_error":     c.FlagSet.Bool("continue-on-error", false, green("(Flag)")+" If set the remaining objects are deleted even if some of them fail. A table with the result for each object is printed at the end."),
+				"format":                tableFormatFlag(c, _nilDefaultStr, "The output format used with --continue-on-error. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: deleteCmd,
 		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli delete -f resources.yaml
+metalcloud-cli delete -f resources.yaml --continue-on-error
+		`,
 	},
 }
 
 func applyCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
-	objects, err := readObjectsFromCommand(c, client)
+	if getBoolParam(c.Arguments["render_only"]) {
+		sources, err := readManifestSourcesFromCommand(c)
+		if err != nil {
+			return "", err
+		}
+
+		return joinRenderedManifests(sources), nil
+	}
+
+	if getBoolParam(c.Arguments["validate_only"]) {
+		sources, err := readManifestSourcesFromCommand(c)
+		if err != nil {
+			return "", err
+		}
+
+		return validateManifests(sources)
+	}
+
+	manifestObjects, err := readManifestObjectsFromCommand(c)
 
 	if err != nil {
 		return "", err
 	}
 
-	for _, object := range objects {
-		err = object.CreateOrUpdate(client)
+	sortManifestObjectsByKind(manifestObjects, false)
+
+	objects := []metalcloud.Applier{}
+	for _, o := range manifestObjects {
+		objects = append(objects, o.object)
+	}
+
+	pruned := []metalcloud.Applier{}
+	datacenterName, hasDatacenter := getStringParamOk(c.Arguments["datacenter_name"])
+
+	if getBoolParam(c.Arguments["prune"]) {
+		if !hasDatacenter {
+			return "", fmt.Errorf("-datacenter is required when using --prune")
+		}
+
+		pruned, err = getPruneCandidates(objects, datacenterName, client)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	return "", nil
+	if getBoolParam(c.Arguments["dry_run"]) {
+		entries := []planEntry{}
+		for _, o := range manifestObjects {
+			entry, err := planObject(o.object, o.fields, client)
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, *entry)
+		}
+
+		for _, object := range pruned {
+			entries = append(entries, planEntry{
+				Kind:       getObjectKind(object),
+				Identifier: getObjectIdentifier(object),
+				Action:     planActionDelete,
+			})
+		}
+
+		return renderPlan(entries), nil
+	}
+
+	if len(pruned) > 0 {
+		confirm, err := confirmPrune(c, pruned, datacenterName)
+		if err != nil {
+			return "", err
+		}
+
+		if !confirm {
+			return "", fmt.Errorf("Operation not confirmed. Aborting")
+		}
+	}
+
+	actions := newObjectActions(objects, "applied", func(object metalcloud.Applier) error {
+		return object.CreateOrUpdate(client)
+	})
+
+	actions = append(actions, newObjectActions(pruned, "deleted", func(object metalcloud.Applier) error {
+		return object.Delete(client)
+	})...)
+
+	return runObjectActions(c, actions)
 }
 
 func deleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -72,74 +171,219 @@ func deleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 		return "", err
 	}
 
+	sortObjectsByKind(objects, true)
+
+	return runObjectActions(c, newObjectActions(objects, "deleted", func(object metalcloud.Applier) error {
+		return object.Delete(client)
+	}))
+}
+
+//kindsDependencyOrder lists the kinds in the order in which they need to be created.
+//Objects are deleted in the reverse order. Kinds not in the list are created last.
+var kindsDependencyOrder = []string{
+	"Datacenter",
+	"SubnetPool",
+	"SwitchDevice",
+	"ExternalConnection",
+	"NetworkProfile",
+	"Server",
+	"Secret",
+	"Variable",
+	"OSAsset",
+	"OSTemplate",
+	"StageDefinition",
+	"Workflow",
+	"Infrastructure",
+	"Network",
+	"InstanceArray",
+	"DriveArray",
+	"SharedDrive",
+}
+
+func getKindDependencyRank(kind string) int {
+	for i, k := range kindsDependencyOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindsDependencyOrder)
+}
+
+//kindDependencyLess returns true if objects of kind ki come before objects of kind kj
+func kindDependencyLess(ki string, kj string, reverse bool) bool {
+	ri := getKindDependencyRank(ki)
+	rj := getKindDependencyRank(kj)
+	if reverse {
+		return ri > rj
+	}
+	return ri < rj
+}
+
+//sortObjectsByKind sorts objects by kind dependency, keeping the file order for objects of the same kind
+func sortObjectsByKind(objects []metalcloud.Applier, reverse bool) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return kindDependencyLess(getObjectKind(objects[i]), getObjectKind(objects[j]), reverse)
+	})
+}
+
+//sortManifestObjectsByKind is sortObjectsByKind for objects read together with their manifest fields
+func sortManifestObjectsByKind(objects []manifestObject, reverse bool) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return kindDependencyLess(getObjectKind(objects[i].object), getObjectKind(objects[j].object), reverse)
+	})
+}
+
+//objectAction is an operation performed on an object, such as applying or deleting it
+type objectAction struct {
+	object metalcloud.Applier
+	//name is the past tense of the operation shown in the results, eg: applied
+	name string
+	run  func(object metalcloud.Applier) error
+}
+
+//newObjectActions returns the same operation for each of the objects
+func newObjectActions(objects []metalcloud.Applier, name string, run func(object metalcloud.Applier) error) []objectAction {
+	actions := []objectAction{}
 	for _, object := range objects {
-		if err != nil {
-			return "", err
+		actions = append(actions, objectAction{
+			object: object,
+			name:   name,
+			run:    run,
+		})
+	}
+	return actions
+}
+
+//runObjectActions runs the actions in order. It stops at the first error unless --continue-on-error is set
+//in which case a table with the result for each object is returned.
+func runObjectActions(c *Command, actions []objectAction) (string, error) {
+	continueOnError := getBoolParam(c.Arguments["continue_on_error"])
+
+	data := [][]interface{}{}
+	failed := 0
+
+	for i, action := range actions {
+		kind := getObjectKind(action.object)
+		identifier := getObjectIdentifier(action.object)
+
+		err := action.run(action.object)
+
+		if err != nil && !continueOnError {
+			return "", fmt.Errorf("%s %s: %s (%d of %d objects processed before the error)", kind, identifier, err, i, len(actions))
 		}
-		err := object.Delete(client)
 
+		result := action.name
+		errStr := ""
 		if err != nil {
-			return "", err
+			result = "failed"
+			errStr = err.Error()
+			failed++
 		}
+
+		data = append(data, []interface{}{
+			kind,
+			identifier,
+			result,
+			errStr,
+		})
 	}
-	return "", nil
-}
 
-func readObjectsFromCommand(c *Command, client metalcloud.MetalCloudClient) ([]metalcloud.Applier, error) {
-	var err error
-	content := []byte{}
-	var results []metalcloud.Applier
+	if !continueOnError {
+		return "", nil
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "KIND",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "IDENTIFIER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "ACTION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "ERROR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
 
-	if filePath, ok := getStringParamOk(c.Arguments["read_config_from_file"]); ok {
-		content, err = readInputFromFile(filePath)
-	} else {
+	topLine := fmt.Sprintf("%d of %d objects processed, %d failed", len(actions)-failed, len(actions), failed)
+
+	return renderTable(c, table, "Objects", topLine)
+}
+
+//readManifestSourcesFromCommand reads the manifests given with -f and renders them if needed
+func readManifestSourcesFromCommand(c *Command) ([]manifestSource, error) {
+	filePath, ok := getStringParamOk(c.Arguments["read_config_from_file"])
+	if !ok {
 		return nil, fmt.Errorf("file name is required")
 	}
 
+	sources, err := readManifestSources(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(content) == 0 {
-		return nil, fmt.Errorf("Content cannot be empty")
+	return renderManifestSources(c, sources)
+}
+
+func readObjectsFromCommand(c *Command, client metalcloud.MetalCloudClient) ([]metalcloud.Applier, error) {
+	manifestObjects, err := readManifestObjectsFromCommand(c)
+	if err != nil {
+		return nil, err
 	}
 
-	objects := strings.Split(string(content), yamlSeparator)
+	results := []metalcloud.Applier{}
+	for _, o := range manifestObjects {
+		results = append(results, o.object)
+	}
 
-	getKind := func(object []byte) (string, error) {
-		re := regexp.MustCompile(`kind\s*:\s*(.+)`)
-		matches := re.FindAllSubmatch(object, -1)
+	return results, nil
+}
 
-		if len(matches) > 0 {
-			return string(matches[0][1]), nil
-		}
+//readManifestObjectsFromCommand returns the objects of the manifests together with the fields set for each of them
+func readManifestObjectsFromCommand(c *Command) ([]manifestObject, error) {
+	var results []manifestObject
 
-		return "", fmt.Errorf("property kind is missing")
+	sources, err := readManifestSourcesFromCommand(c)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, object := range objects {
-		if len(strings.Trim(object, " \n\r")) == 0 {
-			continue
-		}
-		bytes := []byte(object)
-		kind, err := getKind(bytes)
-
+	for _, source := range sources {
+		documents, err := parseManifestDocuments(source)
 		if err != nil {
 			return nil, err
 		}
-		kind = strings.Trim(kind, " \n\r")
 
-		newType, err := metalcloud.GetObjectByKind(kind)
-		if err != nil {
-			return nil, err
-		}
+		for _, doc := range documents {
+			object, err := decodeManifestDocument(doc)
+			if err != nil {
+				return nil, err
+			}
 
-		if err = yaml.Unmarshal(bytes, newType.Interface()); err != nil {
-			return nil, err
+			results = append(results, manifestObject{
+				object: object,
+				fields: doc.fields(),
+			})
 		}
-		newObject := newType.Elem().Interface()
+	}
 
-		results = append(results, newObject.(metalcloud.Applier))
+	if len(results) == 0 {
+		return nil, fmt.Errorf("Content cannot be empty")
 	}
 
 	return results, nil
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"syscall"
@@ -15,6 +16,9 @@ import (
 
 const deleteTestCasesDir = "./cmd_apply_test_cases/delete/"
 
+//yamlSeparator separates the documents of the multi-document yaml manifests used in the tests
+const yamlSeparator = "\n---"
+
 func TestApply(t *testing.T) {
 	RegisterTestingT(t)
 	// dcBytes, err := yaml.Marshal(_osTemplate1)
@@ -172,7 +176,7 @@ func TestApply(t *testing.T) {
 			good: false,
 		},
 		{
-			name: "missing file/not a file",
+			name: "directory without valid manifests",
 			cmd: MakeCommand(map[string]interface{}{
 				"read_config_from_file": "./examples",
 			}),
@@ -294,7 +298,7 @@ func TestDelete(t *testing.T) {
 			good: false,
 		},
 		{
-			name: "missing file/not a file",
+			name: "directory without valid manifests",
 			cmd: MakeCommand(map[string]interface{}{
 				"read_config_from_file": "./examples",
 			}),
@@ -327,6 +331,366 @@ func TestDelete(t *testing.T) {
 	testCreateCommand(deleteCmd, cases, client, t)
 }
 
+func TestApplyDryRun(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	setColoringEnabled(false)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	dcConfig := *_datacenter1.DatacenterConfig
+	dcConfig.BSIVRRPListenIPv4 = "172.16.10.7"
+
+	//no create or update calls are expected in dry run mode
+	client.EXPECT().
+		SwitchDeviceGet(100, false).
+		Return(&_switchDevice1, nil).
+		Times(1)
+	client.EXPECT().
+		SubnetPoolGet(101).
+		Return(nil, fmt.Errorf("subnet pool not found")).
+		Times(1)
+	client.EXPECT().
+		DatacenterGet("dctest").
+		Return(&metalcloud.Datacenter{DatacenterName: "dctest", UserID: 1}, nil).
+		Times(1)
+	client.EXPECT().
+		DatacenterConfigGet("dctest").
+		Return(&dcConfig, nil).
+		Times(1)
+
+	f, err := ioutil.TempFile("./", "testapply-*.yaml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	f.WriteString(fmt.Sprintf("%s\n%s\n%s\n%s\n%s", _switchDeviceFixtureYaml1, yamlSeparator, _subnetPoolFixtureYaml2, yamlSeparator, _datacenterFixtureYaml1))
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	bTrue := true
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": f.Name(),
+	})
+	cmd.Arguments["dry_run"] = &bTrue
+
+	ret, err := applyCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("= unchanged SwitchDevice #100"))
+	Expect(ret).To(ContainSubstring("+ create SubnetPool #101"))
+	Expect(ret).To(ContainSubstring("    + id: 101"))
+	Expect(ret).To(ContainSubstring("~ update Datacenter dctest"))
+	Expect(ret).To(ContainSubstring("    ~ config.BSIVRRPListenIPv4: 172.16.10.7 => 172.16.10.6"))
+	Expect(ret).NotTo(ContainSubstring("config.SANRoutedSubnet"))
+	Expect(ret).To(ContainSubstring("Plan: 1 to create, 1 to update, 1 unchanged."))
+}
+
+func TestPlanObjectErrors(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	client.EXPECT().
+		SubnetPoolGet(101).
+		Return(nil, fmt.Errorf("connection refused")).
+		Times(1)
+
+	//only not found errors are planned as creates
+	_, err := planObject(_subnetPool2, nil, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("connection refused"))
+
+	//the passwords are decrypted when the manifest sets them
+	sw := metalcloud.SwitchDevice{
+		NetworkEquipmentIdentifierString:   "sw1",
+		NetworkEquipmentManagementPassword: "secret",
+	}
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("sw1", true).
+		Return(&sw, nil).
+		Times(1)
+
+	entry, err := planObject(sw, nil, client)
+	Expect(err).To(BeNil())
+	Expect(entry.Action).To(Equal(planActionUnchanged))
+}
+
+func TestPlanObjectManifestFields(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	documents, err := parseManifestDocuments(manifestSource{
+		name:    "test.yaml",
+		content: []byte("kind: SubnetPool\nid: 101\nprefix: 10.0.0.0\n"),
+	})
+	Expect(err).To(BeNil())
+	Expect(documents).To(HaveLen(1))
+	Expect(documents[0].fields()).To(Equal([]string{"id", "prefix"}))
+
+	object, err := decodeManifestDocument(documents[0])
+	Expect(err).To(BeNil())
+
+	client.EXPECT().
+		SubnetPoolGet(101).
+		Return(&metalcloud.SubnetPool{
+			SubnetPoolID:                        101,
+			SubnetPoolPrefixHumanReadable:       "10.0.0.0",
+			SubnetPoolRoutable:                  true,
+			SubnetPoolIsOnlyForManualAllocation: true,
+		}, nil).
+		Times(2)
+
+	//the flags not written in the manifest are not compared
+	entry, err := planObject(object, documents[0].fields(), client)
+	Expect(err).To(BeNil())
+	Expect(entry.Action).To(Equal(planActionUnchanged))
+
+	entry, err = planObject(object, nil, client)
+	Expect(err).To(BeNil())
+	Expect(entry.Action).To(Equal(planActionUpdate))
+	Expect(entry.Changes).To(HaveLen(2))
+
+	//nested objects are compared field by field
+	documents, err = parseManifestDocuments(manifestSource{
+		name:    "test.yaml",
+		content: []byte("kind: Datacenter\nname: dctest\nconfig:\n  BSIVRRPListenIPv4: 172.16.10.6\n"),
+	})
+	Expect(err).To(BeNil())
+	Expect(documents[0].fields()).To(Equal([]string{"config.BSIVRRPListenIPv4", "name"}))
+}
+
+func TestSortObjectsByKind(t *testing.T) {
+	RegisterTestingT(t)
+
+	objects := []metalcloud.Applier{
+		_driveArray1,
+		_server1,
+		_instanceArray1,
+		_subnetPool1,
+		_datacenter1,
+		_subnetPool2,
+		_switchDevice1,
+	}
+
+	sortObjectsByKind(objects, false)
+
+	Expect(objects).To(Equal([]metalcloud.Applier{
+		_datacenter1,
+		_subnetPool1,
+		_subnetPool2,
+		_switchDevice1,
+		_server1,
+		_instanceArray1,
+		_driveArray1,
+	}))
+
+	sortObjectsByKind(objects, true)
+
+	Expect(objects).To(Equal([]metalcloud.Applier{
+		_driveArray1,
+		_instanceArray1,
+		_server1,
+		_switchDevice1,
+		_subnetPool1,
+		_subnetPool2,
+		_datacenter1,
+	}))
+}
+
+func TestApplyContinueOnError(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	gomock.InOrder(
+		client.EXPECT().
+			DatacenterGet("dctest").
+			Return(nil, fmt.Errorf("datacenter not found")),
+		client.EXPECT().
+			DatacenterCreate(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("datacenter create failed")),
+		client.EXPECT().
+			SubnetPoolGet(101).
+			Return(nil, fmt.Errorf("subnet pool not found")),
+		client.EXPECT().
+			SubnetPoolCreate(_subnetPool2).
+			Return(&_subnetPool2, nil),
+	)
+
+	f, err := ioutil.TempFile("./", "testapply-*.yaml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	//the subnet pool is listed first but the datacenter needs to be created first
+	f.WriteString(fmt.Sprintf("%s\n%s\n%s", _subnetPoolFixtureYaml2, yamlSeparator, _datacenterFixtureYaml1))
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": f.Name(),
+		"format":                "json",
+	})
+	bTrue := true
+	cmd.Arguments["continue_on_error"] = &bTrue
+
+	ret, err := applyCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	err = json.Unmarshal([]byte(ret), &m)
+	Expect(err).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	Expect(m[0].(map[string]interface{})["KIND"]).To(Equal("Datacenter"))
+	Expect(m[0].(map[string]interface{})["ACTION"]).To(Equal("failed"))
+	Expect(m[0].(map[string]interface{})["ERROR"]).To(Equal("datacenter create failed"))
+	Expect(m[1].(map[string]interface{})["KIND"]).To(Equal("SubnetPool"))
+	Expect(m[1].(map[string]interface{})["IDENTIFIER"]).To(Equal("#101"))
+	Expect(m[1].(map[string]interface{})["ACTION"]).To(Equal("applied"))
+
+	//without the flag the first error stops the apply
+	client.EXPECT().
+		DatacenterGet("dctest").
+		Return(nil, fmt.Errorf("datacenter not found"))
+	client.EXPECT().
+		DatacenterCreate(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("datacenter create failed"))
+
+	cmd.Arguments["continue_on_error"] = nil
+
+	_, err = applyCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal("Datacenter dctest: datacenter create failed (0 of 2 objects processed before the error)"))
+}
+
+func TestGetPruneCandidatesWithoutID(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	//no calls are expected, nothing can be pruned
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	_, err := getPruneCandidates([]metalcloud.Applier{_subnetPool1, metalcloud.SubnetPool{SubnetPoolPrefixHumanReadable: "10.0.0.0"}}, "dc1", client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("cannot prune SubnetPool objects"))
+
+	_, err = getPruneCandidates([]metalcloud.Applier{metalcloud.Server{ServerSerialNumber: "SN1"}}, "dc1", client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("cannot prune Server objects"))
+}
+
+func TestApplyPrune(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	setColoringEnabled(false)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sw1 := metalcloud.SwitchDevice{
+		NetworkEquipmentID:               100,
+		NetworkEquipmentIdentifierString: "sw1",
+		DatacenterName:                   "dc1",
+	}
+	sw2 := metalcloud.SwitchDevice{
+		NetworkEquipmentID:               101,
+		NetworkEquipmentIdentifierString: "sw2",
+		DatacenterName:                   "dc1",
+	}
+	subnetPool3 := metalcloud.SubnetPool{
+		SubnetPoolID: 102,
+	}
+
+	//servers are not in the manifest so they are not listed
+	client.EXPECT().
+		SwitchDevices("dc1", "").
+		Return(&map[string]metalcloud.SwitchDevice{"sw1": sw1, "sw2": sw2}, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGet(100, false).
+		Return(&sw1, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGet(101, false).
+		Return(&sw2, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("sw1", false).
+		Return(&sw1, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("sw2", false).
+		Return(&sw2, nil).
+		AnyTimes()
+	client.EXPECT().
+		SubnetPoolSearch("datacenter_name: dc1").
+		Return(&[]metalcloud.SubnetPool{_subnetPool1, subnetPool3}, nil).
+		AnyTimes()
+	client.EXPECT().
+		SubnetPoolGet(100).
+		Return(&_subnetPool1, nil).
+		AnyTimes()
+
+	f, err := ioutil.TempFile("./", "testapply-*.yaml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	f.WriteString("kind: SwitchDevice\napiVersion: 1.0\nidentifierString: sw1\ndatacenterName: dc1\n---\nkind: SubnetPool\napiVersion: 1.0\nid: 100\n")
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": f.Name(),
+	})
+	bTrue := true
+	cmd.Arguments["prune"] = &bTrue
+
+	_, err = applyCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	dc := "dc1"
+	cmd.Arguments["datacenter_name"] = &dc
+	cmd.Arguments["dry_run"] = &bTrue
+
+	ret, err := applyCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("- delete SwitchDevice sw2"))
+	Expect(ret).To(ContainSubstring("- delete SubnetPool #102"))
+	Expect(ret).NotTo(ContainSubstring("- delete SwitchDevice sw1"))
+	Expect(ret).To(ContainSubstring("Plan: 0 to create, 0 to update, 2 unchanged, 2 to delete."))
+
+	cmd.Arguments["dry_run"] = nil
+
+	_, err = applyCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(Equal("Operation not confirmed. Aborting"))
+
+	//the objects are applied first then the missing ones are deleted in reverse dependency order
+	gomock.InOrder(
+		client.EXPECT().
+			SwitchDeviceUpdate(100, gomock.Any(), false).
+			Return(&sw1, nil),
+		client.EXPECT().
+			SwitchDeviceDelete(101).
+			Return(nil),
+		client.EXPECT().
+			SubnetPoolDelete(102).
+			Return(nil),
+	)
+
+	cmd.Arguments["autoconfirm"] = &bTrue
+
+	_, err = applyCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
+
 func TestReadObjectsFromCommand(t *testing.T) {
 	RegisterTestingT(t)
 	ctrl := gomock.NewController(t)
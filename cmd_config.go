@@ -0,0 +1,312 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+const configSubject = "config"
+
+var configCmds = []Command{
+	{
+		Description:  "Lists the connection profiles (contexts) from the config file.",
+		Subject:      configSubject,
+		AltSubject:   "cfg",
+		Predicate:    "get-contexts",
+		AltPredicate: "ls",
+		FlagSet:      flag.NewFlagSet("list contexts", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"format":      tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"show_secret": c.FlagSet.Bool("show-api-key", false, green("(Flag)")+" If set returns the api keys of the contexts."),
+			}
+		},
+		ExecuteFunc: configGetContextsCmd,
+		Example: `
+metalcloud-cli config get-contexts
+metalcloud-cli config get-contexts --format json
+		`,
+	},
+	{
+		Description:  "Selects the connection profile (context) used by default.",
+		Subject:      configSubject,
+		AltSubject:   "cfg",
+		Predicate:    "use-context",
+		AltPredicate: "use",
+		FlagSet:      flag.NewFlagSet("use context", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"context_name": c.FlagSet.String("name", _nilDefaultStr, red("(Required)")+" The name of the context."),
+			}
+		},
+		ExecuteFunc: configUseContextCmd,
+		Example: `
+metalcloud-cli config use-context --name production
+		`,
+	},
+	{
+		Description:  "Creates or updates a connection profile (context).",
+		Subject:      configSubject,
+		AltSubject:   "cfg",
+		Predicate:    "set-context",
+		AltPredicate: "set",
+		FlagSet:      flag.NewFlagSet("set context", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"context_name": c.FlagSet.String("name", _nilDefaultStr, red("(Required)")+" The name of the context."),
+				"endpoint":     c.FlagSet.String("endpoint", _nilDefaultStr, "The endpoint of the controller. Eg: https://api.poc.metalsoft.io"),
+				"user_email":   c.FlagSet.String("user", _nilDefaultStr, "The email of the user owning the api key."),
+				"api_key":      c.FlagSet.String("api-key", _nilDefaultStr, "The api key. It should be of the form <number>:<letters>"),
+				"admin":        c.FlagSet.String("admin", _nilDefaultStr, "Set to 'true' to enable admin commands for this context or 'false' to disable them."),
+				"use":          c.FlagSet.Bool("use", false, green("(Flag)")+" If set the context will also become the current context."),
+			}
+		},
+		ExecuteFunc: configSetContextCmd,
+		Example: `
+metalcloud-cli config set-context --name lab --endpoint https://lab.metalsoft.io --user test@test.com --api-key "1:abcdefgh" --admin true
+metalcloud-cli config set-context --name lab --api-key "1:newkey" # only updates the api key
+		`,
+	},
+	{
+		Description:  "Deletes a connection profile (context).",
+		Subject:      configSubject,
+		AltSubject:   "cfg",
+		Predicate:    "delete-context",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete context", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"context_name": c.FlagSet.String("name", _nilDefaultStr, red("(Required)")+" The name of the context."),
+				"autoconfirm":  c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: configDeleteContextCmd,
+	},
+}
+
+//isConfigCommand returns true if the subject of the command line is the subject or alt subject of the config commands.
+//These commands must work even if there are no valid credentials yet.
+func isConfigCommand(args []string) bool {
+	subject, _, _ := validateArguments(args)
+	for _, c := range configCmds {
+		if c.Subject == subject || c.AltSubject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+func configGetContextsCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "CURRENT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 4,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "ENDPOINT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "USER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "ADMIN",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+	}
+
+	showSecret := getBoolParam(c.Arguments["show_secret"])
+
+	if showSecret {
+		schema = append(schema, tableformatter.SchemaField{
+			FieldName: "API_KEY",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		})
+	}
+
+	data := [][]interface{}{}
+	for _, ctx := range config.Contexts {
+
+		current := ""
+		if ctx.Name == config.CurrentContext {
+			current = "*"
+		}
+
+		row := []interface{}{
+			current,
+			ctx.Name,
+			ctx.Endpoint,
+			ctx.UserEmail,
+			ctx.Admin,
+		}
+
+		if showSecret {
+			row = append(row, ctx.APIKey)
+		}
+
+		data = append(data, row)
+	}
+
+	tableformatter.TableSorter(schema).OrderBy(schema[1].FieldName).Sort(data)
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return renderTable(c, table, "Contexts", fmt.Sprintf("Contexts defined in %s", path))
+}
+
+func configUseContextCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	name, ok := getStringParamOk(c.Arguments["context_name"])
+	if !ok {
+		return "", fmt.Errorf("-name is required")
+	}
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := config.getContext(name); !ok {
+		return "", fmt.Errorf("context %s not found in %s", name, path)
+	}
+
+	config.CurrentContext = name
+
+	return "", saveConfig(path, config)
+}
+
+func configSetContextCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	name, ok := getStringParamOk(c.Arguments["context_name"])
+	if !ok {
+		return "", fmt.Errorf("-name is required")
+	}
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	profile := ConnectionProfile{
+		Name: name,
+	}
+
+	if ctx, ok := config.getContext(name); ok {
+		profile = *ctx
+	}
+
+	updateIfStringParamSet(c.Arguments["endpoint"], &profile.Endpoint)
+	updateIfStringParamSet(c.Arguments["user_email"], &profile.UserEmail)
+
+	if apiKey, ok := getStringParamOk(c.Arguments["api_key"]); ok {
+		if err := validateAPIKey(apiKey); err != nil {
+			return "", err
+		}
+		profile.APIKey = apiKey
+	}
+
+	if admin, ok := getStringParamOk(c.Arguments["admin"]); ok {
+		switch strings.ToLower(admin) {
+		case "true":
+			profile.Admin = true
+		case "false":
+			profile.Admin = false
+		default:
+			return "", fmt.Errorf("-admin must be one of 'true' or 'false'")
+		}
+	}
+
+	config.setContext(profile)
+
+	if getBoolParam(c.Arguments["use"]) || config.CurrentContext == "" {
+		config.CurrentContext = name
+	}
+
+	return "", saveConfig(path, config)
+}
+
+func configDeleteContextCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	name, ok := getStringParamOk(c.Arguments["context_name"])
+	if !ok {
+		return "", fmt.Errorf("-name is required")
+	}
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := config.getContext(name); !ok {
+		return "", fmt.Errorf("context %s not found in %s", name, path)
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting context %s from %s.  Are you sure? Type \"yes\" to continue:", name, path)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	config.deleteContext(name)
+
+	return "", saveConfig(path, config)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+//setupTestConfig points METALCLOUD_CONFIG to a temporary file and clears the credential env vars.
+//The returned function restores the environment.
+func setupTestConfig(t *testing.T) (string, func()) {
+	envs := []string{
+		"METALCLOUD_USER_EMAIL",
+		"METALCLOUD_API_KEY",
+		"METALCLOUD_ENDPOINT",
+		"METALCLOUD_ADMIN",
+		"METALCLOUD_PROFILE",
+		"METALCLOUD_CONFIG",
+	}
+
+	currentEnvVals := map[string]string{}
+	for _, e := range envs {
+		if v, ok := os.LookupEnv(e); ok {
+			currentEnvVals[e] = v
+		}
+		os.Unsetenv(e)
+	}
+
+	dir, err := ioutil.TempDir("", "metalcloud-cli-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, defaultConfigDir, defaultConfigFileName)
+	os.Setenv("METALCLOUD_CONFIG", path)
+
+	return path, func() {
+		os.RemoveAll(dir)
+		for _, e := range envs {
+			os.Unsetenv(e)
+		}
+		for k, v := range currentEnvVals {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+func TestExtractProfileArgument(t *testing.T) {
+	RegisterTestingT(t)
+
+	profile, args, err := extractProfileArgument([]string{"metalcloud-cli", "--profile", "lab", "server", "list"})
+	Expect(err).To(BeNil())
+	Expect(profile).To(Equal("lab"))
+	Expect(args).To(Equal([]string{"metalcloud-cli", "server", "list"}))
+
+	profile, args, err = extractProfileArgument([]string{"metalcloud-cli", "server", "list", "-profile=prod", "--format", "json"})
+	Expect(err).To(BeNil())
+	Expect(profile).To(Equal("prod"))
+	Expect(args).To(Equal([]string{"metalcloud-cli", "server", "list", "--format", "json"}))
+
+	profile, args, err = extractProfileArgument([]string{"metalcloud-cli", "server", "list"})
+	Expect(err).To(BeNil())
+	Expect(profile).To(Equal(""))
+	Expect(args).To(Equal([]string{"metalcloud-cli", "server", "list"}))
+
+	_, _, err = extractProfileArgument([]string{"metalcloud-cli", "server", "list", "--profile"})
+	Expect(err).NotTo(BeNil())
+
+	//--profile used as the value of another flag is left to the command
+	profile, args, err = extractProfileArgument([]string{"metalcloud-cli", "server", "edit", "--label", "--profile", "--id", "10"})
+	Expect(err).To(BeNil())
+	Expect(profile).To(Equal(""))
+	Expect(args).To(Equal([]string{"metalcloud-cli", "server", "edit", "--label", "--profile", "--id", "10"}))
+
+	profile, args, err = extractProfileArgument([]string{"metalcloud-cli", "--profile=lab", "version"})
+	Expect(err).To(BeNil())
+	Expect(profile).To(Equal("lab"))
+	Expect(args).To(Equal([]string{"metalcloud-cli", "version"}))
+}
+
+func TestIsConfigCommand(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(isConfigCommand([]string{"metalcloud-cli", "config", "get-contexts"})).To(BeTrue())
+	Expect(isConfigCommand([]string{"metalcloud-cli", "cfg", "ls"})).To(BeTrue())
+	Expect(isConfigCommand([]string{"metalcloud-cli", "server", "list"})).To(BeFalse())
+	Expect(isConfigCommand([]string{"metalcloud-cli"})).To(BeFalse())
+	Expect(isConfigCommand([]string{"metalcloud-cli", "--format", "config"})).To(BeFalse())
+}
+
+func TestConfigContextCmds(t *testing.T) {
+	RegisterTestingT(t)
+
+	path, restore := setupTestConfig(t)
+	defer restore()
+
+	//missing file means no contexts
+	ret, err := configGetContextsCmd(&Command{Arguments: map[string]interface{}{}}, nil)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("Total: 0 Contexts"))
+
+	cmd := MakeCommand(map[string]interface{}{
+		"context_name": "lab",
+		"endpoint":     "https://lab.test",
+		"user_email":   "lab@test.com",
+		"api_key":      "1:abcdef",
+		"admin":        "true",
+	})
+	_, err = configSetContextCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "prod",
+		"endpoint":     "https://prod.test",
+		"user_email":   "prod@test.com",
+		"api_key":      "2:abcdef",
+	})
+	_, err = configSetContextCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "prod",
+		"api_key":      "bad key",
+	})
+	_, err = configSetContextCmd(&cmd, nil)
+	Expect(err).NotTo(BeNil())
+
+	config, err := loadConfig(path)
+	Expect(err).To(BeNil())
+	Expect(config.Contexts).To(HaveLen(2))
+	//first context becomes the current one
+	Expect(config.CurrentContext).To(Equal("lab"))
+
+	info, err := os.Stat(path)
+	Expect(err).To(BeNil())
+	Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	//partial update keeps the other fields
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "lab",
+		"admin":        "false",
+	})
+	_, err = configSetContextCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+
+	config, err = loadConfig(path)
+	Expect(err).To(BeNil())
+	ctx, ok := config.getContext("lab")
+	Expect(ok).To(BeTrue())
+	Expect(ctx.Admin).To(BeFalse())
+	Expect(ctx.Endpoint).To(Equal("https://lab.test"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "missing",
+	})
+	_, err = configUseContextCmd(&cmd, nil)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "prod",
+	})
+	_, err = configUseContextCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+
+	profile, err := resolveConnectionProfile("")
+	Expect(err).To(BeNil())
+	Expect(profile.Endpoint).To(Equal("https://prod.test"))
+
+	//the --profile argument takes precedence over the current context
+	profile, err = resolveConnectionProfile("lab")
+	Expect(err).To(BeNil())
+	Expect(profile.Endpoint).To(Equal("https://lab.test"))
+
+	//env vars override the values from the file
+	os.Setenv("METALCLOUD_ENDPOINT", "https://env.test")
+	os.Setenv("METALCLOUD_ADMIN", "true")
+	profile, err = resolveConnectionProfile("")
+	Expect(err).To(BeNil())
+	Expect(profile.Endpoint).To(Equal("https://env.test"))
+	Expect(profile.UserEmail).To(Equal("prod@test.com"))
+	Expect(profile.Admin).To(BeTrue())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"format": "json",
+	})
+	ret, err = configGetContextsCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+	Expect(ret).NotTo(ContainSubstring("abcdef"))
+	Expect(JSONFirstRowEquals(ret, map[string]interface{}{
+		"NAME":    "lab",
+		"CURRENT": "",
+	})).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"context_name": "prod",
+	})
+	_, err = configDeleteContextCmd(&cmd, nil)
+	Expect(err.Error()).To(Equal("Operation not confirmed. Aborting"))
+
+	bTrue := true
+	cmd.Arguments["autoconfirm"] = &bTrue
+
+	_, err = configDeleteContextCmd(&cmd, nil)
+	Expect(err).To(BeNil())
+
+	config, err = loadConfig(path)
+	Expect(err).To(BeNil())
+	Expect(config.Contexts).To(HaveLen(1))
+	Expect(config.CurrentContext).To(Equal(""))
+}
@@ -27,7 +27,7 @@ var datacenterCmds = []Command{
 				"user_id":       c.FlagSet.String("user", _nilDefaultStr, "List only specific user's datacenters"),
 				"show_inactive": c.FlagSet.Bool("show-inactive", false, green("(Flag)")+" Set flag if inactive datacenters are to be returned"),
 				"show_hidden":   c.FlagSet.Bool("show-hidden", false, green("(Flag)")+" Set flag if hidden datacenters are to be returned"),
-				"format":        c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":        tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"json_path":     c.FlagSet.String("jsonpath", _nilDefaultStr, "Filter the output."),
 			}
 		},
@@ -71,7 +71,7 @@ var datacenterCmds = []Command{
 				"datacenter_name":        c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Label of the datacenter. Also used as an ID."),
 				"show_secret_config_url": c.FlagSet.Bool("show-config-url", false, green("(Flag)")+" If set returns the secret config url for datacenter agents."),
 				"return_config_url":      c.FlagSet.Bool("return-config-url", false, green("(Flag)")+" If set prints the config url of the datacenter. Ignores all other flags. Useful in automation."),
-				"format":                 c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                 outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"json_path":              c.FlagSet.String("jsonpath", _nilDefaultStr, "Filter the JSON config."),
 			}
 		},
@@ -195,7 +195,7 @@ func datacenterListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 		Schema: schema,
 	}
 
-	return table.RenderTable("Datacenters", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Datacenters", "")
 }
 
 func datacenterCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -66,7 +66,7 @@ var driveArrayCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"infrastructure_id_or_label": c.FlagSet.String("infra", _nilDefaultStr, red("(Required)") + " Infrastructure's id or label. Note that the 'label' this be ambiguous in certain situations."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: driveArrayListCmd,
@@ -97,7 +97,7 @@ var driveArrayCmds = []Command{
 			c.Arguments = map[string]interface{}{
 				"drive_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)") + " Drive Array's ID or label. Note that using the label can be ambiguous and is slower."),
 				"show_iscsi_credentials":  c.FlagSet.Bool("show-iscsi-credentials", false, green("(Flag)") + " If set returns the drives' iscsi credentials"),
-				"format":                  c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                  tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: driveArrayGetCmd,
@@ -342,7 +342,7 @@ func driveArrayListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 		Schema: schema,
 	}
 
-	return table.RenderTable("Drive Arrays", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Drive Arrays", "")
 }
 
 func driveArrayDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -522,7 +522,7 @@ func driveArrayGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, e
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Drives", subtitle, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Drives", subtitle)
 }
 
 func argsToDriveArray(m map[string]interface{}) *metalcloud.DriveArray {
@@ -38,7 +38,7 @@ var driveSnapshotCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"drive_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)") + " The id of the drive for which to list snapshots."),
-				"format":   c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":   tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: driveSnapshotListCmd,
@@ -149,7 +149,7 @@ func driveSnapshotListCmd(c *Command, client metalcloud.MetalCloudClient) (strin
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Snapshots", subtitle, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Snapshots", subtitle)
 }
 
 func driveSnapshotDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
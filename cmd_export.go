@@ -0,0 +1,245 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//exportableKinds are the kinds that can be exported, in the order in which they are written
+var exportableKinds = []string{
+	"SubnetPool",
+	"SwitchDevice",
+	"ExternalConnection",
+	"NetworkProfile",
+	"Server",
+}
+
+//exportCmds commands that export server side objects as manifests
+var exportCmds = []Command{
+
+	{
+		Description:  "Export a datacenter's resources as a manifest that can be used with apply.",
+		Subject:      "export",
+		AltSubject:   "export",
+		Predicate:    _nilDefaultStr,
+		AltPredicate: _nilDefaultStr,
+		FlagSet:      flag.NewFlagSet("export", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter_name": c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" The datacenter whose resources are exported."),
+				"kinds":           c.FlagSet.String("kinds", _nilDefaultStr, fmt.Sprintf("Comma separated list of kinds to export. Supported values are %s. All are exported by default.", strings.Join(exportableKinds, ", "))),
+				"redact_secrets":  c.FlagSet.Bool("redact-secrets", false, green("(Flag)")+" If set passwords are removed from the exported objects. Redacted manifests are meant for review and backups: applying them can reset the removed passwords."),
+			}
+		},
+		ExecuteFunc: exportCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli export --datacenter uk-reading > uk-reading.yaml
+metalcloud-cli export --datacenter uk-reading --kinds SwitchDevice,SubnetPool --redact-secrets
+		`,
+	},
+}
+
+func exportCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenterName, ok := getStringParamOk(c.Arguments["datacenter_name"])
+	if !ok {
+		return "", fmt.Errorf("-datacenter is required")
+	}
+
+	kinds := exportableKinds
+	if k, ok := getStringParamOk(c.Arguments["kinds"]); ok {
+		kinds = []string{}
+		for _, kind := range strings.Split(k, ",") {
+			kind = strings.TrimSpace(kind)
+			if !stringInSlice(kind, exportableKinds) {
+				return "", fmt.Errorf("kind %s cannot be exported. Supported values are %s", kind, strings.Join(exportableKinds, ", "))
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	redact := getBoolParam(c.Arguments["redact_secrets"])
+
+	objects := []metalcloud.Applier{}
+
+	for _, kind := range exportableKinds {
+		if !stringInSlice(kind, kinds) {
+			continue
+		}
+
+		list, err := getExportedObjects(kind, datacenterName, !redact, client)
+		if err != nil {
+			return "", err
+		}
+
+		objects = append(objects, list...)
+	}
+
+	documents := []string{}
+	for _, object := range objects {
+		doc, err := exportObject(object, redact)
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, doc)
+	}
+
+	return strings.Join(documents, "---\n"), nil
+}
+
+//getExportedObjects returns the objects of a kind from a datacenter, sorted by id
+func getExportedObjects(kind string, datacenterName string, decryptPasswords bool, client metalcloud.MetalCloudClient) ([]metalcloud.Applier, error) {
+	objects := []metalcloud.Applier{}
+
+	switch kind {
+	case "SubnetPool":
+		list, err := client.SubnetPoolSearch(fmt.Sprintf("datacenter_name: %s", datacenterName))
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(*list, func(i, j int) bool {
+			return (*list)[i].SubnetPoolID < (*list)[j].SubnetPoolID
+		})
+
+		for _, s := range *list {
+			objects = append(objects, s)
+		}
+
+	case "SwitchDevice":
+		list, err := client.SwitchDevices(datacenterName, "")
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []int{}
+		for _, s := range *list {
+			ids = append(ids, s.NetworkEquipmentID)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			sw, err := client.SwitchDeviceGet(id, decryptPasswords)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, *sw)
+		}
+
+	case "ExternalConnection":
+		list, err := client.ExternalConnections(datacenterName)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []int{}
+		for id := range *list {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			objects = append(objects, ExternalConnection((*list)[id]))
+		}
+
+	case "NetworkProfile":
+		list, err := client.NetworkProfiles(datacenterName)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []int{}
+		for id := range *list {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			objects = append(objects, NetworkProfile((*list)[id]))
+		}
+
+	case "Server":
+		list, err := client.ServersSearch(fmt.Sprintf("+datacenter_name:%s", datacenterName))
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []int{}
+		for _, s := range *list {
+			ids = append(ids, s.ServerID)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			server, err := client.ServerGet(id, decryptPasswords)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, *server)
+		}
+	}
+
+	return objects, nil
+}
+
+//exportObject returns the object as a yaml manifest document. Fields are written in the order in which they are declared.
+func exportObject(object metalcloud.Applier, redact bool) (string, error) {
+	bytes, err := yaml.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+
+	if redact {
+		//work on a copy so that the nested objects of the original are not changed
+		v := reflect.New(reflect.TypeOf(object))
+		if err := yaml.Unmarshal(bytes, v.Interface()); err != nil {
+			return "", err
+		}
+
+		redactPasswords(v.Elem())
+
+		bytes, err = yaml.Marshal(v.Elem().Interface())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("kind: %s\napiVersion: 1.0\n%s", getObjectKind(object), string(bytes)), nil
+}
+
+//redactPasswords clears all the string fields whose name contains Password, including the ones of nested objects
+func redactPasswords(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			redactPasswords(v.Elem())
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+
+			if f.Kind() == reflect.String && strings.Contains(strings.ToLower(v.Type().Field(i).Name), "password") {
+				f.SetString("")
+				continue
+			}
+
+			redactPasswords(f)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactPasswords(v.Index(i))
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestExportCmd(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sw := metalcloud.SwitchDevice{
+		NetworkEquipmentID:                 100,
+		NetworkEquipmentIdentifierString:   "sw1",
+		DatacenterName:                     "dc1",
+		NetworkEquipmentManagementPassword: "secret",
+	}
+
+	vlanID := 10
+	np := metalcloud.NetworkProfile{
+		NetworkProfileID:    5,
+		NetworkProfileLabel: "np1",
+		DatacenterName:      "dc1",
+		NetworkType:         "wan",
+		NetworkProfileVLANs: []metalcloud.NetworkProfileVLAN{
+			{
+				VlanID:   &vlanID,
+				PortMode: "trunk",
+			},
+		},
+	}
+
+	ec := metalcloud.ExternalConnection{
+		ExternalConnectionID:          10,
+		ExternalConnectionLabel:       "uplink1",
+		DatacenterName:                "dc1",
+		ExternalConnectionDescription: "internet uplink",
+	}
+
+	server := metalcloud.Server{
+		ServerID:                  200,
+		ServerUUID:                "uuid-200",
+		DatacenterName:            "dc1",
+		ServerIPMInternalPassword: "ipmi-secret",
+	}
+
+	client.EXPECT().
+		SubnetPoolSearch("datacenter_name: dc1").
+		Return(&[]metalcloud.SubnetPool{_subnetPool2, _subnetPool1}, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDevices("dc1", "").
+		Return(&map[string]metalcloud.SwitchDevice{"sw1": sw}, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGet(100, gomock.Any()).
+		Return(&sw, nil).
+		AnyTimes()
+	client.EXPECT().
+		ExternalConnections("dc1").
+		Return(&map[int]metalcloud.ExternalConnection{10: ec}, nil).
+		AnyTimes()
+	client.EXPECT().
+		NetworkProfiles("dc1").
+		Return(&map[int]metalcloud.NetworkProfile{5: np}, nil).
+		AnyTimes()
+	client.EXPECT().
+		ServersSearch("+datacenter_name:dc1").
+		Return(&[]metalcloud.ServerSearchResult{{ServerID: 200}}, nil).
+		AnyTimes()
+	client.EXPECT().
+		ServerGet(200, gomock.Any()).
+		Return(&server, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"datacenter_name": "dc1",
+	})
+
+	ret, err := exportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(HavePrefix("kind: SubnetPool\napiVersion: 1.0\nid: 100\n"))
+	Expect(ret).To(ContainSubstring("managementPassword: secret"))
+
+	documents, err := parseManifestDocuments(manifestSource{name: "export.yaml", content: []byte(ret)})
+	Expect(err).To(BeNil())
+
+	objects := []metalcloud.Applier{}
+	for _, doc := range documents {
+		object, err := decodeManifestDocument(doc)
+		Expect(err).To(BeNil())
+		objects = append(objects, object)
+	}
+
+	identifiers := []string{}
+	for _, object := range objects {
+		identifiers = append(identifiers, getObjectKind(object)+" "+getObjectIdentifier(object))
+	}
+
+	Expect(identifiers).To(Equal([]string{
+		"SubnetPool #100",
+		"SubnetPool #101",
+		"SwitchDevice sw1",
+		"ExternalConnection uplink1",
+		"NetworkProfile np1",
+		"Server uuid-200",
+	}))
+
+	//applying the exported manifest does not change anything
+	client.EXPECT().
+		SubnetPoolGet(100).
+		Return(&_subnetPool1, nil).
+		AnyTimes()
+	client.EXPECT().
+		SubnetPoolGet(101).
+		Return(&_subnetPool2, nil).
+		AnyTimes()
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("sw1", true).
+		Return(&sw, nil).
+		AnyTimes()
+	client.EXPECT().
+		ExternalConnectionGet(10).
+		Return(&ec, nil).
+		AnyTimes()
+	client.EXPECT().
+		NetworkProfileGet(5).
+		Return(&np, nil).
+		AnyTimes()
+	client.EXPECT().
+		ServerGet(200, true).
+		Return(&server, nil).
+		AnyTimes()
+
+	for _, object := range objects {
+		entry, err := planObject(object, nil, client)
+		Expect(err).To(BeNil())
+		Expect(entry.Action).To(Equal(planActionUnchanged))
+	}
+
+	//redacted export
+	cmd = MakeCommand(map[string]interface{}{
+		"datacenter_name": "dc1",
+		"kinds":           "Server,SwitchDevice",
+	})
+	bTrue := true
+	cmd.Arguments["redact_secrets"] = &bTrue
+
+	ret, err = exportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(HavePrefix("kind: SwitchDevice\n"))
+	Expect(ret).NotTo(ContainSubstring("secret"))
+	Expect(ret).NotTo(ContainSubstring("kind: SubnetPool"))
+	//the original objects are not changed
+	Expect(server.ServerIPMInternalPassword).To(Equal("ipmi-secret"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"datacenter_name": "dc1",
+		"kinds":           "Infrastructure",
+	})
+
+	_, err = exportCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{})
+
+	_, err = exportCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
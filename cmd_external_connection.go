@@ -0,0 +1,330 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+var externalConnectionCmds = []Command{
+	{
+		Description:  "Lists all external connections of a datacenter.",
+		Subject:      "external-connection",
+		AltSubject:   "ec",
+		Predicate:    "list",
+		AltPredicate: "ls",
+		FlagSet:      flag.NewFlagSet("list external connections", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter": c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" External connection datacenter"),
+				"format":     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: externalConnectionListCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Get external connection details.",
+		Subject:      "external-connection",
+		AltSubject:   "ec",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("get external connection", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"external_connection_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" External connection's id or label."),
+				"format":                          outputFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"raw":                             c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
+			}
+		},
+		ExecuteFunc: externalConnectionGetCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Create external connection.",
+		Subject:      "external-connection",
+		AltSubject:   "ec",
+		Predicate:    "create",
+		AltPredicate: "new",
+		FlagSet:      flag.NewFlagSet("create external connection", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter":            c.FlagSet.String("datacenter", _nilDefaultStr, "Label of the datacenter. Overrides the dc field of the configuration."),
+				"format":                c.FlagSet.String("format", "json", "The input format. Supported values are 'json','yaml'. The default format is json."),
+				"read_config_from_file": c.FlagSet.String("raw-config", _nilDefaultStr, red("(Required)")+" Read configuration from file in the format specified with --format."),
+				"read_config_from_pipe": c.FlagSet.Bool("pipe", false, green("(Flag)")+" If set, read configuration from pipe instead of from a file. Either this flag or the --raw-config option must be used."),
+				"return_id":             c.FlagSet.Bool("return-id", false, "Will print the ID of the created object. Useful for automating tasks."),
+			}
+		},
+		ExecuteFunc: externalConnectionCreateCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#create file external-connection.yaml:
+label: internet-uplink01
+dc: us02-chi-qts01-dc
+hidden: false
+description: Uplink to the datacenter's internet routers
+
+#create the actual external connection from the file:
+metalcloud-cli external-connection create -format yaml -raw-config ./external-connection.yaml
+`,
+	},
+	{
+		Description:  "Edit external connection.",
+		Subject:      "external-connection",
+		AltSubject:   "ec",
+		Predicate:    "edit",
+		AltPredicate: "update",
+		FlagSet:      flag.NewFlagSet("edit external connection", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"external_connection_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" External connection's id or label."),
+				"format":                          c.FlagSet.String("format", "json", "The input format. Supported values are 'json','yaml'. The default format is json."),
+				"read_config_from_file":           c.FlagSet.String("raw-config", _nilDefaultStr, red("(Required)")+" Read configuration from file in the format specified with --format. Fields missing from the configuration are not changed."),
+				"read_config_from_pipe":           c.FlagSet.Bool("pipe", false, green("(Flag)")+" If set, read configuration from pipe instead of from a file. Either this flag or the --raw-config option must be used."),
+			}
+		},
+		ExecuteFunc: externalConnectionEditCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli external-connection get -id internet-uplink01 -format yaml -raw > external-connection.yaml
+#edit external-connection.yaml, then:
+metalcloud-cli external-connection edit -id internet-uplink01 -format yaml -raw-config ./external-connection.yaml
+`,
+	},
+	{
+		Description:  "Delete external connection.",
+		Subject:      "external-connection",
+		AltSubject:   "ec",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete external connection", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"external_connection_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" External connection's id or label."),
+				"autoconfirm":                     c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: externalConnectionDeleteCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+}
+
+func externalConnectionListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenter, ok := getStringParamOk(c.Arguments["datacenter"])
+	if !ok {
+		return "", fmt.Errorf("-datacenter is required")
+	}
+
+	list, err := client.ExternalConnections(datacenter)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "DATACENTER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "HIDDEN",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "DESCRIPTION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, ec := range *list {
+		data = append(data, []interface{}{
+			ec.ExternalConnectionID,
+			blue(ec.ExternalConnectionLabel),
+			ec.DatacenterName,
+			ec.ExternalConnectionHidden,
+			ec.ExternalConnectionDescription,
+		})
+	}
+
+	tableformatter.TableSorter(schema).OrderBy(schema[0].FieldName).Sort(data)
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return renderTable(c, table, "External connections", "")
+}
+
+func externalConnectionGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	ec, err := getExternalConnectionFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	format := getStringParam(c.Arguments["format"])
+
+	if getBoolParam(c.Arguments["raw"]) {
+		return tableformatter.RenderRawObject(*ec, format, "ExternalConnection")
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "DATACENTER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "HIDDEN",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "DESCRIPTION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+	}
+
+	data := [][]interface{}{{
+		ec.ExternalConnectionID,
+		ec.ExternalConnectionLabel,
+		ec.DatacenterName,
+		ec.ExternalConnectionHidden,
+		ec.ExternalConnectionDescription,
+	}}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable("external connection", "", format)
+}
+
+func externalConnectionCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	var ec metalcloud.ExternalConnection
+
+	err := getRawObjectFromCommand(c, &ec)
+	if err != nil {
+		return "", err
+	}
+
+	if datacenter, ok := getStringParamOk(c.Arguments["datacenter"]); ok {
+		ec.DatacenterName = datacenter
+	}
+
+	if ec.DatacenterName == "" {
+		return "", fmt.Errorf("-datacenter is required if the configuration does not specify dc")
+	}
+
+	ret, err := client.ExternalConnectionCreate(ec)
+	if err != nil {
+		return "", err
+	}
+
+	if getBoolParam(c.Arguments["return_id"]) {
+		return fmt.Sprintf("%d", ret.ExternalConnectionID), nil
+	}
+
+	return "", nil
+}
+
+func externalConnectionEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	ec, err := getExternalConnectionFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	id := ec.ExternalConnectionID
+
+	//the configuration is read over the existing object so that missing fields keep their values
+	err = getRawObjectFromCommand(c, ec)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.ExternalConnectionEdit(id, *ec)
+
+	return "", err
+}
+
+func externalConnectionDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	ec, err := getExternalConnectionFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting external connection %s (%d).  Are you sure? Type \"yes\" to continue:",
+			ec.ExternalConnectionLabel, ec.ExternalConnectionID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	err = client.ExternalConnectionDelete(ec.ExternalConnectionID)
+
+	return "", err
+}
+
+func getExternalConnectionFromCommand(paramName string, c *Command, client metalcloud.MetalCloudClient) (*metalcloud.ExternalConnection, error) {
+
+	m, err := getParam(c, "external_connection_id_or_label", paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	id, label, isID := idOrLabel(m)
+
+	if isID {
+		return client.ExternalConnectionGet(id)
+	}
+
+	return client.ExternalConnectionGetByLabel(label)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"syscall"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestExternalConnectionListAndGetCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ec := metalcloud.ExternalConnection{
+		ExternalConnectionID:          10,
+		ExternalConnectionLabel:       "uplink1",
+		DatacenterName:                "dc1",
+		ExternalConnectionDescription: "internet uplink",
+	}
+
+	client.EXPECT().
+		ExternalConnections("dc1").
+		Return(&map[int]metalcloud.ExternalConnection{10: ec}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ExternalConnectionGetByLabel(ec.ExternalConnectionLabel).
+		Return(&ec, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"datacenter": "dc1",
+		"format":     "json",
+	})
+
+	ret, err := externalConnectionListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	r := m[0].(map[string]interface{})
+	Expect(int(r["ID"].(float64))).To(Equal(10))
+	Expect(r["DESCRIPTION"]).To(Equal("internet uplink"))
+
+	cmd = MakeCommand(map[string]interface{}{})
+	_, err = externalConnectionListCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"external_connection_id_or_label": "uplink1",
+		"format":                          "json",
+	})
+
+	ret, err = externalConnectionGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	r = m[0].(map[string]interface{})
+	Expect(r["DATACENTER"]).To(Equal("dc1"))
+}
+
+func TestExternalConnectionCreateAndEditCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ec := metalcloud.ExternalConnection{
+		ExternalConnectionID:          10,
+		ExternalConnectionLabel:       "uplink1",
+		DatacenterName:                "dc1",
+		ExternalConnectionDescription: "internet uplink",
+	}
+
+	client.EXPECT().
+		ExternalConnectionCreate(metalcloud.ExternalConnection{
+			ExternalConnectionLabel:       "uplink1",
+			DatacenterName:                "dc1",
+			ExternalConnectionDescription: "new uplink",
+		}).
+		Return(&ec, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ExternalConnectionGet(ec.ExternalConnectionID).
+		Return(&ec, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ExternalConnectionEdit(ec.ExternalConnectionID, metalcloud.ExternalConnection{
+			ExternalConnectionID:          10,
+			ExternalConnectionLabel:       "uplink1",
+			DatacenterName:                "dc1",
+			ExternalConnectionDescription: "new uplink",
+		}).
+		Return(&ec, nil).
+		Times(1)
+
+	f, err := ioutil.TempFile("./", "testconf-*.yaml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	f.WriteString("label: uplink1\ndescription: new uplink\n")
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	cases := []CommandTestCase{
+		{
+			name: "good1",
+			cmd: MakeCommand(map[string]interface{}{
+				"datacenter":            "dc1",
+				"format":                "yaml",
+				"read_config_from_file": f.Name(),
+			}),
+			good: true,
+			id:   10,
+		},
+		{
+			name: "missing datacenter",
+			cmd: MakeCommand(map[string]interface{}{
+				"format":                "yaml",
+				"read_config_from_file": f.Name(),
+			}),
+			good: false,
+		},
+		{
+			name: "missing config",
+			cmd: MakeCommand(map[string]interface{}{
+				"datacenter": "dc1",
+				"format":     "yaml",
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(externalConnectionCreateCmd, cases, client, t)
+
+	//fields missing from the configuration keep their values
+	cmd := MakeCommand(map[string]interface{}{
+		"external_connection_id_or_label": 10,
+		"format":                          "yaml",
+		"read_config_from_file":           f.Name(),
+	})
+
+	_, err = externalConnectionEditCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
+
+func TestExternalConnectionDeleteCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ec := metalcloud.ExternalConnection{
+		ExternalConnectionID:    10,
+		ExternalConnectionLabel: "uplink1",
+	}
+
+	client.EXPECT().
+		ExternalConnectionGetByLabel(ec.ExternalConnectionLabel).
+		Return(&ec, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ExternalConnectionDelete(ec.ExternalConnectionID).
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"external_connection_id_or_label": "uplink1",
+		"autoconfirm":                     true,
+	})
+
+	_, err := externalConnectionDeleteCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
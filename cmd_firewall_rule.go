@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -23,7 +24,7 @@ var firewallRuleCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"instance_array_id": c.FlagSet.Int("ia", _nilDefaultInt, red("(Required)") + " The instance array id"),
-				"format":            c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: firewallRuleListCmd,
@@ -67,6 +68,87 @@ var firewallRuleCmds = []Command{
 		},
 		ExecuteFunc: firewallRuleDeleteCmd,
 	},
+	{
+		Description:  "Synchronize instance array firewall rules with a file.",
+		Subject:      "firewall-rule",
+		AltSubject:   "fw",
+		Predicate:    "sync",
+		AltPredicate: "sync",
+		FlagSet:      flag.NewFlagSet("sync firewall rules", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id":     c.FlagSet.Int("ia", _nilDefaultInt, red("(Required)")+" The instance array id"),
+				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, red("(Required)")+" The file with the rules, in yaml or csv format. Use '-' to read from stdin."),
+				"input_format":          c.FlagSet.String("input-format", _nilDefaultStr, "The format of the rules file. Supported values are 'yaml','csv'. By default the format is determined from the file extension, yaml is used if it is not .csv."),
+				"dry_run":               c.FlagSet.Bool("dry-run", false, green("(Flag)")+" If set the changes are shown but not applied."),
+				"autoconfirm":           c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"format":                tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: firewallRuleSyncCmd,
+		Example: `
+#create file rules.yaml:
+- protocol: tcp
+  port: "22"
+  source: 10.0.0.0/24
+  description: ssh from the office
+- protocol: tcp
+  port: 80-443
+  type: ipv6
+  enabled: false
+
+#or the equivalent rules.csv. Rules without an enabled value are enabled:
+protocol,port,source,destination,type,description,enabled
+tcp,22,10.0.0.0/24,,,ssh from the office,
+tcp,80-443,,,ipv6,,false
+
+#preview and apply the changes:
+metalcloud-cli firewall-rule sync --ia 100 -f rules.yaml --dry-run
+metalcloud-cli firewall-rule sync --ia 100 -f rules.yaml
+`,
+	},
+	{
+		Description:  "Export instance array firewall rules.",
+		Subject:      "firewall-rule",
+		AltSubject:   "fw",
+		Predicate:    "export",
+		AltPredicate: "dump",
+		FlagSet:      flag.NewFlagSet("export firewall rules", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id": c.FlagSet.Int("ia", _nilDefaultInt, red("(Required)")+" The instance array id"),
+				"export_format":     c.FlagSet.String("format", "yaml", "The format of the exported rules. Supported values are 'yaml','csv'. The default format is yaml."),
+			}
+		},
+		ExecuteFunc: firewallRuleExportCmd,
+		Example: `
+metalcloud-cli firewall-rule export --ia 100 > rules.yaml
+metalcloud-cli firewall-rule sync --ia 200 -f rules.yaml
+`,
+	},
+	{
+		Description:  "Check if traffic is allowed by the instance array firewall rules.",
+		Subject:      "firewall-rule",
+		AltSubject:   "fw",
+		Predicate:    "check",
+		AltPredicate: "test",
+		FlagSet:      flag.NewFlagSet("check firewall rules", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id":  c.FlagSet.Int("ia", _nilDefaultInt, red("(Required)")+" The instance array id"),
+				"packet_protocol":    c.FlagSet.String("proto", _nilDefaultStr, red("(Required)")+" The protocol of the traffic. Possible values: icmp, tcp, udp."),
+				"packet_port":        c.FlagSet.Int("port", _nilDefaultInt, "The destination port of the traffic. Required for tcp and udp."),
+				"packet_source":      c.FlagSet.String("src", _nilDefaultStr, red("(Required)")+" The source address of the traffic. IPv4 and IPv6 addresses are supported."),
+				"packet_destination": c.FlagSet.String("dst", _nilDefaultStr, "The destination address of the traffic. If not set the rules restricted to a destination are skipped."),
+				"format":             outputFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: firewallRuleCheckCmd,
+		Example: `
+metalcloud-cli firewall-rule check --ia 100 --proto tcp --port 443 --src 10.0.0.5
+metalcloud-cli firewall-rule check --ia 100 --proto icmp --src 2a02:cb80::1
+`,
+	},
 }
 
 func firewallRuleListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -192,7 +274,7 @@ func firewallRuleListCmd(c *Command, client metalcloud.MetalCloudClient) (string
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Rules", topLine, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Rules", topLine)
 }
 
 func firewallRuleAddCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -374,3 +456,380 @@ func addressStringToRange(s string) (string, string, error) {
 	return components[0], components[1], nil
 
 }
+
+func firewallRuleSyncCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	instanceArrayID, ok := getIntParamOk(c.Arguments["instance_array_id"])
+	if !ok {
+		return "", fmt.Errorf("-ia is required")
+	}
+
+	filePath, ok := getStringParamOk(c.Arguments["read_config_from_file"])
+	if !ok {
+		return "", fmt.Errorf("-f is required")
+	}
+
+	format, err := getFirewallRuleSpecsFormat(getStringParam(c.Arguments["input_format"]), filePath)
+	if err != nil {
+		return "", err
+	}
+
+	var content []byte
+	if filePath == "-" {
+		content, err = readInputFromPipe()
+	} else {
+		content, err = readInputFromFile(filePath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	specs, err := parseFirewallRuleSpecs(content, format)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", filePath, err)
+	}
+
+	desired := []metalcloud.FirewallRule{}
+	for i, spec := range specs {
+		fw, err := firewallRuleFromSpec(spec)
+		if err != nil {
+			return "", fmt.Errorf("%s: rule %d: %s", filePath, i, err)
+		}
+		desired = append(desired, fw)
+	}
+
+	retIA, err := client.InstanceArrayGet(instanceArrayID)
+	if err != nil {
+		return "", err
+	}
+
+	if !retIA.InstanceArrayOperation.InstanceArrayFirewallManaged {
+		return "", fmt.Errorf("the instance array %s [#%d] has firewall management disabled", retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	existing := retIA.InstanceArrayOperation.InstanceArrayFirewallRules
+	used := make([]bool, len(existing))
+
+	rules := []metalcloud.FirewallRule{}
+	actions := []string{}
+	added, removed, updated := 0, 0, 0
+
+	for _, fw := range desired {
+		action := "add"
+
+		for i, e := range existing {
+			if used[i] || !firewallRulesSame(e, fw) {
+				continue
+			}
+			used[i] = true
+
+			action = "unchanged"
+			if e.FirewallRuleDescription != fw.FirewallRuleDescription || e.FirewallRuleEnabled != fw.FirewallRuleEnabled {
+				action = "update"
+			}
+
+			//only the description and the enabled state are changed, the rule keeps its other properties
+			description, enabled := fw.FirewallRuleDescription, fw.FirewallRuleEnabled
+			fw = e
+			fw.FirewallRuleDescription = description
+			fw.FirewallRuleEnabled = enabled
+			break
+		}
+
+		switch action {
+		case "add":
+			added++
+		case "update":
+			updated++
+		}
+
+		rules = append(rules, fw)
+		actions = append(actions, action)
+	}
+
+	removedIndexes := []int{}
+	for i := range existing {
+		if !used[i] {
+			removedIndexes = append(removedIndexes, i)
+			removed++
+		}
+	}
+
+	conflicts := getFirewallRuleConflicts(rules)
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "RULE",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "ACTION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "PROTOCOL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "PORT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "SOURCE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "DEST",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "DESC.",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "CONFLICTS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	data := [][]interface{}{}
+
+	addRow := func(index int, action string, fw metalcloud.FirewallRule, notes string) {
+		spec := firewallRuleToSpec(fw)
+		data = append(data, []interface{}{
+			index,
+			action,
+			spec.Protocol,
+			spec.Port,
+			spec.Source,
+			spec.Destination,
+			spec.Type,
+			spec.Description,
+			notes,
+		})
+	}
+
+	//the rules are numbered from 0 as in firewall-rule list: the kept and added rules with their index
+	//after the sync and the removed rules with their current index
+	for i, fw := range rules {
+		addRow(i, actions[i], fw, strings.Join(conflicts[i], ", "))
+	}
+
+	for _, i := range removedIndexes {
+		addRow(i, "remove", existing[i], "")
+	}
+
+	topLine := fmt.Sprintf("Instance Array %s (%d): %d rules to add, %d to remove, %d to update, %d with conflicts", retIA.InstanceArrayLabel, retIA.InstanceArrayID, added, removed, updated, len(conflicts))
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	if getBoolParam(c.Arguments["dry_run"]) {
+		return renderTable(c, table, "Rules", topLine)
+	}
+
+	if added == 0 && removed == 0 && updated == 0 {
+		return renderTable(c, table, "Rules", topLine)
+	}
+
+	if removed > 0 {
+		confirm, err := confirmCommand(c, func() string {
+
+			confirmationMessage := fmt.Sprintf("Synchronizing firewall rules of instance array %s (%d) removes %d rules.  Are you sure? Type \"yes\" to continue:",
+				retIA.InstanceArrayLabel, retIA.InstanceArrayID, removed)
+
+			//this is simply so that we don't output a text on the command line under go test
+			if strings.HasSuffix(os.Args[0], ".test") {
+				confirmationMessage = ""
+			}
+
+			return confirmationMessage
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if !confirm {
+			return "", fmt.Errorf("Operation not confirmed. Aborting")
+		}
+	}
+
+	retIA.InstanceArrayOperation.InstanceArrayFirewallRules = rules
+
+	bFalse := false
+	_, err = client.InstanceArrayEdit(retIA.InstanceArrayID, *retIA.InstanceArrayOperation, &bFalse, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTable(c, table, "Rules", topLine)
+}
+
+func firewallRuleExportCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	instanceArrayID, ok := getIntParamOk(c.Arguments["instance_array_id"])
+	if !ok {
+		return "", fmt.Errorf("-ia is required")
+	}
+
+	format := getStringParam(c.Arguments["export_format"])
+	if format == "" {
+		format = "yaml"
+	}
+
+	retIA, err := client.InstanceArrayGet(instanceArrayID)
+	if err != nil {
+		return "", err
+	}
+
+	if !retIA.InstanceArrayOperation.InstanceArrayFirewallManaged {
+		return "", fmt.Errorf("the instance array %s [#%d] has firewall management disabled", retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	specs := []firewallRuleSpec{}
+	for _, fw := range retIA.InstanceArrayOperation.InstanceArrayFirewallRules {
+		specs = append(specs, firewallRuleToSpec(fw))
+	}
+
+	return renderFirewallRuleSpecs(specs, format)
+}
+
+func firewallRuleCheckCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	instanceArrayID, ok := getIntParamOk(c.Arguments["instance_array_id"])
+	if !ok {
+		return "", fmt.Errorf("-ia is required")
+	}
+
+	protocol, ok := getStringParamOk(c.Arguments["packet_protocol"])
+	if !ok {
+		return "", fmt.Errorf("-proto is required")
+	}
+
+	source, ok := getStringParamOk(c.Arguments["packet_source"])
+	if !ok {
+		return "", fmt.Errorf("-src is required")
+	}
+
+	packet, err := getFirewallPacket(
+		protocol,
+		getIntParam(c.Arguments["packet_port"]),
+		source,
+		getStringParam(c.Arguments["packet_destination"]))
+	if err != nil {
+		return "", err
+	}
+
+	retIA, err := client.InstanceArrayGet(instanceArrayID)
+	if err != nil {
+		return "", err
+	}
+
+	verdict := "allowed"
+	reason := "firewall management is disabled"
+	rule := ""
+	spec := firewallRuleSpec{}
+	warnings := []string{}
+
+	if retIA.InstanceArrayOperation.InstanceArrayFirewallManaged {
+		rules := retIA.InstanceArrayOperation.InstanceArrayFirewallRules
+
+		//the rule numbers are the ones shown by firewall-rule list
+		i, w := getFirewallRuleMatch(rules, packet)
+		warnings = w
+
+		if i >= 0 {
+			reason = fmt.Sprintf("matched by rule %d", i)
+			rule = fmt.Sprintf("%d", i)
+			spec = firewallRuleToSpec(rules[i])
+		} else {
+			verdict = "denied"
+			reason = "no rule matches, denied by default"
+		}
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "VERDICT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "REASON",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+		{
+			FieldName: "RULE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "PROTOCOL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "PORT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "SOURCE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "DEST",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "DESC.",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "WARNINGS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	data := [][]interface{}{{
+		verdict,
+		reason,
+		rule,
+		spec.Protocol,
+		spec.Port,
+		spec.Source,
+		spec.Destination,
+		spec.Type,
+		spec.Description,
+		strings.Join(warnings, "\n"),
+	}}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable(fmt.Sprintf("firewall check for instance array %s (%d)", retIA.InstanceArrayLabel, retIA.InstanceArrayID), "", getStringParam(c.Arguments["format"]))
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
+	"syscall"
 	"testing"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
@@ -461,3 +463,307 @@ func TestAddressStringToRange(t *testing.T) {
 	Expect(err).NotTo(BeNil())
 
 }
+
+func TestFirewallRuleSyncCmd(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	//the 10.0.0.0/24 source of the file is sent as the range of its addresses
+	ssh := metalcloud.FirewallRule{
+		FirewallRuleProtocol:                  "tcp",
+		FirewallRulePortRangeStart:            22,
+		FirewallRulePortRangeEnd:              22,
+		FirewallRuleSourceIPAddressRangeStart: "10.0.0.0",
+		FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.255",
+		FirewallRuleIPAddressType:             "ipv4",
+		FirewallRuleDescription:               "ssh",
+		FirewallRuleEnabled:                   true,
+	}
+
+	dns := metalcloud.FirewallRule{
+		FirewallRuleProtocol:       "udp",
+		FirewallRulePortRangeStart: 53,
+		FirewallRulePortRangeEnd:   53,
+		FirewallRuleIPAddressType:  "ipv4",
+		FirewallRuleEnabled:        true,
+	}
+
+	ping := metalcloud.FirewallRule{
+		FirewallRuleProtocol:      "icmp",
+		FirewallRuleIPAddressType: "ipv4",
+		FirewallRuleEnabled:       false,
+	}
+
+	iao := metalcloud.InstanceArrayOperation{
+		InstanceArrayID:              11,
+		InstanceArrayLabel:           "testia",
+		InstanceArrayFirewallManaged: true,
+		InstanceArrayFirewallRules:   []metalcloud.FirewallRule{ssh, dns, ping},
+	}
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:        11,
+		InstanceArrayLabel:     "testia",
+		InstanceArrayOperation: &iao,
+	}
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		DoAndReturn(func(id int) (*metalcloud.InstanceArray, error) {
+			//each call returns a copy so that the edits of a test case do not leak in the next one
+			o := iao
+			ret := ia
+			ret.InstanceArrayOperation = &o
+			return &ret, nil
+		}).
+		AnyTimes()
+
+	//ssh is kept with a new description, dns is removed, http is added, the disabled ping rule stays disabled
+	web := metalcloud.FirewallRule{
+		FirewallRuleProtocol:       "tcp",
+		FirewallRulePortRangeStart: 20,
+		FirewallRulePortRangeEnd:   80,
+		FirewallRuleIPAddressType:  "ipv4",
+		FirewallRuleEnabled:        true,
+	}
+
+	updatedSSH := ssh
+	updatedSSH.FirewallRuleDescription = "ssh from the office"
+
+	expectedIAO := iao
+	expectedIAO.InstanceArrayFirewallRules = []metalcloud.FirewallRule{updatedSSH, web, ping}
+
+	client.EXPECT().
+		InstanceArrayEdit(ia.InstanceArrayID, expectedIAO, gomock.Any(), nil, nil, nil).
+		Return(&ia, nil).
+		Times(1)
+
+	f, err := ioutil.TempFile("./", "testrules-*.csv")
+	if err != nil {
+		t.Error(err)
+	}
+	f.WriteString("protocol,port,source,description,enabled\ntcp,22,10.0.0.0/24,ssh from the office,\ntcp,20-80,,,true\nicmp,,,,false\n")
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id":     11,
+		"read_config_from_file": f.Name(),
+		"dry_run":               true,
+		"format":                "json",
+	})
+
+	//dry run does not edit the instance array
+	ret, err := firewallRuleSyncCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(4))
+
+	actions := []string{}
+	indexes := []float64{}
+	for _, r := range m {
+		actions = append(actions, r.(map[string]interface{})["ACTION"].(string))
+		indexes = append(indexes, r.(map[string]interface{})["RULE"].(float64))
+	}
+	Expect(actions).To(Equal([]string{"update", "add", "unchanged", "remove"}))
+
+	//the rules are numbered from 0 as in firewall-rule list, dns is removed from position 1
+	Expect(indexes).To(Equal([]float64{0, 1, 2, 1}))
+
+	//the port range 20-80 includes port 22 of the ssh rule
+	Expect(m[1].(map[string]interface{})["CONFLICTS"]).To(Equal("covers rule 0"))
+
+	cmd.Arguments["dry_run"] = &[]bool{false}[0]
+	cmd.Arguments["autoconfirm"] = &[]bool{true}[0]
+
+	_, err = firewallRuleSyncCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//invalid rules are reported with their position
+	f2, err := ioutil.TempFile("./", "testrules-*.yaml")
+	if err != nil {
+		t.Error(err)
+	}
+	f2.WriteString("- protocol: tcp\n  port: 22\n- protocol: sctp\n")
+	f2.Close()
+	defer syscall.Unlink(f2.Name())
+
+	cmd.Arguments["read_config_from_file"] = &[]string{f2.Name()}[0]
+	_, err = firewallRuleSyncCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("rule 1"))
+}
+
+func TestFirewallRuleExportCmd(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	iao := metalcloud.InstanceArrayOperation{
+		InstanceArrayID:              11,
+		InstanceArrayFirewallManaged: true,
+		InstanceArrayFirewallRules: []metalcloud.FirewallRule{
+			{
+				FirewallRuleProtocol:                  "tcp",
+				FirewallRulePortRangeStart:            80,
+				FirewallRulePortRangeEnd:              443,
+				FirewallRuleSourceIPAddressRangeStart: "192.168.0.1",
+				FirewallRuleSourceIPAddressRangeEnd:   "192.168.0.100",
+				FirewallRuleIPAddressType:             "ipv4",
+				FirewallRuleDescription:               "web",
+				FirewallRuleEnabled:                   true,
+			},
+			{
+				FirewallRuleProtocol:      "icmp",
+				FirewallRuleIPAddressType: "ipv4",
+			},
+		},
+	}
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:        11,
+		InstanceArrayOperation: &iao,
+	}
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		Return(&ia, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id": 11,
+		"export_format":     "csv",
+	})
+
+	ret, err := firewallRuleExportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(Equal("protocol,port,source,destination,type,description,enabled\ntcp,80-443,192.168.0.1-192.168.0.100,,ipv4,web,true\nicmp,,,,ipv4,,false\n"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"instance_array_id": 11,
+	})
+
+	ret, err = firewallRuleExportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(Equal("- protocol: tcp\n  port: 80-443\n  source: 192.168.0.1-192.168.0.100\n  type: ipv4\n  description: web\n  enabled: true\n- protocol: icmp\n  type: ipv4\n  enabled: false\n"))
+}
+
+func TestFirewallRuleCheckCmd(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	iao := metalcloud.InstanceArrayOperation{
+		InstanceArrayID:              11,
+		InstanceArrayFirewallManaged: true,
+		InstanceArrayFirewallRules: []metalcloud.FirewallRule{
+			{
+				FirewallRuleProtocol:                  "tcp",
+				FirewallRulePortRangeStart:            443,
+				FirewallRulePortRangeEnd:              443,
+				FirewallRuleSourceIPAddressRangeStart: "10.0.0.0/24",
+				FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.0/24",
+				FirewallRuleIPAddressType:             "ipv4",
+				FirewallRuleDescription:               "https",
+				FirewallRuleEnabled:                   true,
+			},
+		},
+	}
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:        11,
+		InstanceArrayLabel:     "testia",
+		InstanceArrayOperation: &iao,
+	}
+
+	unmanagedIA := metalcloud.InstanceArray{
+		InstanceArrayID:        12,
+		InstanceArrayLabel:     "unmanaged",
+		InstanceArrayOperation: &metalcloud.InstanceArrayOperation{},
+	}
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayGet(unmanagedIA.InstanceArrayID).
+		Return(&unmanagedIA, nil).
+		AnyTimes()
+
+	cases := []struct {
+		name    string
+		args    map[string]interface{}
+		verdict string
+		rule    string
+		good    bool
+	}{
+		{
+			name:    "matching rule",
+			args:    map[string]interface{}{"instance_array_id": 11, "packet_protocol": "tcp", "packet_port": 443, "packet_source": "10.0.0.5"},
+			verdict: "allowed",
+			rule:    "0",
+			good:    true,
+		},
+		{
+			name:    "default verdict",
+			args:    map[string]interface{}{"instance_array_id": 11, "packet_protocol": "tcp", "packet_port": 22, "packet_source": "10.0.0.5"},
+			verdict: "denied",
+			good:    true,
+		},
+		{
+			name:    "firewall not managed",
+			args:    map[string]interface{}{"instance_array_id": 12, "packet_protocol": "udp", "packet_port": 53, "packet_source": "2001:db8::1"},
+			verdict: "allowed",
+			good:    true,
+		},
+		{
+			name: "missing instance array",
+			args: map[string]interface{}{"packet_protocol": "tcp", "packet_port": 443, "packet_source": "10.0.0.5"},
+		},
+		{
+			name: "missing protocol",
+			args: map[string]interface{}{"instance_array_id": 11, "packet_port": 443, "packet_source": "10.0.0.5"},
+		},
+		{
+			name: "missing source",
+			args: map[string]interface{}{"instance_array_id": 11, "packet_protocol": "tcp", "packet_port": 443},
+		},
+		{
+			name: "missing port",
+			args: map[string]interface{}{"instance_array_id": 11, "packet_protocol": "tcp", "packet_source": "10.0.0.5"},
+		},
+	}
+
+	for _, c := range cases {
+		c.args["format"] = "json"
+		cmd := MakeCommand(c.args)
+
+		ret, err := firewallRuleCheckCmd(&cmd, client)
+		if !c.good {
+			if err == nil {
+				t.Errorf("case %s: expected error", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("case %s: unexpected error %s", c.name, err)
+			continue
+		}
+
+		var m []interface{}
+		Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+		r := m[0].(map[string]interface{})
+		Expect(r["VERDICT"]).To(Equal(c.verdict))
+		Expect(r["RULE"]).To(Equal(c.rule))
+	}
+}
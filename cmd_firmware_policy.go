@@ -0,0 +1,495 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+var firmwarePolicyCmds = []Command{
+
+	{
+		Description:  "Lists the rules of a firmware policy. The API has no call that lists the firmware policies themselves so the policy's id is required.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "list",
+		AltPredicate: "rules",
+		FlagSet:      flag.NewFlagSet("list firmware policy rules", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"format":    tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"raw":       c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
+			}
+		},
+		ExecuteFunc: firmwarePolicyRulesCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Create firmware policy.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "create",
+		AltPredicate: "new",
+		FlagSet:      flag.NewFlagSet("create firmware policy", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"label":     c.FlagSet.String("label", _nilDefaultStr, red("(Required)")+" The firmware policy's label."),
+				"action":    c.FlagSet.String("action", _nilDefaultStr, red("(Required)")+" The upgrade action of the policy. For example `upgrade_to_latest`."),
+				"return_id": c.FlagSet.Bool("return-id", false, "Will print the ID of the created object. Useful for automating tasks."),
+			}
+		},
+		ExecuteFunc: firmwarePolicyCreateCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli firmware-policy create --label bios-latest --action upgrade_to_latest --return-id
+`,
+	},
+	{
+		Description:  "Add a rule to a firmware policy.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "add-rule",
+		AltPredicate: "rule-add",
+		FlagSet:      flag.NewFlagSet("add firmware policy rule", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"operation": c.FlagSet.String("operation", _nilDefaultStr, red("(Required)")+" The operation of the rule. For example `string_equal`."),
+				"property":  c.FlagSet.String("property", _nilDefaultStr, red("(Required)")+" The property the rule applies to. For example `server_component_type`."),
+				"value":     c.FlagSet.String("value", _nilDefaultStr, red("(Required)")+" The value the property is compared to."),
+			}
+		},
+		ExecuteFunc: firmwarePolicyAddRuleCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli firmware-policy add-rule --id 10 --operation string_equal --property server_component_type --value bios
+`,
+	},
+	{
+		Description:  "Delete a rule from a firmware policy.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "delete-rule",
+		AltPredicate: "rule-rm",
+		FlagSet:      flag.NewFlagSet("delete firmware policy rule", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id":   c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"operation":   c.FlagSet.String("operation", _nilDefaultStr, red("(Required)")+" The operation of the rule."),
+				"property":    c.FlagSet.String("property", _nilDefaultStr, red("(Required)")+" The property the rule applies to."),
+				"value":       c.FlagSet.String("value", _nilDefaultStr, red("(Required)")+" The value the property is compared to."),
+				"autoconfirm": c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: firmwarePolicyDeleteRuleCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Assign a firmware policy to instance arrays.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "assign",
+		AltPredicate: "apply",
+		FlagSet:      flag.NewFlagSet("assign firmware policy", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id":          c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"instance_array_ids": c.FlagSet.String("ia", _nilDefaultStr, red("(Required)")+" Comma separated list of instance array ids."),
+				"replace":            c.FlagSet.Bool("replace", false, green("(Flag)")+" If set the policy is assigned only to the given instance arrays. By default they are added to the instance arrays the policy is already assigned to."),
+				"autoconfirm":        c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: firmwarePolicyAssignCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli firmware-policy assign --id 10 --ia 100,101
+`,
+	},
+	{
+		Description:  "Edit firmware policy.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "edit",
+		AltPredicate: "update",
+		FlagSet:      flag.NewFlagSet("edit firmware policy", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"label":     c.FlagSet.String("label", _nilDefaultStr, "The new label of the policy."),
+				"action":    c.FlagSet.String("action", _nilDefaultStr, "The new upgrade action of the policy."),
+			}
+		},
+		ExecuteFunc: firmwarePolicyEditCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Delete firmware policy.",
+		Subject:      "firmware-policy",
+		AltSubject:   "fw-policy",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete firmware policy", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"policy_id":   c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" The firmware policy's id."),
+				"autoconfirm": c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: firmwarePolicyDeleteCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+}
+
+func firmwarePolicyRulesCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	format := getStringParam(c.Arguments["format"])
+
+	if getBoolParam(c.Arguments["raw"]) {
+		return tableformatter.RenderRawObject(*policy, format, "ServerFirmwareUpgradePolicy")
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "INDEX",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "OPERATION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "PROPERTY",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "VALUE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+	}
+
+	data := [][]interface{}{}
+	for i, r := range policy.ServerFirmwareUpgradePolicyRules {
+		data = append(data, []interface{}{
+			i,
+			r.Operation,
+			r.Property,
+			r.Value,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	assigned := "not assigned"
+	if len(policy.InstanceArrayIDList) > 0 {
+		assigned = "assigned to instance arrays " + formatInstanceArrayIDList(policy.InstanceArrayIDList)
+	}
+
+	topLine := fmt.Sprintf("Firmware policy %s (#%d), action %s, %s",
+		policy.ServerFirmwareUpgradePolicyLabel,
+		policy.ServerFirmwareUpgradePolicyID,
+		policy.ServerFirmwareUpgradePolicyAction,
+		assigned)
+
+	return renderTable(c, table, "Rules", topLine)
+}
+
+func firmwarePolicyCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	label, ok := getStringParamOk(c.Arguments["label"])
+	if !ok {
+		return "", fmt.Errorf("-label is required")
+	}
+
+	action, ok := getStringParamOk(c.Arguments["action"])
+	if !ok {
+		return "", fmt.Errorf("-action is required")
+	}
+
+	ret, err := client.ServerFirmwareUpgradePolicyCreate(&metalcloud.ServerFirmwareUpgradePolicy{
+		ServerFirmwareUpgradePolicyLabel:  label,
+		ServerFirmwareUpgradePolicyAction: action,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if getBoolParam(c.Arguments["return_id"]) {
+		return fmt.Sprintf("%d", ret.ServerFirmwareUpgradePolicyID), nil
+	}
+
+	return "", nil
+}
+
+func firmwarePolicyAddRuleCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	rule, err := getFirmwarePolicyRuleFromCommand(c)
+	if err != nil {
+		return "", err
+	}
+
+	if getFirmwarePolicyRuleIndex(policy, *rule) != -1 {
+		return "", fmt.Errorf("firmware policy %s (#%d) already has this rule", policy.ServerFirmwareUpgradePolicyLabel, policy.ServerFirmwareUpgradePolicyID)
+	}
+
+	_, err = client.ServerFirmwarePolicyAddRule(policy.ServerFirmwareUpgradePolicyID, rule)
+
+	return "", err
+}
+
+func firmwarePolicyDeleteRuleCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	rule, err := getFirmwarePolicyRuleFromCommand(c)
+	if err != nil {
+		return "", err
+	}
+
+	if getFirmwarePolicyRuleIndex(policy, *rule) == -1 {
+		return "", fmt.Errorf("firmware policy %s (#%d) has no such rule", policy.ServerFirmwareUpgradePolicyLabel, policy.ServerFirmwareUpgradePolicyID)
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting rule %s %s %s of firmware policy %s (#%d).  Are you sure? Type \"yes\" to continue:",
+			rule.Property,
+			rule.Operation,
+			rule.Value,
+			policy.ServerFirmwareUpgradePolicyLabel,
+			policy.ServerFirmwareUpgradePolicyID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.ServerFirmwarePolicyDeleteRule(policy.ServerFirmwareUpgradePolicyID, rule)
+}
+
+func firmwarePolicyAssignCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := getStringParamOk(c.Arguments["instance_array_ids"])
+	if !ok {
+		return "", fmt.Errorf("-ia is required")
+	}
+
+	ids, err := parseInstanceArrayIDList(s)
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range ids {
+		if _, err := client.InstanceArrayGet(id); err != nil {
+			return "", err
+		}
+	}
+
+	replace := getBoolParam(c.Arguments["replace"])
+
+	if !replace {
+		ids = mergeInstanceArrayIDLists(policy.InstanceArrayIDList, ids)
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Assigning firmware policy %s (#%d) to instance arrays %s.  Are you sure? Type \"yes\" to continue:",
+			policy.ServerFirmwareUpgradePolicyLabel,
+			policy.ServerFirmwareUpgradePolicyID,
+			formatInstanceArrayIDList(ids))
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.ServerFirmwareUgradePolicyInstanceArraySet(policy.ServerFirmwareUpgradePolicyID, ids)
+}
+
+func firmwarePolicyEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	label, labelOk := getStringParamOk(c.Arguments["label"])
+	action, actionOk := getStringParamOk(c.Arguments["action"])
+
+	if !labelOk && !actionOk {
+		return "", fmt.Errorf("-label or -action is required")
+	}
+
+	if labelOk {
+		err = client.ServerFirmwareUpgradePolicyLabelSet(policy.ServerFirmwareUpgradePolicyID, label)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if actionOk {
+		err = client.ServerFirmwareUpgradePolicyActionSet(policy.ServerFirmwareUpgradePolicyID, action)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+func firmwarePolicyDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	policy, err := getFirmwarePolicyFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting firmware policy %s (#%d).  Are you sure? Type \"yes\" to continue:",
+			policy.ServerFirmwareUpgradePolicyLabel,
+			policy.ServerFirmwareUpgradePolicyID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.ServerFirmwareUpgradePolicyDelete(policy.ServerFirmwareUpgradePolicyID)
+}
+
+func getFirmwarePolicyFromCommand(c *Command, client metalcloud.MetalCloudClient) (*metalcloud.ServerFirmwareUpgradePolicy, error) {
+	id, ok := getIntParamOk(c.Arguments["policy_id"])
+	if !ok {
+		return nil, fmt.Errorf("-id is required")
+	}
+
+	return client.ServerFirmwarePolicyGet(id)
+}
+
+func getFirmwarePolicyRuleFromCommand(c *Command) (*metalcloud.ServerFirmwareUpgradePolicyRule, error) {
+	rule := metalcloud.ServerFirmwareUpgradePolicyRule{}
+
+	var ok bool
+
+	if rule.Operation, ok = getStringParamOk(c.Arguments["operation"]); !ok {
+		return nil, fmt.Errorf("-operation is required")
+	}
+
+	if rule.Property, ok = getStringParamOk(c.Arguments["property"]); !ok {
+		return nil, fmt.Errorf("-property is required")
+	}
+
+	if rule.Value, ok = getStringParamOk(c.Arguments["value"]); !ok {
+		return nil, fmt.Errorf("-value is required")
+	}
+
+	return &rule, nil
+}
+
+//getFirmwarePolicyRuleIndex returns the index of the rule in the policy or -1 if the policy does not have it
+func getFirmwarePolicyRuleIndex(policy *metalcloud.ServerFirmwareUpgradePolicy, rule metalcloud.ServerFirmwareUpgradePolicyRule) int {
+	for i, r := range policy.ServerFirmwareUpgradePolicyRules {
+		if r == rule {
+			return i
+		}
+	}
+	return -1
+}
+
+//parseInstanceArrayIDList parses a comma separated list of instance array ids
+func parseInstanceArrayIDList(s string) ([]int, error) {
+	ids := []int{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid instance array id %s", v)
+		}
+		ids = append(ids, id)
+	}
+	return mergeInstanceArrayIDLists(nil, ids), nil
+}
+
+//mergeInstanceArrayIDLists returns the sorted union of the two lists
+func mergeInstanceArrayIDLists(a []int, b []int) []int {
+	seen := map[int]bool{}
+	ret := []int{}
+	for _, id := range append(append([]int{}, a...), b...) {
+		if !seen[id] {
+			seen[id] = true
+			ret = append(ret, id)
+		}
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+func formatInstanceArrayIDList(ids []int) string {
+	s := []string{}
+	for _, id := range ids {
+		s = append(s, fmt.Sprintf("#%d", id))
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func getTestFirmwarePolicy() metalcloud.ServerFirmwareUpgradePolicy {
+	return metalcloud.ServerFirmwareUpgradePolicy{
+		ServerFirmwareUpgradePolicyID:     10,
+		ServerFirmwareUpgradePolicyLabel:  "bios-latest",
+		ServerFirmwareUpgradePolicyAction: "upgrade_to_latest",
+		ServerFirmwareUpgradePolicyRules: []metalcloud.ServerFirmwareUpgradePolicyRule{
+			{
+				Operation: "string_equal",
+				Property:  "server_component_type",
+				Value:     "bios",
+			},
+		},
+		InstanceArrayIDList: []int{100},
+	}
+}
+
+func TestFirmwarePolicyRulesCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	policy := getTestFirmwarePolicy()
+
+	client.EXPECT().
+		ServerFirmwarePolicyGet(10).
+		Return(&policy, nil).
+		AnyTimes()
+
+	expectedFirstRow := map[string]interface{}{
+		"INDEX":     0,
+		"OPERATION": "string_equal",
+		"PROPERTY":  "server_component_type",
+		"VALUE":     "bios",
+	}
+
+	cmd := MakeCommand(map[string]interface{}{
+		"policy_id": 10,
+	})
+
+	testListCommand(firmwarePolicyRulesCmd, &cmd, client, expectedFirstRow, t)
+}
+
+func TestFirmwarePolicyCreateCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	policy := getTestFirmwarePolicy()
+
+	client.EXPECT().
+		ServerFirmwareUpgradePolicyCreate(gomock.Any()).
+		Return(&policy, nil).
+		AnyTimes()
+
+	cases := []CommandTestCase{
+		{
+			name: "good1",
+			cmd: MakeCommand(map[string]interface{}{
+				"label":     "bios-latest",
+				"action":    "upgrade_to_latest",
+				"return_id": true,
+			}),
+			good: true,
+			id:   10,
+		},
+		{
+			name: "missing action",
+			cmd: MakeCommand(map[string]interface{}{
+				"label": "bios-latest",
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(firmwarePolicyCreateCmd, cases, client, t)
+}
+
+func TestFirmwarePolicyAddDeleteRuleCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	policy := getTestFirmwarePolicy()
+
+	client.EXPECT().
+		ServerFirmwarePolicyGet(10).
+		Return(&policy, nil).
+		AnyTimes()
+
+	newRule := metalcloud.ServerFirmwareUpgradePolicyRule{
+		Operation: "string_equal",
+		Property:  "server_component_type",
+		Value:     "bmc",
+	}
+
+	client.EXPECT().
+		ServerFirmwarePolicyAddRule(10, &newRule).
+		Return(&policy, nil).
+		Times(1)
+
+	client.EXPECT().
+		ServerFirmwarePolicyDeleteRule(10, &policy.ServerFirmwareUpgradePolicyRules[0]).
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"policy_id": 10,
+		"operation": "string_equal",
+		"property":  "server_component_type",
+		"value":     "bmc",
+	})
+
+	_, err := firmwarePolicyAddRuleCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//the rule is not in the policy
+	cmd.Arguments["autoconfirm"] = &[]bool{true}[0]
+	_, err = firmwarePolicyDeleteRuleCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"policy_id":   10,
+		"operation":   "string_equal",
+		"property":    "server_component_type",
+		"value":       "bios",
+		"autoconfirm": true,
+	})
+
+	//the rule is already in the policy
+	_, err = firmwarePolicyAddRuleCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	_, err = firmwarePolicyDeleteRuleCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
+
+func TestFirmwarePolicyAssignCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	policy := getTestFirmwarePolicy()
+
+	client.EXPECT().
+		ServerFirmwarePolicyGet(10).
+		Return(&policy, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayGet(gomock.Any()).
+		Return(&metalcloud.InstanceArray{}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerFirmwareUgradePolicyInstanceArraySet(10, []int{100, 101, 102}).
+		Return(nil).
+		Times(1)
+
+	client.EXPECT().
+		ServerFirmwareUgradePolicyInstanceArraySet(10, []int{102}).
+		Return(nil).
+		Times(1)
+
+	cases := []CommandTestCase{
+		{
+			name: "added to the existing instance arrays",
+			cmd: MakeCommand(map[string]interface{}{
+				"policy_id":          10,
+				"instance_array_ids": "102, 101,102",
+				"autoconfirm":        true,
+			}),
+			good: true,
+		},
+		{
+			name: "replace",
+			cmd: MakeCommand(map[string]interface{}{
+				"policy_id":          10,
+				"instance_array_ids": "102",
+				"replace":            true,
+				"autoconfirm":        true,
+			}),
+			good: true,
+		},
+		{
+			name: "invalid id",
+			cmd: MakeCommand(map[string]interface{}{
+				"policy_id":          10,
+				"instance_array_ids": "102,ia",
+				"autoconfirm":        true,
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(firmwarePolicyAssignCmd, cases, client, t)
+}
@@ -39,7 +39,7 @@ var infrastructureCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list infrastructure", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":       c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":       tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"filter":       c.FlagSet.String("filter", "*", "filter to use when searching for servers. Check the documentation for examples. Defaults to '*'"),
 				"show_ordered": c.FlagSet.Bool("show-ordered", false, green("(Flag)")+" If set will also return ordered (created but not deployed) infrastructures. Default is false."),
 				"show_deleted": c.FlagSet.Bool("show-deleted", false, green("(Flag)")+" If set will also return deleted infrastructures. Default is false."),
@@ -98,7 +98,7 @@ var infrastructureCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"infrastructure_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Infrastructure's id or label. Note that using the 'label' might be ambiguous in certain situations."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: infrastructureGetCmd,
@@ -277,7 +277,7 @@ func infrastructureListCmd(c *Command, client metalcloud.MetalCloudClient) (stri
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Infrastructures", topLine, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Infrastructures", topLine)
 }
 
 type infrastructureConfirmAndDoFunc func(infraID int, c *Command, client metalcloud.MetalCloudClient) (string, error)
@@ -550,7 +550,7 @@ func infrastructureGetCmd(c *Command, client metalcloud.MetalCloudClient) (strin
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Infrastructures", topLine, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Infrastructures", topLine)
 }
 
 func listWorkflowStagesCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -637,7 +637,7 @@ func listWorkflowStagesCmd(c *Command, client metalcloud.MetalCloudClient) (stri
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Workflow Stages", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Workflow Stages", "")
 }
 
 //getInfrastructureFromCommand returns an Infrastructure object using the infrastructure_id_or_label argument
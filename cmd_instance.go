@@ -22,12 +22,18 @@ var instanceCmds = []Command{
 		FlagSet:      flag.NewFlagSet("instance_array", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"instance_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Instances's id . Note that the 'label' this be ambiguous in certain situations."),
-				"operation":   c.FlagSet.String("operation", _nilDefaultStr, red("(Required)")+" Power control operation, one of: on, off, reset, soft"),
-				"autoconfirm": c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"instance_id":                c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Instances's id . Note that the 'label' this be ambiguous in certain situations. Either this or -ia must be used."),
+				"instance_array_id_or_label": c.FlagSet.String("ia", _nilDefaultStr, "Instance array's id or label. If set the operation is performed on all the instances of the instance array."),
+				"operation":                  c.FlagSet.String("operation", _nilDefaultStr, red("(Required)")+" Power control operation, one of: on, off, reset, soft"),
+				"autoconfirm":                c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"format":                     tableFormatFlag(c, "", "The output format of the results when used with -ia. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: instancePowerControlCmd,
+		Example: `
+metalcloud-cli instance power-control --id 1200 --operation reset
+metalcloud-cli instance power-control --ia workers --operation reset --autoconfirm
+		`,
 	},
 
 	{
@@ -42,7 +48,7 @@ var instanceCmds = []Command{
 				"instance_id":           c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Instances's id . Note that the 'label' this be ambiguous in certain situations."),
 				"show_credentials":      c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the instance's credentials"),
 				"show_custom_variables": c.FlagSet.Bool("show-custom-variables", false, green("(Flag)")+" If set returns the instance's custom variables"),
-				"format":                c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: instanceGetCmd,
@@ -83,17 +89,51 @@ var instanceCmds = []Command{
 	},
 }
 
+//powerOperations are the operations supported by InstanceServerPowerSet
+var powerOperations = []string{"on", "off", "reset", "soft"}
+
+//getPowerOperationDescription returns the text used in confirmation messages for a power operation
+func getPowerOperationDescription(operation string) string {
+	switch operation {
+	case "on":
+		return "Turning on"
+	case "off":
+		return "Turning off (hard)"
+	case "reset":
+		return "Rebooting"
+	case "soft":
+		return "Shutting down"
+	}
+	return ""
+}
+
 func instancePowerControlCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 
-	instanceID, ok := getIntParamOk(c.Arguments["instance_id"])
-	if !ok {
-		return "", fmt.Errorf("-id is required (drive id)")
-	}
 	operation, ok := getStringParamOk(c.Arguments["operation"])
 	if !ok {
 		return "", fmt.Errorf("-operation is required (one of: on, off, reset, soft)")
 	}
 
+	if !stringInSlice(operation, powerOperations) {
+		return "", fmt.Errorf("invalid operation %s. Possible values: %s", operation, strings.Join(powerOperations, ", "))
+	}
+
+	_, hasInstance := getIntParamOk(c.Arguments["instance_id"])
+	_, hasInstanceArray := getStringParamOk(c.Arguments["instance_array_id_or_label"])
+
+	if hasInstance && hasInstanceArray {
+		return "", fmt.Errorf("-id and -ia cannot be used together")
+	}
+
+	if hasInstanceArray {
+		return instanceArrayPowerControl(c, client, operation)
+	}
+
+	instanceID, ok := getIntParamOk(c.Arguments["instance_id"])
+	if !ok {
+		return "", fmt.Errorf("-id or -ia is required")
+	}
+
 	instance, err := client.InstanceGet(instanceID)
 	if err != nil {
 		return "", err
@@ -111,20 +151,8 @@ func instancePowerControlCmd(c *Command, client metalcloud.MetalCloudClient) (st
 
 	confirm, err := confirmCommand(c, func() string {
 
-		op := ""
-		switch operation {
-		case "on":
-			op = "Turning on"
-		case "off":
-			op = "Turning off (hard)"
-		case "reset":
-			op = "Rebooting"
-		case "soft":
-			op = "Shutting down"
-		}
-
 		confirmationMessage := fmt.Sprintf("%s instance %s (%d) of instance array %s (#%d) infrastructure %s (#%d).  Are you sure? Type \"yes\" to continue:",
-			op,
+			getPowerOperationDescription(operation),
 			instance.InstanceLabel,
 			instance.InstanceID,
 			ia.InstanceArrayLabel,
@@ -153,6 +181,116 @@ func instancePowerControlCmd(c *Command, client metalcloud.MetalCloudClient) (st
 	return "", err
 }
 
+//instanceArrayPowerControl performs a power operation on all the instances of an instance array.
+//An error on one instance does not stop the operation, the result is reported for each instance.
+func instanceArrayPowerControl(c *Command, client metalcloud.MetalCloudClient, operation string) (string, error) {
+
+	ia, err := getInstanceArrayFromCommand("ia", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	infra, err := client.InfrastructureGet(ia.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	instances, err := getInstanceArrayInstancesSorted(ia.InstanceArrayID, client)
+	if err != nil {
+		return "", err
+	}
+
+	if len(instances) == 0 {
+		return "", fmt.Errorf("instance array %s (#%d) has no instances", ia.InstanceArrayLabel, ia.InstanceArrayID)
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("%s all %d instances of instance array %s (#%d) infrastructure %s (#%d).  Are you sure? Type \"yes\" to continue:",
+			getPowerOperationDescription(operation),
+			len(instances),
+			ia.InstanceArrayLabel,
+			ia.InstanceArrayID,
+			infra.InfrastructureLabel,
+			infra.InfrastructureID,
+		)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	data := [][]interface{}{}
+	failed := 0
+
+	for _, i := range instances {
+		result := "ok"
+		errStr := ""
+
+		if err := client.InstanceServerPowerSet(i.InstanceID, operation); err != nil {
+			result = "failed"
+			errStr = err.Error()
+			failed++
+		}
+
+		data = append(data, []interface{}{
+			i.InstanceID,
+			i.InstanceLabel,
+			operation,
+			result,
+			errStr,
+		})
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "OPERATION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "RESULT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "ERROR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d of %d instances processed, %d failed", len(instances)-failed, len(instances), failed)
+
+	return renderTable(c, table, "Instances", topLine)
+}
+
 func instanceEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 
 	instanceID, ok := getIntParamOk(c.Arguments["instance_id"])
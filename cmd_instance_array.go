@@ -4,8 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
 	"github.com/metalsoft-io/tableformatter"
@@ -55,7 +57,7 @@ var instanceArrayCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"infrastructure_id_or_label": c.FlagSet.String("infra", _nilDefaultStr, red("(Required)")+" Infrastructure's id or label. Note that the 'label' this be ambiguous in certain situations."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: instanceArrayListCmd,
@@ -71,7 +73,7 @@ var instanceArrayCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: instanceArrayInstancesListCmd,
@@ -136,12 +138,125 @@ var instanceArrayCmds = []Command{
 				"show_power_status":          c.FlagSet.Bool("show-power-status", false, green("(Flag)")+" If set returns the instances' power status"),
 				"show_iscsi_credentials":     c.FlagSet.Bool("show-iscsi-credentials", false, green("(Flag)")+" If set returns the instances' iscsi credentials"),
 				"show_custom_variables":      c.FlagSet.Bool("show-custom-variables", false, green("(Flag)")+" If set returns the instances' custom variables"),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: instanceArrayGetCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Attach an instance array interface to a network.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "interface-attach",
+		AltPredicate: "attach-intf",
+		FlagSet:      flag.NewFlagSet("attach instance array interface", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"interface_port":             c.FlagSet.Int("port", _nilDefaultInt, red("(Required)")+" The interface's port number as shown by 'network list' (starting from 1)."),
+				"network_id_or_label":        c.FlagSet.String("network", _nilDefaultStr, red("(Required)")+" Network's id or label. Note that the label can be ambigous."),
+			}
+		},
+		ExecuteFunc: instanceArrayInterfaceAttachCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli instance-array interface-attach --id workers --port 2 --network backend-lan
+		`,
+	},
+	{
+		Description:  "Detach an instance array interface from its network.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "interface-detach",
+		AltPredicate: "detach-intf",
+		FlagSet:      flag.NewFlagSet("detach instance array interface", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"interface_port":             c.FlagSet.Int("port", _nilDefaultInt, red("(Required)")+" The interface's port number as shown by 'network list' (starting from 1)."),
+			}
+		},
+		ExecuteFunc: instanceArrayInterfaceDetachCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Start an instance array.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "start",
+		AltPredicate: "power-on",
+		FlagSet:      flag.NewFlagSet("start instance array", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"autoconfirm":                c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: instanceArrayStartCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Stop an instance array.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "stop",
+		AltPredicate: "power-off",
+		FlagSet:      flag.NewFlagSet("stop instance array", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"autoconfirm":                c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: instanceArrayStopCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Show the power status of the instances of an instance array.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "power-status",
+		AltPredicate: "pwr-status",
+		FlagSet:      flag.NewFlagSet("instance array power status", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: instanceArrayPowerStatusCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Restart the instances of an instance array in batches.",
+		Subject:      "instance-array",
+		AltSubject:   "ia",
+		Predicate:    "rolling-restart",
+		AltPredicate: "rolling-reboot",
+		FlagSet:      flag.NewFlagSet("rolling restart instance array", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"instance_array_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+				"batch_size":                 c.FlagSet.Int("batch-size", 1, "The number of instances restarted at the same time. Defaults to 1."),
+				"pause":                      c.FlagSet.String("pause", "0s", "How long to wait after a batch is powered on before restarting the next one, eg: 30s, 5m. Defaults to 0s."),
+				"block_timeout":              c.FlagSet.Int("block-timeout", 15*60, "How long to wait for a batch to be powered off and then for it to be powered on, in seconds. After this timeout the restart is aborted. Defaults to 15 minutes."),
+				"block_check_interval":       c.FlagSet.Int("block-check-interval", 10, "Check interval for the power status. Defaults to 10 seconds."),
+				"hard":                       c.FlagSet.Bool("hard", false, green("(Flag)")+" If set the instances are hard powered off instead of being shut down by their OS."),
+				"dry_run":                    c.FlagSet.Bool("dry-run", false, green("(Flag)")+" If set the batches are printed but no instance is restarted."),
+				"autoconfirm":                c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: instanceArrayRollingRestartCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli instance-array rolling-restart --id workers --batch-size 2 --pause 60s --dry-run
+metalcloud-cli instance-array rolling-restart --id workers --batch-size 2 --pause 60s
+#instances that do not shut down in time can be hard powered off:
+metalcloud-cli instance-array rolling-restart --id workers --batch-size 2 --hard
+		`,
+	},
 }
 
 func instanceArrayCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -311,7 +426,7 @@ func instanceArrayListCmd(c *Command, client metalcloud.MetalCloudClient) (strin
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Instance Arrays", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Instance Arrays", "")
 }
 
 func instanceArrayDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -427,7 +542,7 @@ func instanceArrayGetNetworkAttachments(c *Command, client metalcloud.MetalCloud
 	}
 	subtitleNetworkAttachmentsRender := "NETWORK ATTACHEMENTS\n--------------------\nNetworks to which this instance array is attached to:\n"
 
-	return tableNetworkAttachments.RenderTable("", subtitleNetworkAttachmentsRender, getStringParam(c.Arguments["format"]))
+	return renderTable(c, tableNetworkAttachments, "", subtitleNetworkAttachmentsRender)
 }
 
 func instanceArrayGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -528,7 +643,7 @@ func instanceArrayGetCmd(c *Command, client metalcloud.MetalCloudClient) (string
 		Schema: schema,
 	}
 
-	return table.RenderTable("", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "", "")
 }
 
 func instanceArrayInstancesListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -718,7 +833,7 @@ func instanceArrayInstancesListCmd(c *Command, client metalcloud.MetalCloudClien
 		Schema: schema,
 	}
 
-	return table.RenderTable("Instances", subtitle, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Instances", subtitle)
 }
 
 func argsToInstanceArray(m map[string]interface{}, c *Command, client metalcloud.MetalCloudClient) (*metalcloud.InstanceArray, error) {
@@ -884,6 +999,420 @@ func copyInstanceArrayInterfaceToOperation(i metalcloud.InstanceArrayInterface,
 	io.NetworkID = i.NetworkID
 }
 
+func instanceArrayInterfaceAttachCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retIA, err := getInstanceArrayFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := getInstanceArrayInterfaceIndexFromCommand(c, retIA)
+	if err != nil {
+		return "", err
+	}
+
+	retNW, err := getNetworkFromCommand("network", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	if retNW.InfrastructureID != retIA.InfrastructureID {
+		return "", fmt.Errorf("network %s (#%d) and instance array %s (#%d) are not in the same infrastructure", retNW.NetworkLabel, retNW.NetworkID, retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	_, err = client.InstanceArrayInterfaceAttachNetwork(retIA.InstanceArrayID, index, retNW.NetworkID)
+
+	return "", err
+}
+
+func instanceArrayInterfaceDetachCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retIA, err := getInstanceArrayFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := getInstanceArrayInterfaceIndexFromCommand(c, retIA)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.InstanceArrayInterfaceDetach(retIA.InstanceArrayID, index)
+
+	return "", err
+}
+
+//getInstanceArrayInterfaceIndexFromCommand converts the port given with -port, which starts from 1, to the interface index
+func getInstanceArrayInterfaceIndexFromCommand(c *Command, ia *metalcloud.InstanceArray) (int, error) {
+	port, ok := getIntParamOk(c.Arguments["interface_port"])
+	if !ok {
+		return 0, fmt.Errorf("-port is required")
+	}
+
+	for _, i := range ia.InstanceArrayInterfaces {
+		if i.InstanceArrayInterfaceIndex == port-1 {
+			return i.InstanceArrayInterfaceIndex, nil
+		}
+	}
+
+	return 0, fmt.Errorf("instance array %s (#%d) has no interface with port #%d", ia.InstanceArrayLabel, ia.InstanceArrayID, port)
+}
+
+func instanceArrayStartCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	return instanceArrayStartStop(c, client, true)
+}
+
+func instanceArrayStopCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	return instanceArrayStartStop(c, client, false)
+}
+
+func instanceArrayStartStop(c *Command, client metalcloud.MetalCloudClient, start bool) (string, error) {
+
+	retIA, err := getInstanceArrayFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	retInfra, err := client.InfrastructureGet(retIA.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		op := "Stopping"
+		if start {
+			op = "Starting"
+		}
+
+		confirmationMessage := fmt.Sprintf("%s instance array %s (%d) - from infrastructure %s (%d).  Are you sure? Type \"yes\" to continue:",
+			op,
+			retIA.InstanceArrayLabel, retIA.InstanceArrayID,
+			retInfra.InfrastructureLabel, retInfra.InfrastructureID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	if start {
+		_, err = client.InstanceArrayStart(retIA.InstanceArrayID)
+	} else {
+		_, err = client.InstanceArrayStop(retIA.InstanceArrayID)
+	}
+
+	return "", err
+}
+
+func instanceArrayPowerStatusCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retIA, err := getInstanceArrayFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	instances, err := getInstanceArrayInstancesSorted(retIA.InstanceArrayID, client)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []int{}
+	for _, i := range instances {
+		ids = append(ids, i.InstanceID)
+	}
+
+	powerStatus, err := client.InstanceServerPowerGetBatch(retIA.InfrastructureID, ids)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SERVER",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "POWER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, i := range instances {
+		status, ok := (*powerStatus)[strconv.Itoa(i.InstanceID)]
+		if !ok {
+			status = "unknown"
+		}
+
+		data = append(data, []interface{}{
+			i.InstanceID,
+			i.InstanceLabel,
+			i.ServerID,
+			status,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Instance array %s (#%d) has %d instances", retIA.InstanceArrayLabel, retIA.InstanceArrayID, len(instances))
+
+	return renderTable(c, table, "Instances", topLine)
+}
+
+func instanceArrayRollingRestartCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	batchSize := getIntParam(c.Arguments["batch_size"])
+	if batchSize < 1 {
+		return "", fmt.Errorf("-batch-size must be at least 1")
+	}
+
+	pause, err := time.ParseDuration(getStringParam(c.Arguments["pause"]))
+	if err != nil {
+		return "", fmt.Errorf("invalid -pause: %s", err)
+	}
+
+	retIA, err := getInstanceArrayFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	instances, err := getInstanceArrayInstancesSorted(retIA.InstanceArrayID, client)
+	if err != nil {
+		return "", err
+	}
+
+	if len(instances) == 0 {
+		return "", fmt.Errorf("instance array %s (#%d) has no instances", retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	batches := [][]metalcloud.Instance{}
+	for i := 0; i < len(instances); i += batchSize {
+		end := i + batchSize
+		if end > len(instances) {
+			end = len(instances)
+		}
+		batches = append(batches, instances[i:end])
+	}
+
+	dryRun := getBoolParam(c.Arguments["dry_run"])
+
+	if !dryRun {
+		retInfra, err := client.InfrastructureGet(retIA.InfrastructureID)
+		if err != nil {
+			return "", err
+		}
+
+		confirm, err := confirmCommand(c, func() string {
+
+			confirmationMessage := fmt.Sprintf("Rebooting %d instances of instance array %s (#%d) infrastructure %s (#%d) in %d batches.  Are you sure? Type \"yes\" to continue:",
+				len(instances),
+				retIA.InstanceArrayLabel, retIA.InstanceArrayID,
+				retInfra.InfrastructureLabel, retInfra.InfrastructureID,
+				len(batches))
+
+			//this is simply so that we don't output a text on the command line under go test
+			if strings.HasSuffix(os.Args[0], ".test") {
+				confirmationMessage = ""
+			}
+
+			return confirmationMessage
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if !confirm {
+			return "", fmt.Errorf("Operation not confirmed. Aborting")
+		}
+	}
+
+	timeout := getIntParam(c.Arguments["block_timeout"])
+	checkInterval := getIntParam(c.Arguments["block_check_interval"])
+
+	//the OS of the instances is asked to shut down unless -hard is used
+	powerOffOperation := "soft"
+	if getBoolParam(c.Arguments["hard"]) {
+		powerOffOperation = "off"
+	}
+
+	data := [][]interface{}{}
+	restarted := 0
+
+	for b, batch := range batches {
+
+		if !dryRun {
+			if b > 0 {
+				time.Sleep(pause)
+			}
+
+			ids := []int{}
+			for _, i := range batch {
+				ids = append(ids, i.InstanceID)
+			}
+
+			//a reset does not change the power status so there would be no way to tell when the
+			//instances are back. The batch is powered off and then on, waiting for each state.
+			for _, operation := range []string{powerOffOperation, "on"} {
+				powerStatus := "on"
+				if operation != "on" {
+					powerStatus = "off"
+				}
+
+				for _, i := range batch {
+					if err := client.InstanceServerPowerSet(i.InstanceID, operation); err != nil {
+						return "", fmt.Errorf("batch %d, instance %s (#%d): %s (%d of %d instances restarted before the error)", b+1, i.InstanceLabel, i.InstanceID, err, restarted, len(instances))
+					}
+				}
+
+				if err := loopUntilInstancesPowerStatus(ids, powerStatus, timeout, checkInterval, client); err != nil {
+					return "", fmt.Errorf("batch %d: %s (%d of %d instances restarted before the error)", b+1, err, restarted, len(instances))
+				}
+			}
+		}
+
+		for _, i := range batch {
+			result := "restarted"
+			if dryRun {
+				result = "planned"
+			}
+
+			data = append(data, []interface{}{
+				b + 1,
+				i.InstanceID,
+				i.InstanceLabel,
+				i.ServerID,
+				result,
+			})
+			restarted++
+		}
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "BATCH",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SERVER",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "RESULT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d instances restarted in %d batches", len(instances), len(batches))
+	if dryRun {
+		topLine = fmt.Sprintf("Dry run: %d instances would be restarted in %d batches of up to %d instances, pausing %s between batches", len(instances), len(batches), batchSize, pause)
+	}
+
+	return renderTable(c, table, "Instances", topLine)
+}
+
+//loop until all the instances have the given power status. The polling stops on timeout.
+func loopUntilInstancesPowerStatus(instanceIDs []int, powerStatus string, timeoutSeconds int, checkIntervalSeconds int, client metalcloud.MetalCloudClient) error {
+	c := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		pending := instanceIDs
+		for len(pending) > 0 {
+			stillPending := []int{}
+			for _, id := range pending {
+				status, err := client.InstanceServerPowerGet(id)
+				if err != nil {
+					c <- err
+					return
+				}
+				if *status != powerStatus {
+					stillPending = append(stillPending, id)
+				}
+			}
+			pending = stillPending
+
+			if len(pending) > 0 {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Duration(checkIntervalSeconds) * time.Second):
+				}
+			}
+		}
+		c <- nil
+	}()
+
+	select {
+	case err := <-c:
+		return err
+	case <-time.After(time.Duration(timeoutSeconds) * time.Second):
+		return fmt.Errorf("timeout after %d seconds while waiting for the instances to be powered %s", timeoutSeconds, powerStatus)
+	}
+}
+
+//getInstanceArrayInstancesSorted returns the instances of an instance array sorted by id
+func getInstanceArrayInstancesSorted(instanceArrayID int, client metalcloud.MetalCloudClient) ([]metalcloud.Instance, error) {
+	iList, err := client.InstanceArrayInstances(instanceArrayID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []metalcloud.Instance{}
+	for _, i := range *iList {
+		instances = append(instances, i)
+	}
+
+	sort.Slice(instances, func(a, b int) bool {
+		return instances[a].InstanceID < instances[b].InstanceID
+	})
+
+	return instances, nil
+}
+
 func getInstanceArrayFromCommand(paramName string, c *Command, client metalcloud.MetalCloudClient) (*metalcloud.InstanceArray, error) {
 
 	m, err := getParam(c, "instance_array_id_or_label", paramName)
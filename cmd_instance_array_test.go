@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
@@ -595,3 +596,378 @@ func TestInstanceArrayInstancesListCmd(t *testing.T) {
 	Expect(csv[1][2]).To(Equal(ips[0].IPHumanReadable))
 
 }
+
+func TestInstanceArrayInterfaceAttachDetachCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    200,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+		InstanceArrayInterfaces: []metalcloud.InstanceArrayInterface{
+			{InstanceArrayInterfaceIndex: 0},
+			{InstanceArrayInterfaceIndex: 1},
+		},
+	}
+
+	client.EXPECT().
+		InstanceArrayGetByLabel(ia.InstanceArrayLabel).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		NetworkGetByLabel("backend").
+		Return(&metalcloud.Network{NetworkID: 10, NetworkLabel: "backend", InfrastructureID: 100}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		NetworkGetByLabel("other-infra").
+		Return(&metalcloud.Network{NetworkID: 11, NetworkLabel: "other-infra", InfrastructureID: 101}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayInterfaceAttachNetwork(ia.InstanceArrayID, 1, 10).
+		Return(&ia, nil).
+		Times(1)
+
+	client.EXPECT().
+		InstanceArrayInterfaceDetach(ia.InstanceArrayID, 0).
+		Return(&ia, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": "workers",
+		"interface_port":             2,
+		"network_id_or_label":        "backend",
+	})
+	_, err := instanceArrayInterfaceAttachCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	cmd.Arguments["network_id_or_label"] = &[]string{"other-infra"}[0]
+	_, err = instanceArrayInterfaceAttachCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": "workers",
+		"interface_port":             1,
+	})
+	_, err = instanceArrayInterfaceDetachCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//the port does not exist
+	cmd.Arguments["interface_port"] = &[]int{5}[0]
+	_, err = instanceArrayInterfaceDetachCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	_, err = instanceArrayInterfaceDetachCmd(&Command{Arguments: map[string]interface{}{"instance_array_id_or_label": &ia.InstanceArrayLabel}}, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestInstanceArrayStartStopCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    10,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+	}
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(ia.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayStart(ia.InstanceArrayID).
+		Return(&ia, nil).
+		Times(1)
+
+	client.EXPECT().
+		InstanceArrayStop(ia.InstanceArrayID).
+		Return(&ia, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": 10,
+		"autoconfirm":                true,
+	})
+
+	_, err := instanceArrayStartCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	_, err = instanceArrayStopCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
+
+func TestInstanceArrayPowerStatusCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    10,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+	}
+
+	instances := map[string]metalcloud.Instance{
+		"instance-111": {InstanceID: 111, InstanceLabel: "instance-111", ServerID: 201},
+		"instance-110": {InstanceID: 110, InstanceLabel: "instance-110", ServerID: 200},
+		"instance-112": {InstanceID: 112, InstanceLabel: "instance-112"},
+	}
+
+	client.EXPECT().
+		InstanceArrayGetByLabel(ia.InstanceArrayLabel).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayInstances(ia.InstanceArrayID).
+		Return(&instances, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceServerPowerGetBatch(ia.InfrastructureID, []int{110, 111, 112}).
+		Return(&map[string]string{"110": "on", "111": "off"}, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": "workers",
+		"format":                     "json",
+	})
+
+	ret, err := instanceArrayPowerStatusCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	power := []string{}
+	for _, r := range m {
+		power = append(power, r.(map[string]interface{})["POWER"].(string))
+	}
+	Expect(power).To(Equal([]string{"on", "off", "unknown"}))
+}
+
+func TestInstanceArrayRollingRestartCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    10,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+	}
+
+	instances := map[string]metalcloud.Instance{
+		"instance-110": {InstanceID: 110, InstanceLabel: "instance-110"},
+		"instance-111": {InstanceID: 111, InstanceLabel: "instance-111"},
+		"instance-112": {InstanceID: 112, InstanceLabel: "instance-112"},
+	}
+
+	power, powerCalls := fakeInstancesPower(client, "on")
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(ia.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayInstances(ia.InstanceArrayID).
+		Return(&instances, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceServerPowerSet(gomock.Any(), gomock.Any()).
+		DoAndReturn(power).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": 10,
+		"batch_size":                 2,
+		"pause":                      "0s",
+		"block_timeout":              3,
+		"block_check_interval":       1,
+		"autoconfirm":                true,
+		"dry_run":                    true,
+		"format":                     "json",
+	})
+
+	//no instance is restarted in dry run mode
+	ret, err := instanceArrayRollingRestartCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	batches := []int{}
+	for _, r := range m {
+		batches = append(batches, int(r.(map[string]interface{})["BATCH"].(float64)))
+	}
+	Expect(batches).To(Equal([]int{1, 1, 2}))
+
+	Expect(*powerCalls).To(BeEmpty())
+
+	cmd.Arguments["dry_run"] = &[]bool{false}[0]
+
+	ret, err = instanceArrayRollingRestartCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("restarted"))
+
+	//each batch is shut down then powered on before the next one is started
+	Expect(*powerCalls).To(Equal([]string{"110 soft", "111 soft", "110 on", "111 on", "112 soft", "112 on"}))
+
+	//with -hard the batches are powered off
+	*powerCalls = []string{}
+	cmd.Arguments["batch_size"] = &[]int{3}[0]
+	cmd.Arguments["hard"] = &[]bool{true}[0]
+
+	_, err = instanceArrayRollingRestartCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(*powerCalls).To(Equal([]string{"110 off", "111 off", "112 off", "110 on", "111 on", "112 on"}))
+
+	cmd.Arguments["batch_size"] = &[]int{0}[0]
+	_, err = instanceArrayRollingRestartCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestInstanceArrayRollingRestartCmdAbortsOnFailure(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    10,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+	}
+
+	instances := map[string]metalcloud.Instance{
+		"instance-110": {InstanceID: 110, InstanceLabel: "instance-110"},
+		"instance-111": {InstanceID: 111, InstanceLabel: "instance-111"},
+		"instance-112": {InstanceID: 112, InstanceLabel: "instance-112"},
+	}
+
+	power, _ := fakeInstancesPower(client, "on")
+
+	client.EXPECT().
+		InstanceArrayGet(ia.InstanceArrayID).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(ia.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayInstances(ia.InstanceArrayID).
+		Return(&instances, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceServerPowerSet(110, gomock.Any()).
+		DoAndReturn(power).
+		Times(2)
+
+	client.EXPECT().
+		InstanceServerPowerSet(111, "soft").
+		Return(fmt.Errorf("server is locked")).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": 10,
+		"batch_size":                 1,
+		"pause":                      "0s",
+		"block_timeout":              2,
+		"block_check_interval":       1,
+		"autoconfirm":                true,
+	})
+
+	//instance 112 is not restarted after 111 fails
+	_, err := instanceArrayRollingRestartCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("1 of 3 instances restarted"))
+
+}
+
+func TestLoopUntilInstancesPowerStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	on := "on"
+	off := "off"
+
+	client.EXPECT().
+		InstanceServerPowerGet(110).
+		Return(&on, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceServerPowerGet(111).
+		Return(&off, nil).
+		AnyTimes()
+
+	Expect(loopUntilInstancesPowerStatus([]int{110}, "on", 2, 1, client)).To(BeNil())
+	Expect(loopUntilInstancesPowerStatus([]int{111}, "off", 2, 1, client)).To(BeNil())
+
+	//an instance that does not power on in time returns an error
+	Expect(loopUntilInstancesPowerStatus([]int{110, 111}, "on", 2, 1, client)).NotTo(BeNil())
+}
+
+//fakeInstancesPower keeps the power status of the instances. All of them start with the given status.
+//Returns the function used for InstanceServerPowerSet and the list of calls made to it.
+func fakeInstancesPower(client *mock_metalcloud.MockMetalCloudClient, initialStatus string) (func(int, string) error, *[]string) {
+	var lock sync.Mutex
+	status := map[int]string{}
+	calls := []string{}
+
+	client.EXPECT().
+		InstanceServerPowerGet(gomock.Any()).
+		DoAndReturn(func(id int) (*string, error) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			s, ok := status[id]
+			if !ok {
+				s = initialStatus
+			}
+			return &s, nil
+		}).
+		AnyTimes()
+
+	return func(id int, s string) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		//a soft power off ends with the instance powered off
+		status[id] = s
+		if s == "soft" {
+			status[id] = "off"
+		}
+		calls = append(calls, fmt.Sprintf("%d %s", id, s))
+		return nil
+	}, &calls
+}
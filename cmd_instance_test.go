@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
@@ -144,3 +145,84 @@ func TestInstanceServerReplaceCmd(t *testing.T) {
 	Expect(ret).To(Equal("500"))
 
 }
+
+func TestInstancePowerControlCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	ia := metalcloud.InstanceArray{
+		InstanceArrayID:    10,
+		InstanceArrayLabel: "workers",
+		InfrastructureID:   100,
+	}
+
+	instances := map[string]metalcloud.Instance{
+		"instance-111": {InstanceID: 111, InstanceLabel: "instance-111", InstanceArrayID: 10},
+		"instance-110": {InstanceID: 110, InstanceLabel: "instance-110", InstanceArrayID: 10},
+	}
+
+	client.EXPECT().
+		InstanceArrayGetByLabel(ia.InstanceArrayLabel).
+		Return(&ia, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(ia.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayInstances(ia.InstanceArrayID).
+		Return(&instances, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceServerPowerSet(110, "reset").
+		Return(nil).
+		Times(1)
+
+	client.EXPECT().
+		InstanceServerPowerSet(111, "reset").
+		Return(fmt.Errorf("server is locked")).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": "workers",
+		"operation":                  "reset",
+		"autoconfirm":                true,
+		"format":                     "json",
+	})
+
+	//a failure on one instance does not stop the others
+	ret, err := instancePowerControlCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	r := m[0].(map[string]interface{})
+	Expect(int(r["ID"].(float64))).To(Equal(110))
+	Expect(r["RESULT"]).To(Equal("ok"))
+
+	r = m[1].(map[string]interface{})
+	Expect(r["RESULT"]).To(Equal("failed"))
+	Expect(r["ERROR"]).To(Equal("server is locked"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"instance_array_id_or_label": "workers",
+		"instance_id":                110,
+		"operation":                  "reset",
+	})
+	_, err = instancePowerControlCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"instance_id": 110,
+		"operation":   "restart",
+	})
+	_, err = instancePowerControlCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
@@ -3,7 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
 	"github.com/metalsoft-io/tableformatter"
@@ -20,13 +23,105 @@ var networkCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"instance_array_id_or_label": c.FlagSet.String("ia", _nilDefaultStr, red("(Required)") + " InstanceArray's id or label. Note that the label can be ambigous."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: networkListCmd,
 	},
+	{
+		Description:  "Create a network in an infrastructure.",
+		Subject:      "network",
+		AltSubject:   "nw",
+		Predicate:    "create",
+		AltPredicate: "new",
+		FlagSet:      flag.NewFlagSet("create network", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"infrastructure_id_or_label":   c.FlagSet.String("infra", _nilDefaultStr, red("(Required)")+" Infrastructure's id or label. Note that the 'label' this be ambiguous in certain situations."),
+				"network_type":                 c.FlagSet.String("type", _nilDefaultStr, red("(Required)")+" Network's type. Possible values: "+strings.Join(networkTypes, ", ")),
+				"network_label":                c.FlagSet.String("label", _nilDefaultStr, "Network's label"),
+				"network_subdomain":            c.FlagSet.String("subdomain", _nilDefaultStr, "Network's subdomain"),
+				"network_lan_autoallocate_ips": c.FlagSet.Bool("lan-autoallocate-ips", false, green("(Flag)")+" If set, IPs are automatically allocated to the instances of the LAN network"),
+				"return_id":                    c.FlagSet.Bool("return-id", false, green("(Flag)")+" If set will print the ID of the created network. Useful for automating tasks."),
+			}
+		},
+		ExecuteFunc: networkCreateCmd,
+		Example: `
+metalcloud-cli network create --infra my-infra --type lan --label backend-lan
+		`,
+	},
+	{
+		Description:  "Get network details.",
+		Subject:      "network",
+		AltSubject:   "nw",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("get network", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Network's id or label. Note that the label can be ambigous."),
+				"format":              outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: networkGetCmd,
+	},
+	{
+		Description:  "Edit a network.",
+		Subject:      "network",
+		AltSubject:   "nw",
+		Predicate:    "edit",
+		AltPredicate: "update",
+		FlagSet:      flag.NewFlagSet("edit network", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_id_or_label":             c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Network's id or label. Note that the label can be ambigous."),
+				"network_label":                   c.FlagSet.String("label", _nilDefaultStr, "Network's new label"),
+				"network_subdomain":               c.FlagSet.String("subdomain", _nilDefaultStr, "Network's new subdomain"),
+				"network_lan_autoallocate_ips":    c.FlagSet.Bool("lan-autoallocate-ips", false, green("(Flag)")+" If set, IPs are automatically allocated to the instances of the LAN network"),
+				"no_network_lan_autoallocate_ips": c.FlagSet.Bool("no-lan-autoallocate-ips", false, green("(Flag)")+" If set, IPs are no longer automatically allocated to the instances of the LAN network"),
+			}
+		},
+		ExecuteFunc: networkEditCmd,
+	},
+	{
+		Description:  "Delete a network.",
+		Subject:      "network",
+		AltSubject:   "nw",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete network", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Network's id or label. Note that the label can be ambigous."),
+				"autoconfirm":         c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: networkDeleteCmd,
+	},
+	{
+		Description:  "Join two networks. The second network is merged into the first one and deleted.",
+		Subject:      "network",
+		AltSubject:   "nw",
+		Predicate:    "join",
+		AltPredicate: "merge",
+		FlagSet:      flag.NewFlagSet("join network", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_id_or_label":        c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" The id or label of the network that is kept."),
+				"joined_network_id_or_label": c.FlagSet.String("with", _nilDefaultStr, red("(Required)")+" The id or label of the network that is merged into the first one and deleted."),
+				"autoconfirm":                c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: networkJoinCmd,
+		Example: `
+metalcloud-cli network join --id backend-lan --with old-lan
+		`,
+	},
 }
 
+//networkTypes are the types of networks that can be created
+var networkTypes = []string{"lan", "san", "wan"}
+
 func networkListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 
 	retIA, err := getInstanceArrayFromCommand("ia", c, client)
@@ -97,5 +192,280 @@ func networkListCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 	}
 	subtitleNetworkAttachmentsRender := "NETWORK ATTACHEMENTS\n--------------------\nNetworks to which this instance array is attached to:\n"
 
-	return tableNetworkAttachments.RenderTable("", subtitleNetworkAttachmentsRender, getStringParam(c.Arguments["format"]))
+	return renderTable(c, tableNetworkAttachments, "", subtitleNetworkAttachmentsRender)
+}
+
+func networkCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	infra, err := getInfrastructureFromCommand("infra", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	networkType, ok := getStringParamOk(c.Arguments["network_type"])
+	if !ok {
+		return "", fmt.Errorf("-type is required")
+	}
+
+	if !stringInSlice(networkType, networkTypes) {
+		return "", fmt.Errorf("invalid network type %s. Possible values: %s", networkType, strings.Join(networkTypes, ", "))
+	}
+
+	nw := metalcloud.Network{
+		NetworkType:               networkType,
+		NetworkLabel:              getStringParam(c.Arguments["network_label"]),
+		NetworkSubdomain:          getStringParam(c.Arguments["network_subdomain"]),
+		NetworkLANAutoAllocateIPs: getBoolParam(c.Arguments["network_lan_autoallocate_ips"]),
+	}
+
+	retNW, err := client.NetworkCreate(infra.InfrastructureID, nw)
+	if err != nil {
+		return "", err
+	}
+
+	if getBoolParam(c.Arguments["return_id"]) {
+		return fmt.Sprintf("%d", retNW.NetworkID), nil
+	}
+
+	return "", nil
+}
+
+func networkGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retNW, err := getNetworkFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	infra, err := client.InfrastructureGet(retNW.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	iaList, err := client.InstanceArrays(retNW.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	attachments := []string{}
+	for _, ia := range *iaList {
+		for _, i := range ia.InstanceArrayInterfaces {
+			if i.NetworkID == retNW.NetworkID {
+				attachments = append(attachments, fmt.Sprintf("%s (#%d) port #%d", ia.InstanceArrayLabel, ia.InstanceArrayID, i.InstanceArrayInterfaceIndex+1))
+			}
+		}
+	}
+	sort.Strings(attachments)
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "SUBDOMAIN",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "INFRASTRUCTURE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "LAN_AUTOALLOCATE_IPS",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 3,
+		},
+		{
+			FieldName: "ATTACHED TO",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "CREATED",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	data := [][]interface{}{{
+		retNW.NetworkID,
+		retNW.NetworkLabel,
+		retNW.NetworkType,
+		retNW.NetworkSubdomain,
+		fmt.Sprintf("%s (#%d)", infra.InfrastructureLabel, infra.InfrastructureID),
+		retNW.NetworkLANAutoAllocateIPs,
+		strings.Join(attachments, "\n"),
+		retNW.NetworkCreatedTimestamp,
+	}}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable("network", "", getStringParam(c.Arguments["format"]))
+}
+
+func networkEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retNW, err := getNetworkFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	var nwo metalcloud.NetworkOperation
+	if retNW.NetworkOperation != nil {
+		nwo = *retNW.NetworkOperation
+	} else {
+		nwo = metalcloud.NetworkOperation{
+			NetworkID:                 retNW.NetworkID,
+			NetworkLabel:              retNW.NetworkLabel,
+			NetworkSubdomain:          retNW.NetworkSubdomain,
+			NetworkType:               retNW.NetworkType,
+			InfrastructureID:          retNW.InfrastructureID,
+			NetworkLANAutoAllocateIPs: retNW.NetworkLANAutoAllocateIPs,
+		}
+	}
+
+	updateIfStringParamSet(c.Arguments["network_label"], &nwo.NetworkLabel)
+	updateIfStringParamSet(c.Arguments["network_subdomain"], &nwo.NetworkSubdomain)
+
+	if getBoolParam(c.Arguments["network_lan_autoallocate_ips"]) && getBoolParam(c.Arguments["no_network_lan_autoallocate_ips"]) {
+		return "", fmt.Errorf("-lan-autoallocate-ips and -no-lan-autoallocate-ips cannot be used together")
+	}
+
+	if getBoolParam(c.Arguments["network_lan_autoallocate_ips"]) {
+		nwo.NetworkLANAutoAllocateIPs = true
+	}
+
+	if getBoolParam(c.Arguments["no_network_lan_autoallocate_ips"]) {
+		nwo.NetworkLANAutoAllocateIPs = false
+	}
+
+	_, err = client.NetworkEdit(retNW.NetworkID, nwo)
+
+	return "", err
+}
+
+func networkDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retNW, err := getNetworkFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	retInfra, err := client.InfrastructureGet(retNW.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting %s network %s (%d) - from infrastructure %s (%d).  Are you sure? Type \"yes\" to continue:",
+			retNW.NetworkType,
+			retNW.NetworkLabel, retNW.NetworkID,
+			retInfra.InfrastructureLabel, retInfra.InfrastructureID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.NetworkDelete(retNW.NetworkID)
+}
+
+func networkJoinCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retNW, err := getNetworkFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := getParam(c, "joined_network_id_or_label", "with")
+	if err != nil {
+		return "", err
+	}
+
+	id, label, isID := idOrLabel(m)
+
+	var joinedNW *metalcloud.Network
+	if isID {
+		joinedNW, err = client.NetworkGet(id)
+	} else {
+		joinedNW, err = client.NetworkGetByLabel(label)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if joinedNW.NetworkID == retNW.NetworkID {
+		return "", fmt.Errorf("a network cannot be joined with itself")
+	}
+
+	if joinedNW.NetworkType != retNW.NetworkType {
+		return "", fmt.Errorf("cannot join a %s network with a %s network", retNW.NetworkType, joinedNW.NetworkType)
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Joining network %s (%d) into network %s (%d). Network %s (%d) will be deleted. Are you sure? Type \"yes\" to continue:",
+			joinedNW.NetworkLabel, joinedNW.NetworkID,
+			retNW.NetworkLabel, retNW.NetworkID,
+			joinedNW.NetworkLabel, joinedNW.NetworkID)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.NetworkJoin(retNW.NetworkID, joinedNW.NetworkID)
+}
+
+func getNetworkFromCommand(paramName string, c *Command, client metalcloud.MetalCloudClient) (*metalcloud.Network, error) {
+
+	m, err := getParam(c, "network_id_or_label", paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	id, label, isID := idOrLabel(m)
+
+	if isID {
+		return client.NetworkGet(id)
+	}
+
+	return client.NetworkGetByLabel(label)
 }
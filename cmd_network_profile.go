@@ -25,7 +25,7 @@ var networkProfileCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"datacenter": c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" Network profile datacenter"),
-				"format":     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: networkProfileListCmd,
@@ -41,7 +41,7 @@ var networkProfileCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"network_profile_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Network profile's id."),
-				"format":             c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":             outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: networkProfileVlansListCmd,
@@ -57,7 +57,7 @@ var networkProfileCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"network_profile_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Network profile's id."),
-				"format":             c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":             tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"raw":                c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
 			}
 		},
@@ -235,7 +235,7 @@ func networkProfileListCmd(c *Command, client metalcloud.MetalCloudClient) (stri
 		Schema: schema,
 	}
 
-	return table.RenderTable("Network Profiles", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Network Profiles", "")
 }
 
 func networkProfileVlansListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -441,7 +441,7 @@ func networkProfileGetCmd(c *Command, client metalcloud.MetalCloudClient) (strin
 			Schema: schema,
 		}
 
-		ret, err := table.RenderTable("", "", format)
+		ret, err := renderTable(c, table, "", "")
 		if err != nil {
 			return "", err
 		}
@@ -141,3 +141,233 @@ func TestNetworkListCmd(t *testing.T) {
 	_, err = networkListCmd(&cmd, client)
 	Expect(err).NotTo(BeNil())
 }
+
+func TestNetworkCreateCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	infra := metalcloud.Infrastructure{
+		InfrastructureID:    100,
+		InfrastructureLabel: "test",
+	}
+
+	client.EXPECT().
+		InfrastructureGet(infra.InfrastructureID).
+		Return(&infra, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGetByLabel(infra.InfrastructureLabel).
+		Return(&infra, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		NetworkCreate(infra.InfrastructureID, metalcloud.Network{
+			NetworkType:               "lan",
+			NetworkLabel:              "backend",
+			NetworkLANAutoAllocateIPs: true,
+		}).
+		Return(&metalcloud.Network{NetworkID: 10}, nil).
+		AnyTimes()
+
+	cases := []CommandTestCase{
+		{
+			name: "good1",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label":   "test",
+				"network_type":                 "lan",
+				"network_label":                "backend",
+				"network_lan_autoallocate_ips": true,
+			}),
+			good: true,
+			id:   10,
+		},
+		{
+			name: "missing type",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label": 100,
+			}),
+			good: false,
+		},
+		{
+			name: "invalid type",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label": 100,
+				"network_type":               "xan",
+			}),
+			good: false,
+		},
+		{
+			name: "missing infra",
+			cmd: MakeCommand(map[string]interface{}{
+				"network_type": "lan",
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(networkCreateCmd, cases, client, t)
+}
+
+func TestNetworkGetCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	nw := metalcloud.Network{
+		NetworkID:        10,
+		NetworkLabel:     "backend",
+		NetworkType:      "lan",
+		InfrastructureID: 100,
+	}
+
+	iaList := map[string]metalcloud.InstanceArray{
+		"workers": {
+			InstanceArrayID:    200,
+			InstanceArrayLabel: "workers",
+			InstanceArrayInterfaces: []metalcloud.InstanceArrayInterface{
+				{InstanceArrayInterfaceIndex: 0, NetworkID: 11},
+				{InstanceArrayInterfaceIndex: 1, NetworkID: 10},
+			},
+		},
+	}
+
+	client.EXPECT().
+		NetworkGetByLabel(nw.NetworkLabel).
+		Return(&nw, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(nw.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrays(nw.InfrastructureID).
+		Return(&iaList, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"network_id_or_label": "backend",
+		"format":              "json",
+	})
+
+	ret, err := networkGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	r := m[0].(map[string]interface{})
+	Expect(r["TYPE"]).To(Equal("lan"))
+	Expect(r["INFRASTRUCTURE"]).To(Equal("test (#100)"))
+	Expect(r["ATTACHED TO"]).To(Equal("workers (#200) port #2"))
+}
+
+func TestNetworkEditCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	nw := metalcloud.Network{
+		NetworkID:                 10,
+		NetworkLabel:              "backend",
+		NetworkType:               "lan",
+		NetworkLANAutoAllocateIPs: true,
+		NetworkOperation: &metalcloud.NetworkOperation{
+			NetworkID:                 10,
+			NetworkLabel:              "backend",
+			NetworkType:               "lan",
+			NetworkLANAutoAllocateIPs: true,
+		},
+	}
+
+	client.EXPECT().
+		NetworkGet(nw.NetworkID).
+		Return(&nw, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		NetworkEdit(nw.NetworkID, metalcloud.NetworkOperation{
+			NetworkID:                 10,
+			NetworkLabel:              "frontend",
+			NetworkType:               "lan",
+			NetworkLANAutoAllocateIPs: false,
+		}).
+		Return(&nw, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"network_id_or_label":             10,
+		"network_label":                   "frontend",
+		"no_network_lan_autoallocate_ips": true,
+	})
+
+	_, err := networkEditCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	cmd.Arguments["network_lan_autoallocate_ips"] = &[]bool{true}[0]
+	_, err = networkEditCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestNetworkDeleteAndJoinCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	nw1 := metalcloud.Network{NetworkID: 10, NetworkLabel: "lan1", NetworkType: "lan", InfrastructureID: 100}
+	nw2 := metalcloud.Network{NetworkID: 11, NetworkLabel: "lan2", NetworkType: "lan", InfrastructureID: 100}
+	nw3 := metalcloud.Network{NetworkID: 12, NetworkLabel: "san1", NetworkType: "san", InfrastructureID: 100}
+
+	for _, nw := range []metalcloud.Network{nw1, nw2, nw3} {
+		n := nw
+		client.EXPECT().NetworkGet(n.NetworkID).Return(&n, nil).AnyTimes()
+		client.EXPECT().NetworkGetByLabel(n.NetworkLabel).Return(&n, nil).AnyTimes()
+	}
+
+	client.EXPECT().
+		InfrastructureGet(100).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		NetworkDelete(nw1.NetworkID).
+		Return(nil).
+		Times(1)
+
+	client.EXPECT().
+		NetworkJoin(nw1.NetworkID, nw2.NetworkID).
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"network_id_or_label": "lan1",
+		"autoconfirm":         true,
+	})
+	_, err := networkDeleteCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"network_id_or_label":        10,
+		"joined_network_id_or_label": "lan2",
+		"autoconfirm":                true,
+	})
+	_, err = networkJoinCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//different types
+	cmd.Arguments["joined_network_id_or_label"] = &[]string{"san1"}[0]
+	_, err = networkJoinCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	//same network
+	cmd.Arguments["joined_network_id_or_label"] = &[]string{"lan1"}[0]
+	_, err = networkJoinCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
@@ -23,7 +23,7 @@ var osAssetsCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list assets", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"usage":  c.FlagSet.String("usage", _nilDefaultStr, "Asset's usage"),
 			}
 		},
@@ -258,7 +258,7 @@ func assetsListCmd(c *Command, client metalcloud.MetalCloudClient) (string, erro
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Assets", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Assets", "")
 }
 
 func assetCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -611,7 +611,7 @@ func templateListAssociatedAssetsCmd(c *Command, client metalcloud.MetalCloudCli
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Associated assets", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Associated assets", "")
 }
 
 func assetEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
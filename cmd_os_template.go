@@ -21,7 +21,7 @@ var osTemplatesCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list templates", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"usage":  c.FlagSet.String("usage", _nilDefaultStr, "Template's usage"),
 			}
 		},
@@ -112,7 +112,7 @@ var osTemplatesCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"template_id_or_name": c.FlagSet.String("id", _nilDefaultStr, "Asset's id or name"),
-				"format":              c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":              tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"show_credentials":    c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the templates initial ssh credentials"),
 			}
 		},
@@ -307,7 +307,7 @@ func templatesListCmd(c *Command, client metalcloud.MetalCloudClient) (string, e
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Templates", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Templates", "")
 }
 
 func templateCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -691,7 +691,7 @@ func templateGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Templates", topLine, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Templates", topLine)
 }
 
 func templateMakePublicCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
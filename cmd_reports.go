@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"fmt"
+	"sort"
+	"strings"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
 	"github.com/metalsoft-io/tableformatter"
@@ -19,12 +21,79 @@ var reportsCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list active devices in all datacenters", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: devicesListCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Cabling map of a datacenter.",
+		Subject:      "report",
+		AltSubject:   "report",
+		Predicate:    "cabling",
+		AltPredicate: "cables",
+		FlagSet:      flag.NewFlagSet("show the links between server and switch interfaces", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter": c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" The datacenter of the servers"),
+				"format":     tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: cablingReportCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Validate the cabling of a datacenter against a cabling plan.",
+		Subject:      "report",
+		AltSubject:   "report",
+		Predicate:    "validate-cabling",
+		AltPredicate: "check-cabling",
+		FlagSet:      flag.NewFlagSet("validate the cabling against a plan", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter":            c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" The datacenter of the servers"),
+				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, red("(Required)")+" The cabling plan in csv format. Use '-' to read from stdin."),
+				"show_ok":               c.FlagSet.Bool("show-ok", false, green("(Flag)")+" If set the links that match the plan are also shown."),
+				"format":                tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: cablingValidateCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#create file plan.csv. Interfaces are the server interface indexes, as shown by the server interfaces command:
+serial_number,interface,switch,switch_interface
+CZ3801K9XY,0,leaf01,Ethernet1/1
+CZ3801K9XY,1,leaf02,Ethernet1/1
+
+#only the interfaces of the servers in the plan are checked:
+metalcloud-cli report validate-cabling --datacenter dc1 -f plan.csv
+`,
+	},
+	{
+		Description:  "Firmware compliance of the servers of a datacenter.",
+		Subject:      "report",
+		AltSubject:   "report",
+		Predicate:    "firmware",
+		AltPredicate: "fw",
+		FlagSet:      flag.NewFlagSet("show the firmware compliance per server vendor and model", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter":  c.FlagSet.String("datacenter", _nilDefaultStr, red("(Required)")+" The datacenter of the servers"),
+				"policy_id":   c.FlagSet.Int("policy", _nilDefaultInt, "The id of a firmware policy. Components without a target version of the servers of the instance arrays it is assigned to that match its rules must have the latest available version."),
+				"concurrency": c.FlagSet.Int("concurrency", 5, "The maximum number of servers whose components are retrieved in parallel."),
+				"details":     c.FlagSet.Bool("details", false, green("(Flag)")+" If set the status of every component is listed instead of the counts per vendor and model."),
+				"status":      c.FlagSet.String("status", _nilDefaultStr, "Only list the components with this status when using -details. Supported values are 'compliant','outdated','unknown'."),
+				"format":      tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: firmwareReportCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli report firmware --datacenter dc1 --policy 10
+metalcloud-cli report firmware --datacenter dc1 --policy 10 --details --status outdated --format csv
+`,
+	},
 }
 
 func getActiveServers(datacenter string, client metalcloud.MetalCloudClient) (*[]metalcloud.ServerSearchResult, error) {
@@ -191,6 +260,499 @@ func devicesListCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 
 	title := fmt.Sprint("Count of active or in-use equipment per datacenter")
 
-	return table.RenderTable(fmt.Sprintf("Records (%d active devices across all datacenters)", totalDevices), title, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, fmt.Sprintf("Records (%d active devices across all datacenters)", totalDevices), title)
+
+}
+
+func cablingReportCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenter, ok := getStringParamOk(c.Arguments["datacenter"])
+	if !ok {
+		return "", fmt.Errorf("-datacenter is required")
+	}
+
+	servers, err := getDatacenterServers(datacenter, client)
+	if err != nil {
+		return "", err
+	}
+
+	links, err := getDatacenterCabling(servers, client)
+	if err != nil {
+		return "", err
+	}
+
+	unlinked, err := getServerInterfacesWithoutLinks(servers, links, client)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "SERVER ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "SERIAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "RACK",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "RU",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "INTF. IDX",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "SERVER INTERFACE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 17,
+		},
+		{
+			FieldName: "SWITCH",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SWITCH INTERFACE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "CAPACITY",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 8,
+		},
+	}
+
+	//the server interfaces without links are listed together with the links of their server
+	rows := append(append([]cablingLink{}, links...), unlinked...)
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].serverID != rows[j].serverID {
+			return rows[i].serverID < rows[j].serverID
+		}
+		return rows[i].interfaceIndex < rows[j].interfaceIndex
+	})
+
+	cabled := map[int]bool{}
+	listed := map[int]bool{}
+	data := [][]interface{}{}
+
+	for _, l := range rows {
+		s := servers[l.serverID]
+		listed[l.serverID] = true
+
+		if l.switchName == "" {
+			data = append(data, []interface{}{
+				l.serverID,
+				l.serialNumber,
+				s.ServerRackName,
+				getServerRackUnits(s),
+				fmt.Sprintf("%d", l.interfaceIndex),
+				l.macAddress,
+				red("not cabled"),
+				"",
+				"",
+			})
+			continue
+		}
+
+		cabled[l.serverID] = true
+
+		data = append(data, []interface{}{
+			l.serverID,
+			l.serialNumber,
+			s.ServerRackName,
+			getServerRackUnits(s),
+			fmt.Sprintf("%d", l.interfaceIndex),
+			l.macAddress,
+			fmt.Sprintf("%s (#%d)", l.switchName, l.switchID),
+			l.switchInterface,
+			fmt.Sprintf("%d Gbps", int(l.capacityMbps/1000)),
+		})
+	}
+
+	notCabled := []int{}
+	for id := range servers {
+		if !cabled[id] {
+			notCabled = append(notCabled, id)
+		}
+	}
+	sort.Ints(notCabled)
+
+	//servers without known interfaces have no interface index
+	for _, id := range notCabled {
+		if listed[id] {
+			continue
+		}
+
+		s := servers[id]
+		data = append(data, []interface{}{
+			s.ServerID,
+			s.ServerSerialNumber,
+			s.ServerRackName,
+			getServerRackUnits(s),
+			"",
+			"",
+			red("not cabled"),
+			"",
+			"",
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d links of %d servers, %d servers without links, %d server interfaces not cabled",
+		len(links), len(servers)-len(notCabled), len(notCabled), len(unlinked))
+
+	return renderTable(c, table, fmt.Sprintf("Cabling of datacenter %s", datacenter), topLine)
+}
+
+func getServerRackUnits(s metalcloud.ServerSearchResult) string {
+	if s.ServerRackPositionLowerUnit == "" || s.ServerRackPositionLowerUnit == s.ServerRackPositionUpperUnit {
+		return s.ServerRackPositionLowerUnit
+	}
+	return fmt.Sprintf("%s-%s", s.ServerRackPositionLowerUnit, s.ServerRackPositionUpperUnit)
+}
+
+func cablingValidateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenter, ok := getStringParamOk(c.Arguments["datacenter"])
+	if !ok {
+		return "", fmt.Errorf("-datacenter is required")
+	}
+
+	filePath, ok := getStringParamOk(c.Arguments["read_config_from_file"])
+	if !ok {
+		return "", fmt.Errorf("-f is required")
+	}
+
+	var content []byte
+	var err error
+	if filePath == "-" {
+		content, err = readInputFromPipe()
+	} else {
+		content, err = readInputFromFile(filePath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	plan, err := parseCablingPlan(content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", filePath, err)
+	}
+
+	servers, err := getDatacenterServers(datacenter, client)
+	if err != nil {
+		return "", err
+	}
+
+	links, err := getDatacenterCabling(servers, client)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 12,
+		},
+		{
+			FieldName: "SERIAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "SERVER ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "INTF. IDX",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "EXPECTED",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "ACTUAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	counts := map[string]int{}
+	data := [][]interface{}{}
+
+	for _, d := range compareCabling(plan, links, servers) {
+		counts[d.status]++
+
+		if d.status == "ok" && !getBoolParam(c.Arguments["show_ok"]) {
+			continue
+		}
+
+		data = append(data, []interface{}{
+			d.status,
+			d.serialNumber,
+			d.serverID,
+			d.interfaceIndex,
+			d.expected,
+			d.actual,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d planned links: %d ok, %d missing, %d mis-patched. %d extra links.",
+		len(plan), counts["ok"], counts["missing"], counts["mis-patched"], counts["extra"])
+
+	return renderTable(c, table, "Cabling differences", topLine)
+}
+
+func firmwareReportCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenter, ok := getStringParamOk(c.Arguments["datacenter"])
+	if !ok {
+		return "", fmt.Errorf("-datacenter is required")
+	}
+
+	concurrency := getIntParam(c.Arguments["concurrency"])
+	if concurrency <= 0 {
+		return "", fmt.Errorf("-concurrency must be greater than 0")
+	}
+
+	status := getStringParam(c.Arguments["status"])
+	if status != "" && !stringInSlice(status, firmwareComplianceStatuses) {
+		return "", fmt.Errorf("invalid status %s. Supported values are %s", status, strings.Join(firmwareComplianceStatuses, ","))
+	}
+
+	policies := []metalcloud.ServerFirmwareUpgradePolicy{}
+	if policyID, ok := getIntParamOk(c.Arguments["policy_id"]); ok {
+		policy, err := client.ServerFirmwarePolicyGet(policyID)
+		if err != nil {
+			return "", err
+		}
+
+		if err := validateFirmwarePolicy(*policy); err != nil {
+			return "", err
+		}
+
+		policies = append(policies, *policy)
+	}
+
+	serversMap, err := getDatacenterServers(datacenter, client)
+	if err != nil {
+		return "", err
+	}
+
+	servers := []metalcloud.ServerSearchResult{}
+	for _, s := range serversMap {
+		servers = append(servers, s)
+	}
+
+	components := getServersFirmwareCompliance(servers, policies, concurrency, client)
+
+	counts := map[string]int{}
+	for _, r := range components {
+		counts[r.status]++
+	}
+
+	topLine := fmt.Sprintf("%d components of %d servers: %d compliant, %d outdated, %d unknown",
+		len(components), len(servers), counts["compliant"], counts["outdated"], counts["unknown"])
+
+	if getBoolParam(c.Arguments["details"]) {
+		return firmwareReportDetails(c, components, status, topLine)
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "VENDOR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "MODEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SERVERS",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "COMPLIANT",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "OUTDATED",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "UNKNOWN",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+	}
+
+	type modelKey struct {
+		vendor string
+		model  string
+	}
+
+	modelServers := map[modelKey]int{}
+	for _, s := range servers {
+		modelServers[modelKey{s.ServerVendor, s.ServerProductName}]++
+	}
+
+	modelCounts := map[modelKey]map[string]int{}
+	for _, r := range components {
+		k := modelKey{r.server.ServerVendor, r.server.ServerProductName}
+		if modelCounts[k] == nil {
+			modelCounts[k] = map[string]int{}
+		}
+		modelCounts[k][r.status]++
+	}
+
+	keys := []modelKey{}
+	for k := range modelServers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].vendor != keys[j].vendor {
+			return keys[i].vendor < keys[j].vendor
+		}
+		return keys[i].model < keys[j].model
+	})
+
+	data := [][]interface{}{}
+	for _, k := range keys {
+		data = append(data, []interface{}{
+			k.vendor,
+			k.model,
+			modelServers[k],
+			modelCounts[k]["compliant"],
+			modelCounts[k]["outdated"],
+			modelCounts[k]["unknown"],
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return renderTable(c, table, fmt.Sprintf("Firmware compliance of datacenter %s", datacenter), topLine)
+}
+
+func firmwareReportDetails(c *Command, components []firmwareComponentCompliance, status string, topLine string) (string, error) {
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "SERVER ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "SERIAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "VENDOR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "MODEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "COMPONENT ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "COMPONENT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "VERSION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "EXPECTED",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "REASON",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, r := range components {
+		if status != "" && r.status != status {
+			continue
+		}
+
+		data = append(data, []interface{}{
+			r.server.ServerID,
+			r.server.ServerSerialNumber,
+			r.server.ServerVendor,
+			r.server.ServerProductName,
+			r.component.ServerComponentID,
+			r.component.ServerComponentName,
+			r.component.ServerComponentType,
+			r.component.ServerComponentFirmwareVersion,
+			r.expected,
+			r.status,
+			r.reason,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
 
+	return renderTable(c, table, "Components", topLine)
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"syscall"
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
@@ -55,5 +58,315 @@ func TestDevicesListCmdWithWatch(t *testing.T) {
 	Expect(ret).To(ContainSubstring("2"))
 }
 
+func TestCablingReportCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 2, ServerSerialNumber: "SN2", DatacenterName: "dc1", ServerStatus: "available", ServerRackName: "R1", ServerRackPositionLowerUnit: "10", ServerRackPositionUpperUnit: "11"},
+		{ServerID: 1, ServerSerialNumber: "SN1", DatacenterName: "dc1", ServerStatus: "used", ServerRackName: "R1", ServerRackPositionLowerUnit: "12", ServerRackPositionUpperUnit: "12"},
+		{ServerID: 3, ServerSerialNumber: "SN3", DatacenterName: "dc1", ServerStatus: "available"},
+		{ServerID: 4, ServerSerialNumber: "SN4", DatacenterName: "dc1", ServerStatus: "decommissioned"},
+		//matched by the full text search but from another datacenter
+		{ServerID: 5, ServerSerialNumber: "SN5", DatacenterName: "dc10", ServerStatus: "available"},
+	}
+
+	interfaces := []metalcloud.SwitchInterfaceSearchResult{
+		{ServerID: 2, ServerInterfaceIndex: 1, NetworkEquipmentID: 10, NetworkEquipmentIdentifierString: "leaf01", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/2", ServerInterfaceCapacityMBPs: 25000},
+		{ServerID: 2, ServerInterfaceIndex: 0, NetworkEquipmentID: 10, NetworkEquipmentIdentifierString: "leaf01", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/1", ServerInterfaceCapacityMBPs: 25000},
+		{ServerID: 1, ServerInterfaceIndex: 0, NetworkEquipmentID: 11, NetworkEquipmentIdentifierString: "leaf02", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/1", ServerInterfaceCapacityMBPs: 10000},
+		//server of another datacenter
+		{ServerID: 100, ServerInterfaceIndex: 0, NetworkEquipmentID: 50, NetworkEquipmentIdentifierString: "leaf50", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/1"},
+	}
+
+	client.EXPECT().
+		ServersSearch("+datacenter_name:dc1").
+		Return(&servers, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchInterfaceSearch("*").
+		Return(&interfaces, nil).
+		AnyTimes()
+
+	//the second interface of server 1 is not cabled and the interfaces of server 3 are not known
+	client.EXPECT().
+		ServerGet(1, false).
+		Return(&metalcloud.Server{ServerID: 1, ServerInterfaces: []metalcloud.ServerInterface{
+			{ServerInterfaceMACAddress: "00:00:00:00:01:00"},
+			{ServerInterfaceMACAddress: "00:00:00:00:01:01"},
+		}}, nil).
+		AnyTimes()
+	client.EXPECT().
+		ServerGet(2, false).
+		Return(&metalcloud.Server{ServerID: 2, ServerInterfaces: []metalcloud.ServerInterface{
+			{ServerInterfaceMACAddress: "00:00:00:00:02:00"},
+			{ServerInterfaceMACAddress: "00:00:00:00:02:01"},
+		}}, nil).
+		AnyTimes()
+	client.EXPECT().
+		ServerGet(3, false).
+		Return(&metalcloud.Server{ServerID: 3}, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"datacenter": "dc1",
+		"format":     "json",
+	})
+
+	ret, err := cablingReportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(5))
+
+	r := m[0].(map[string]interface{})
+	Expect(int(r["SERVER ID"].(float64))).To(Equal(1))
+	Expect(r["RU"]).To(Equal("12"))
+	Expect(r["INTF. IDX"]).To(Equal("0"))
+	Expect(r["SWITCH"]).To(Equal("leaf02 (#11)"))
+	Expect(r["CAPACITY"]).To(Equal("10 Gbps"))
+
+	r = m[1].(map[string]interface{})
+	Expect(int(r["SERVER ID"].(float64))).To(Equal(1))
+	Expect(r["INTF. IDX"]).To(Equal("1"))
+	Expect(r["SERVER INTERFACE"]).To(Equal("00:00:00:00:01:01"))
+	Expect(r["SWITCH"]).To(ContainSubstring("not cabled"))
+
+	r = m[2].(map[string]interface{})
+	Expect(r["SWITCH INTERFACE"]).To(Equal("Ethernet1/1"))
+	Expect(r["RU"]).To(Equal("10-11"))
+
+	r = m[4].(map[string]interface{})
+	Expect(r["SERIAL"]).To(Equal("SN3"))
+	Expect(r["INTF. IDX"]).To(Equal(""))
+	Expect(r["SWITCH"]).To(ContainSubstring("not cabled"))
+
+	cmd = MakeCommand(map[string]interface{}{})
+	_, err = cablingReportCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestCablingValidateCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 1, ServerSerialNumber: "SN1", DatacenterName: "dc1", ServerStatus: "available"},
+	}
+
+	interfaces := []metalcloud.SwitchInterfaceSearchResult{
+		{ServerID: 1, ServerInterfaceIndex: 0, NetworkEquipmentIdentifierString: "leaf01", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/1"},
+		{ServerID: 1, ServerInterfaceIndex: 1, NetworkEquipmentIdentifierString: "leaf01", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/2"},
+	}
+
+	client.EXPECT().
+		ServersSearch("+datacenter_name:dc1").
+		Return(&servers, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchInterfaceSearch("*").
+		Return(&interfaces, nil).
+		AnyTimes()
+
+	f, err := ioutil.TempFile("./", "testplan-*.csv")
+	if err != nil {
+		t.Error(err)
+	}
+	f.WriteString("serial_number,interface,switch,switch_interface\nSN1,0,leaf01,Ethernet1/1\nSN1,1,leaf02,Ethernet1/2\n")
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	cmd := MakeCommand(map[string]interface{}{
+		"datacenter":            "dc1",
+		"read_config_from_file": f.Name(),
+		"format":                "json",
+	})
+
+	ret, err := cablingValidateCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(1))
+
+	r := m[0].(map[string]interface{})
+	Expect(r["STATUS"]).To(Equal("mis-patched"))
+	Expect(r["EXPECTED"]).To(Equal("leaf02:Ethernet1/2"))
+	Expect(r["ACTUAL"]).To(Equal("leaf01:Ethernet1/2"))
+
+	cmd.Arguments["show_ok"] = &[]bool{true}[0]
+
+	ret, err = cablingValidateCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"datacenter": "dc1",
+	})
+	_, err = cablingValidateCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestFirmwareReportCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 1, ServerSerialNumber: "SN1", DatacenterName: "dc1", ServerStatus: "available", ServerVendor: "Dell", ServerProductName: "PowerEdge R640", InstanceArrayID: []int{50}},
+		{ServerID: 2, ServerSerialNumber: "SN2", DatacenterName: "dc1", ServerStatus: "used", ServerVendor: "Dell", ServerProductName: "PowerEdge R640", InstanceArrayID: []int{50}},
+		{ServerID: 3, ServerSerialNumber: "SN3", DatacenterName: "dc1", ServerStatus: "available", ServerVendor: "HPE", ServerProductName: "ProLiant DL380", InstanceArrayID: []int{50}},
+		{ServerID: 4, ServerSerialNumber: "SN4", DatacenterName: "dc1", ServerStatus: "decommissioned", ServerVendor: "HPE", ServerProductName: "ProLiant DL380"},
+		//of an instance array the policy is not assigned to
+		{ServerID: 5, ServerSerialNumber: "SN5", DatacenterName: "dc1", ServerStatus: "used", ServerVendor: "Dell", ServerProductName: "PowerEdge R640", InstanceArrayID: []int{60}},
+		//its components cannot be retrieved
+		{ServerID: 6, ServerSerialNumber: "SN6", DatacenterName: "dc1", ServerStatus: "available", ServerVendor: "HPE", ServerProductName: "ProLiant DL380", InstanceArrayID: []int{50}},
+	}
+
+	components := map[int][]metalcloud.ServerComponent{
+		1: {
+			{ServerComponentID: 11, ServerComponentType: "bios", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "2.11.0", ServerComponentFirmwareUpdateAvailableVersions: []string{"2.11.0"}},
+			{ServerComponentID: 12, ServerComponentType: "nic", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "20.5.13"},
+		},
+		2: {
+			{ServerComponentID: 21, ServerComponentType: "bios", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "2.9.3", ServerComponentFirmwareUpdateAvailableVersions: []string{"2.11.0"}},
+			{ServerComponentID: 22, ServerComponentType: "nic", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "20.5.13"},
+		},
+		3: {
+			{ServerComponentID: 31, ServerComponentType: "bios", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "U30 v2.14", ServerComponentFirmwareUpdateAvailableVersions: []string{"U30 v2.15"}},
+			{ServerComponentID: 32, ServerComponentType: "disk", ServerComponentFirmwareVersion: "HPD3"},
+		},
+		5: {
+			{ServerComponentID: 51, ServerComponentType: "bios", ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "2.9.3", ServerComponentFirmwareUpdateAvailableVersions: []string{"2.11.0"}},
+		},
+	}
+
+	//only the nic of server 1 has a target version
+	targets := map[int]string{
+		12: "20.5.13",
+	}
+
+	policy := metalcloud.ServerFirmwareUpgradePolicy{
+		ServerFirmwareUpgradePolicyID:    10,
+		ServerFirmwareUpgradePolicyLabel: "bios-latest",
+		ServerFirmwareUpgradePolicyRules: []metalcloud.ServerFirmwareUpgradePolicyRule{
+			{Operation: "string_equal", Property: "server_component_type", Value: "bios"},
+		},
+		InstanceArrayIDList: []int{50},
+	}
+
+	client.EXPECT().
+		ServersSearch("+datacenter_name:dc1").
+		Return(&servers, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponents(gomock.Any(), "").
+		DoAndReturn(func(serverID int, filter string) (*[]metalcloud.ServerComponent, error) {
+			if serverID == 6 {
+				return nil, fmt.Errorf("timeout")
+			}
+			list := append([]metalcloud.ServerComponent{}, components[serverID]...)
+			return &list, nil
+		}).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponentGet(gomock.Any()).
+		DoAndReturn(func(componentID int) (*metalcloud.ServerComponent, error) {
+			for _, list := range components {
+				for _, c := range list {
+					if c.ServerComponentID == componentID {
+						c.ServerComponentFirmwareTargetVersion = targets[componentID]
+						return &c, nil
+					}
+				}
+			}
+			return nil, fmt.Errorf("component %d not found", componentID)
+		}).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerFirmwarePolicyGet(10).
+		Return(&policy, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"datacenter":  "dc1",
+		"policy_id":   10,
+		"concurrency": 2,
+		"format":      "json",
+	})
+
+	ret, err := firmwareReportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	r := m[0].(map[string]interface{})
+	Expect(r["VENDOR"]).To(Equal("Dell"))
+	Expect(int(r["SERVERS"].(float64))).To(Equal(3))
+	Expect(int(r["COMPLIANT"].(float64))).To(Equal(2))
+	Expect(int(r["OUTDATED"].(float64))).To(Equal(1))
+	Expect(int(r["UNKNOWN"].(float64))).To(Equal(2))
+
+	r = m[1].(map[string]interface{})
+	Expect(r["MODEL"]).To(Equal("ProLiant DL380"))
+	Expect(int(r["SERVERS"].(float64))).To(Equal(2))
+	Expect(int(r["OUTDATED"].(float64))).To(Equal(1))
+	Expect(int(r["UNKNOWN"].(float64))).To(Equal(2))
+
+	//drill-down of the outdated components
+	cmd.Arguments["details"] = &[]bool{true}[0]
+	status := "outdated"
+	cmd.Arguments["status"] = &status
+
+	ret, err = firmwareReportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	r = m[0].(map[string]interface{})
+	Expect(int(r["COMPONENT ID"].(float64))).To(Equal(21))
+	Expect(r["EXPECTED"]).To(Equal("2.11.0"))
+	Expect(r["SERIAL"]).To(Equal("SN2"))
+
+	r = m[1].(map[string]interface{})
+	Expect(int(r["COMPONENT ID"].(float64))).To(Equal(31))
+	Expect(r["EXPECTED"]).To(Equal("U30 v2.15"))
+
+	//the server whose components cannot be retrieved is reported as unknown
+	status = "unknown"
+
+	ret, err = firmwareReportCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	r = m[len(m)-1].(map[string]interface{})
+	Expect(r["SERIAL"]).To(Equal("SN6"))
+	Expect(r["REASON"]).To(Equal("timeout"))
+
+	status = "bad"
+	_, err = firmwareReportCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"datacenter": "dc1",
+	})
+	_, err = firmwareReportCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
 const _storageListFixture = "[\r\n                {\r\n                    \"storage_pool_id\": 1,\r\n                    \"storage_pool_name\": \"UnityVSA\",\r\n                    \"storage_pool_status\": \"active\",\r\n                    \"storage_pool_in_maintenance\": false,\r\n                    \"datacenter_name\": \"us02-chi-qts01-dc\",\r\n                    \"storage_type\": \"iscsi_ssd\",\r\n                    \"user_id\": null,\r\n                    \"storage_pool_iscsi_host\": \"100.96.0.2\",\r\n                    \"storage_pool_iscsi_port\": 3260,\r\n                    \"storage_pool_capacity_total_cached_real_mbytes\": 505344,\r\n                    \"storage_pool_capacity_usable_cached_real_mbytes\": 505344,\r\n                    \"storage_pool_capacity_free_cached_real_mbytes\": 496128,\r\n                    \"storage_pool_capacity_used_cached_virtual_mbytes\": 122880\r\n                }\r\n            ]"
 const _datacenterList = "{\"test\":{\"datacenter_id\":6,\"datacenter_name\":\"test\",\"datacenter_name_parent\":null,\"user_id\":null,\"datacenter_is_master\":false,\"datacenter_is_maintenance\":false,\"datacenter_type\":\"metal_cloud\",\"datacenter_display_name\":\"US02 Chi QTS01 DC\",\"datacenter_hidden\":false,\"datacenter_created_timestamp\":\"2022-02-11T11:14:08Z\",\"datacenter_updated_timestamp\":\"2022-06-09T13:32:56Z\",\"type\":\"Datacenter\",\"datacenter_tags\":[]}}"
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//schemaCmds commands that describe the manifests used with apply
+var schemaCmds = []Command{
+	{
+		Description:  "Export the JSON Schema of a manifest kind.",
+		Subject:      "schema",
+		AltSubject:   "schema",
+		Predicate:    "export",
+		AltPredicate: "get",
+		FlagSet:      flag.NewFlagSet("export schema", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"kind": c.FlagSet.String("kind", _nilDefaultStr, red("(Required)")+fmt.Sprintf(" The kind whose schema is exported. Supported values are %s.", strings.Join(kindsDependencyOrder, ", "))),
+			}
+		},
+		ExecuteFunc: schemaExportCmd,
+		Example: `
+metalcloud-cli schema export --kind SwitchDevice > switch-device.schema.json
+		`,
+	},
+}
+
+func schemaExportCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	kind, ok := getStringParamOk(c.Arguments["kind"])
+	if !ok {
+		return "", fmt.Errorf("-kind is required")
+	}
+
+	if !stringInSlice(kind, kindsDependencyOrder) {
+		return "", fmt.Errorf("kind %s is not supported. Supported values are %s", kind, strings.Join(kindsDependencyOrder, ", "))
+	}
+
+	schema, err := getKindSchema(kind)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes) + "\n", nil
+}
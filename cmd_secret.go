@@ -23,7 +23,7 @@ var secretsCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list secrets", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"usage":  c.FlagSet.String("usage", _nilDefaultStr, "Secret's usage"),
 			}
 		},
@@ -126,7 +126,7 @@ func secretsListCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Secrets", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Secrets", "")
 }
 
 func secretCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
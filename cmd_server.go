@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
@@ -21,7 +22,7 @@ var serversCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list servers", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":              c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":              tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"filter":              c.FlagSet.String("filter", "*", "filter to use when searching for servers. Check the documentation for examples. Defaults to '*'"),
 				"show_credentials":    c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the servers' IPMI credentials. (Slow for large queries)"),
 				"show_rack_info":      c.FlagSet.Bool("show-rack-info", false, green("(Flag)")+" If set returns the servers' rack metadata"),
@@ -47,7 +48,7 @@ metalcloud-cli server list --show-credentials # to retrieve a list of credential
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, "Server's ID or UUID"),
-				"format":            c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":            outputFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"show_credentials":  c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the servers' IPMI credentials"),
 				"raw":               c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the servers' raw object serialized using specified format"),
 			}
@@ -95,6 +96,36 @@ metalcloud-cli server list --show-credentials # to retrieve a list of credential
 		ExecuteFunc: serverRegisterCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Register servers from a csv file.",
+		Subject:      "server",
+		AltSubject:   "srv",
+		Predicate:    "register-bulk",
+		AltPredicate: "bulk-register",
+		FlagSet:      flag.NewFlagSet("register servers from file", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"read_config_from_file": c.FlagSet.String("f", _nilDefaultStr, red("(Required)")+" The csv file with the servers to register. The columns are datacenter, vendor, mgmt_address, mgmt_user and mgmt_pass."),
+				"results_file":          c.FlagSet.String("results", _nilDefaultStr, "The csv file in which the server id or the error of each row is written. The default is the input file name followed by .results.csv"),
+				"resume":                c.FlagSet.Bool("resume", false, green("(Flag)")+" If set, the servers that the results file lists as registered are skipped and the file is updated."),
+				"concurrency":           c.FlagSet.Int("concurrency", 5, "The maximum number of servers registered at the same time."),
+				"format":                tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverRegisterBulkCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#create file servers.csv:
+datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass
+dc1,dell,10.0.0.11,root,calvin
+dc1,hpe,10.0.0.12,admin,password
+
+metalcloud-cli server register-bulk -f servers.csv --concurrency 10
+
+#after fixing the rows that failed, register the remaining servers:
+metalcloud-cli server register-bulk -f servers.csv --resume
+`,
+	},
 
 	{
 		Description:  "Edit server.",
@@ -252,7 +283,7 @@ metalcloud-cli server list --show-credentials # to retrieve a list of credential
 		FlagSet:      flag.NewFlagSet("list server interfaces", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":            c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"server_id_or_uuid": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)")+" Server's id."),
 				"raw":               c.FlagSet.Bool("raw", false, green("(Flag)")+" When set the return will be a full dump of the object. This is useful when copying configurations. Only works with json and yaml formats."),
 			}
@@ -260,6 +291,95 @@ metalcloud-cli server list --show-credentials # to retrieve a list of credential
 		ExecuteFunc: serverInterfacesListCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Lists server hardware components: the firmware components, the disks and the network interfaces. The vendor, model and serial number of firmware components are shown only if their firmware details include them. Memory modules and power supplies are listed only if they are reported as firmware components.",
+		Subject:      "server",
+		AltSubject:   "srv",
+		Predicate:    "components",
+		AltPredicate: "comp",
+		FlagSet:      flag.NewFlagSet("list server hardware components", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Server's ID or UUID"),
+				"type":              c.FlagSet.String("type", _nilDefaultStr, "The optional parameter acts as a filter that restricts the returned results to components of the specified type. For example `disk`."),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverComponentsListCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Compares the hardware components of two servers, as listed by server components.",
+		Subject:      "server",
+		AltSubject:   "srv",
+		Predicate:    "components-diff",
+		AltPredicate: "comp-diff",
+		FlagSet:      flag.NewFlagSet("compare server hardware components", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid":  c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" The ID or UUID of the server to compare against."),
+				"server_id_or_uuid2": c.FlagSet.String("id2", _nilDefaultStr, red("(Required)")+" The ID or UUID of the server to compare."),
+				"show_same":          c.FlagSet.Bool("show-same", false, green("(Flag)")+" If set the components that are the same on both servers are also shown."),
+				"format":             tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverComponentsDiffCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#components are paired by type and name. Serial numbers are not compared:
+metalcloud-cli server components-diff --id 100 --id2 101
+`,
+	},
+	{
+		Description:  "Decommission servers.",
+		Subject:      "server",
+		AltSubject:   "srv",
+		Predicate:    "decommission",
+		AltPredicate: "decom",
+		FlagSet:      flag.NewFlagSet("decommission servers", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, "Server's ID or UUID. Required if -filter is not used."),
+				"filter":            c.FlagSet.String("filter", _nilDefaultStr, "Filter to use when searching for the servers to decommission. Required if -id is not used."),
+				"skip_ipmi":         c.FlagSet.Bool("skip-ipmi", false, green("(Flag)")+" If set the server's BMC is not contacted."),
+				"autoconfirm":       c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverDecommissionCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#servers allocated to instances are not decommissioned. The serial number of a server given with --id must be typed to confirm.
+#The servers matching --filter are listed with their switch interfaces and the number of servers to remove must be typed to confirm:
+metalcloud-cli server decommission --id 100
+metalcloud-cli server decommission --filter "removed_from_rack"
+metalcloud-cli server decommission --filter "status:available datacenter_name:dc1"
+`,
+	},
+	{
+		Description:  "Delete servers.",
+		Subject:      "server",
+		AltSubject:   "srv",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete servers", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, "Server's ID or UUID. Required if -filter is not used."),
+				"filter":            c.FlagSet.String("filter", _nilDefaultStr, "Filter to use when searching for the servers to delete. Required if -id is not used."),
+				"skip_ipmi":         c.FlagSet.Bool("skip-ipmi", false, green("(Flag)")+" If set the server's BMC is not contacted."),
+				"autoconfirm":       c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverDeleteCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#servers allocated to instances are not deleted. The serial number of a server given with --id must be typed to confirm.
+#The servers matching --filter are listed with their switch interfaces and the number of servers to remove must be typed to confirm:
+metalcloud-cli server delete --id 100
+`,
+	},
 }
 
 func serverPowerControlCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -935,7 +1055,7 @@ func serversListCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 		title = title + fmt.Sprintf(" %d decommissioned", statusCounts["decommissioned"])
 	}
 
-	return table.RenderTable(title, "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, title, "")
 }
 
 func serverGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -1250,6 +1370,109 @@ func serverRegisterCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 	return "", err
 }
 
+func serverRegisterBulkCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	filePath, ok := getStringParamOk(c.Arguments["read_config_from_file"])
+	if !ok {
+		return "", fmt.Errorf("-f is required")
+	}
+
+	resultsPath, ok := getStringParamOk(c.Arguments["results_file"])
+	if !ok {
+		resultsPath = filePath + ".results.csv"
+	}
+
+	concurrency := getIntParam(c.Arguments["concurrency"])
+	if concurrency < 1 {
+		return "", fmt.Errorf("-concurrency must be at least 1")
+	}
+
+	content, err := readInputFromFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	registrations, err := parseServerRegistrations(content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", filePath, err)
+	}
+
+	f, w, registered, err := openServerRegistrationResults(resultsPath, getBoolParam(c.Arguments["resume"]))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ROW",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "MGMT ADDRESS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SERVER ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "RESULT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "ERROR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+	}
+
+	data := [][]interface{}{}
+	pending := []serverRegistration{}
+
+	for _, r := range registrations {
+		if prev, ok := registered[strings.ToLower(r.server.ServerManagementAddress)]; ok {
+			data = append(data, []interface{}{r.row, r.server.ServerManagementAddress, prev.serverID, "skipped", ""})
+			continue
+		}
+		pending = append(pending, r)
+	}
+
+	skipped := len(data)
+	failed := 0
+
+	err = registerServers(pending, concurrency, client, func(r serverRegistrationResult) error {
+		result := "registered"
+		if r.err != "" {
+			result = "failed"
+			failed++
+		}
+
+		data = append(data, []interface{}{r.row, r.mgmtAddress, r.serverID, result, r.err})
+
+		return writeServerRegistrationResult(w, r)
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not write the results file %s: %s", resultsPath, err)
+	}
+
+	tableformatter.TableSorter(schema).OrderBy(schema[0].FieldName).Sort(data)
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d of %d servers registered, %d failed, %d skipped. Results written to %s",
+		len(pending)-failed, len(registrations), failed, skipped, resultsPath)
+
+	return renderTable(c, table, "Servers", topLine)
+}
+
 func serverEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 
 	server, err := getServerFromCommand("id", c, client, false)
@@ -1347,8 +1570,12 @@ func serverEditIPMICmd(c *Command, client metalcloud.MetalCloudClient) (string,
 }
 
 func getServerFromCommand(paramName string, c *Command, client metalcloud.MetalCloudClient, decryptPassword bool) (*metalcloud.Server, error) {
+	return getServerFromCommandWithPrivateParam("server_id_or_uuid", paramName, c, client, decryptPassword)
+}
+
+func getServerFromCommandWithPrivateParam(privateParamName string, paramName string, c *Command, client metalcloud.MetalCloudClient, decryptPassword bool) (*metalcloud.Server, error) {
 
-	m, err := getParam(c, "server_id_or_uuid", paramName)
+	m, err := getParam(c, privateParamName, paramName)
 	if err != nil {
 		return nil, err
 	}
@@ -1492,7 +1719,7 @@ func serverInterfacesListCmd(c *Command, client metalcloud.MetalCloudClient) (st
 			Data:   data,
 			Schema: schema,
 		}
-		ret, err := table.RenderTable(fmt.Sprintf("Server interfaces of server #%d %s", server.ServerID, server.ServerSerialNumber), "", format)
+		ret, err := renderTable(c, table, fmt.Sprintf("Server interfaces of server #%d %s", server.ServerID, server.ServerSerialNumber), "")
 		if err != nil {
 			return "", err
 		}
@@ -1524,3 +1751,517 @@ func getStringFromStringOrEmpty(str *string) string {
 	}
 	return *str
 }
+
+func serverComponentsListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	server, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	components, err := getServerComponentInventory(server, client)
+	if err != nil {
+		return "", err
+	}
+
+	componentType := getStringParam(c.Arguments["type"])
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "VENDOR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "MODEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SERIAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "VERSION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, s := range components {
+		if componentType != "" && !strings.EqualFold(s.componentType, componentType) {
+			continue
+		}
+
+		data = append(data, []interface{}{
+			s.id,
+			s.componentType,
+			s.name,
+			s.vendor,
+			s.model,
+			s.serial,
+			s.version,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Components of server %s (#%d)", server.ServerSerialNumber, server.ServerID)
+
+	return renderTable(c, table, "Components", topLine)
+}
+
+func serverComponentsDiffCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	first, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	second, err := getServerFromCommandWithPrivateParam("server_id_or_uuid2", "id2", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	if first.ServerID == second.ServerID {
+		return "", fmt.Errorf("a server cannot be compared to itself")
+	}
+
+	firstComponents, err := getServerComponentInventory(first, client)
+	if err != nil {
+		return "", err
+	}
+
+	secondComponents, err := getServerComponentInventory(second, client)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "PROPERTY",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 8,
+		},
+		{
+			FieldName: "FIRST",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "SECOND",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	//a component with several different properties is counted once
+	counts := map[string]int{}
+	counted := map[string]bool{}
+	data := [][]interface{}{}
+
+	for _, d := range diffServerComponents(firstComponents, secondComponents) {
+		if !counted[d.status+"/"+d.key] {
+			counted[d.status+"/"+d.key] = true
+			counts[d.status]++
+		}
+
+		if d.status == "same" && !getBoolParam(c.Arguments["show_same"]) {
+			continue
+		}
+
+		data = append(data, []interface{}{
+			d.status,
+			d.componentType,
+			d.name,
+			d.property,
+			d.first,
+			d.second,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Server %s (#%d) compared to server %s (#%d): %d different, %d missing, %d extra components",
+		second.ServerSerialNumber,
+		second.ServerID,
+		first.ServerSerialNumber,
+		first.ServerID,
+		counts["different"],
+		counts["missing"],
+		counts["extra"])
+
+	return renderTable(c, table, "Component differences", topLine)
+}
+
+//serverRemoval is the operation performed by server decommission and server delete
+type serverRemoval struct {
+	verb   string
+	done   string
+	remove func(serverID int, skipIPMI bool) error
+}
+
+func serverDecommissionCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	return serverRemoveCmd(c, client, serverRemoval{
+		verb:   "decommission",
+		done:   "decommissioned",
+		remove: client.ServerDecomission,
+	})
+}
+
+func serverDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+	return serverRemoveCmd(c, client, serverRemoval{
+		verb:   "delete",
+		done:   "deleted",
+		remove: client.ServerDelete,
+	})
+}
+
+//serverRemoveCmd removes the server given with -id or the servers matching -filter.
+//A server given with -id that cannot be removed returns an error. With -filter the result of each server is listed.
+func serverRemoveCmd(c *Command, client metalcloud.MetalCloudClient, removal serverRemoval) (string, error) {
+
+	servers, batch, err := getServersToRemove(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	skipIPMI := getBoolParam(c.Arguments["skip_ipmi"])
+
+	//the switch interfaces are shown before removing the servers, and in the results of a batch
+	interfaces := map[int][]metalcloud.SwitchInterfaceSearchResult{}
+	for _, s := range servers {
+		if getServerRemovalSkipReason(s, removal) != "" {
+			continue
+		}
+
+		list, err := client.SwitchInterfaceSearch(fmt.Sprintf("server_id:%d", s.ServerID))
+		if err != nil {
+			return "", err
+		}
+
+		interfaces[s.ServerID] = *list
+	}
+
+	if batch && len(servers) > 0 {
+		confirm, err := confirmServersRemoval(c, servers, interfaces, removal)
+		if err != nil {
+			return "", err
+		}
+
+		if !confirm {
+			return "", fmt.Errorf("Operation not confirmed. Aborting")
+		}
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "SERIAL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "RESULT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "REASON",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "SWITCH INTERFACES",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	counts := map[string]int{}
+	data := [][]interface{}{}
+
+	for _, s := range servers {
+		result, reason, err := removeServer(s, interfaces[s.ServerID], removal, skipIPMI, batch, c, client)
+		if err != nil && !batch {
+			return "", err
+		}
+
+		if !batch {
+			return fmt.Sprintf("Server #%d (%s) was %s. Its switch interfaces were:\n%s\n",
+				s.ServerID,
+				s.ServerSerialNumber,
+				removal.done,
+				strings.Join(getSwitchInterfaceLines(interfaces[s.ServerID]), "\n")), nil
+		}
+
+		counts[result]++
+
+		switchInterfaces := []string{}
+		for _, i := range interfaces[s.ServerID] {
+			switchInterfaces = append(switchInterfaces, fmt.Sprintf("%s %s", i.NetworkEquipmentIdentifierString, i.NetworkEquipmentInterfaceIdentifierString))
+		}
+
+		data = append(data, []interface{}{
+			s.ServerID,
+			s.ServerSerialNumber,
+			s.ServerStatus,
+			result,
+			reason,
+			strings.Join(switchInterfaces, ", "),
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("%d of %d servers %s, %d skipped, %d not confirmed, %d failed",
+		counts[removal.done], len(servers), removal.done, counts["skipped"], counts["not confirmed"], counts["failed"])
+
+	return renderTable(c, table, "Servers", topLine)
+}
+
+//getServersToRemove returns the server given with -id or the servers matching -filter and whether -filter was used
+func getServersToRemove(c *Command, client metalcloud.MetalCloudClient) ([]metalcloud.ServerSearchResult, bool, error) {
+
+	filter, filterOk := getStringParamOk(c.Arguments["filter"])
+
+	if _, err := getParam(c, "server_id_or_uuid", "id"); err != nil {
+		if !filterOk {
+			return nil, false, fmt.Errorf("-id or -filter is required")
+		}
+
+		list, err := client.ServersSearch(convertToSearchFieldFormat(filter))
+		if err != nil {
+			return nil, false, err
+		}
+
+		servers := []metalcloud.ServerSearchResult{}
+		for _, s := range *list {
+			servers = append(servers, s)
+		}
+
+		sort.Slice(servers, func(i, j int) bool { return servers[i].ServerID < servers[j].ServerID })
+
+		return servers, true, nil
+	}
+
+	if filterOk {
+		return nil, false, fmt.Errorf("-id and -filter cannot be used together")
+	}
+
+	server, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	//the allocation of the server is only returned by searches
+	list, err := client.ServersSearch(fmt.Sprintf("+server_id:%d", server.ServerID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, s := range *list {
+		if s.ServerID == server.ServerID {
+			return []metalcloud.ServerSearchResult{s}, false, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("server #%d was not found by search", server.ServerID)
+}
+
+//getServerRemovalSkipReason returns why the server cannot be removed or an empty string if it can be
+func getServerRemovalSkipReason(s metalcloud.ServerSearchResult, removal serverRemoval) string {
+
+	reason := ""
+	switch {
+	case len(s.InstanceID) > 0 && s.InstanceID[0] != 0:
+		reason = fmt.Sprintf("allocated to instance #%d", s.InstanceID[0])
+		if len(s.InstanceLabel) > 0 {
+			reason = fmt.Sprintf("allocated to instance %s (#%d)", s.InstanceLabel[0], s.InstanceID[0])
+		}
+	case s.ServerStatus == "used" || s.ServerStatus == "used_registering":
+		reason = fmt.Sprintf("server status is %s", s.ServerStatus)
+	case s.ServerStatus == "decommissioned" && removal.verb == "decommission":
+		reason = "already decommissioned"
+	}
+
+	return reason
+}
+
+//removeServer checks that the server can be removed, asks for the serial number to confirm unless it is part of an
+//already confirmed batch and removes it. Returns the result, the reason if the server was not removed and an error in the same case.
+func removeServer(s metalcloud.ServerSearchResult, interfaces []metalcloud.SwitchInterfaceSearchResult, removal serverRemoval, skipIPMI bool, batch bool, c *Command, client metalcloud.MetalCloudClient) (string, string, error) {
+
+	if reason := getServerRemovalSkipReason(s, removal); reason != "" {
+		return "skipped", reason, fmt.Errorf("server %s (#%d) cannot be %s: %s", s.ServerSerialNumber, s.ServerID, removal.done, reason)
+	}
+
+	if !batch && !getBoolParam(c.Arguments["autoconfirm"]) {
+		confirm, err := confirmServerSerialNumber(s, interfaces, removal)
+		if err != nil {
+			return "failed", err.Error(), err
+		}
+
+		if !confirm {
+			return "not confirmed", "", fmt.Errorf("Operation not confirmed. Aborting")
+		}
+	}
+
+	if err := removal.remove(s.ServerID, skipIPMI); err != nil {
+		return "failed", err.Error(), err
+	}
+
+	return removal.done, "", nil
+}
+
+//getSwitchInterfaceLines returns an indented line for each switch interface of a server, sorted by index
+func getSwitchInterfaceLines(interfaces []metalcloud.SwitchInterfaceSearchResult) []string {
+
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].ServerInterfaceIndex < interfaces[j].ServerInterfaceIndex })
+
+	lines := []string{}
+	for _, i := range interfaces {
+		lines = append(lines, fmt.Sprintf("  interface %d (%s) connected to %s (#%d) %s",
+			i.ServerInterfaceIndex,
+			i.ServerInterfaceMACAddress,
+			i.NetworkEquipmentIdentifierString,
+			i.NetworkEquipmentID,
+			i.NetworkEquipmentInterfaceIdentifierString))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "  no switch interfaces")
+	}
+
+	return lines
+}
+
+//confirmServersRemoval shows all the servers matching -filter with their switch interfaces, or why they are skipped, and asks
+//for the number of servers that will be removed so that an over-broad filter is not confirmed by mistake
+func confirmServersRemoval(c *Command, servers []metalcloud.ServerSearchResult, interfaces map[int][]metalcloud.SwitchInterfaceSearchResult, removal serverRemoval) (bool, error) {
+
+	if getBoolParam(c.Arguments["autoconfirm"]) {
+		return true, nil
+	}
+
+	count := 0
+	lines := []string{}
+	for _, s := range servers {
+		if reason := getServerRemovalSkipReason(s, removal); reason != "" {
+			lines = append(lines, fmt.Sprintf("Server #%d (%s) %s %s is skipped: %s", s.ServerID, s.ServerSerialNumber, s.ServerVendor, s.ServerProductName, reason))
+			continue
+		}
+
+		count++
+		lines = append(lines, fmt.Sprintf("Server #%d (%s) %s %s with the switch interfaces:", s.ServerID, s.ServerSerialNumber, s.ServerVendor, s.ServerProductName))
+		lines = append(lines, getSwitchInterfaceLines(interfaces[s.ServerID])...)
+	}
+
+	//all the servers are skipped
+	if count == 0 {
+		return true, nil
+	}
+
+	confirmationMessage := fmt.Sprintf("%d servers match the filter and %s of them will be %s:\n%s\nType the number of servers that will be %s to continue:",
+		len(servers),
+		yellow(fmt.Sprintf("%d", count)),
+		removal.done,
+		strings.Join(lines, "\n"),
+		removal.done)
+
+	//this is simply so that we don't output a text on the command line under go test
+	if strings.HasSuffix(os.Args[0], ".test") {
+		confirmationMessage = ""
+	}
+
+	input, err := requestInputString(confirmationMessage)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(input) == fmt.Sprintf("%d", count), nil
+}
+
+//confirmServerSerialNumber shows the server and its switch interfaces and asks for its serial number, or its id if it has none
+func confirmServerSerialNumber(s metalcloud.ServerSearchResult, interfaces []metalcloud.SwitchInterfaceSearchResult, removal serverRemoval) (bool, error) {
+
+	expected := s.ServerSerialNumber
+	expectedName := "serial number"
+	if expected == "" {
+		expected = fmt.Sprintf("%d", s.ServerID)
+		expectedName = "id"
+	}
+
+	lines := getSwitchInterfaceLines(interfaces)
+
+	confirmationMessage := fmt.Sprintf("Server #%s (%s) %s %s will be %s. Its switch interfaces are:\n%s\nType the %s of the server to continue:",
+		blue(fmt.Sprintf("%d", s.ServerID)),
+		yellow(s.ServerSerialNumber),
+		s.ServerVendor,
+		s.ServerProductName,
+		removal.done,
+		strings.Join(lines, "\n"),
+		expectedName)
+
+	//this is simply so that we don't output a text on the command line under go test
+	if strings.HasSuffix(os.Args[0], ".test") {
+		confirmationMessage = ""
+	}
+
+	input, err := requestInputString(confirmationMessage)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(input) == expected, nil
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+var serverFirmwareCmds = []Command{
+
+	{
+		Description:  "Lists the firmware of a server's components.",
+		Subject:      "server-firmware",
+		AltSubject:   "firmware",
+		Predicate:    "components",
+		AltPredicate: "comp",
+		FlagSet:      flag.NewFlagSet("list server components firmware", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Server's ID or UUID"),
+				"filter":            c.FlagSet.String("filter", "", "Filter to restrict the results. For example 'server_component_type:bios'."),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverFirmwareComponentsListCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Upgrades the firmware of a server.",
+		Subject:      "server-firmware",
+		AltSubject:   "firmware",
+		Predicate:    "upgrade",
+		AltPredicate: "update",
+		FlagSet:      flag.NewFlagSet("upgrade server firmware", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Server's ID or UUID"),
+				"component_id":      c.FlagSet.Int("component-id", _nilDefaultInt, "The id of the component to upgrade. If not set all the updateable components that have a target version are upgraded."),
+				"version":           c.FlagSet.String("version", _nilDefaultStr, "The version to upgrade the component to. If not set the target version of the component is used."),
+				"url":               c.FlagSet.String("url", _nilDefaultStr, "The url of the firmware binary. If not set the url registered for the version is used."),
+				"autoconfirm":       c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: serverFirmwareUpgradeCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli server-firmware upgrade --id 100
+metalcloud-cli server-firmware upgrade --id 100 --component-id 2001 --version 2.15.0
+`,
+	},
+	{
+		Description:  "Sets the firmware version a component is upgraded to on the next upgrade.",
+		Subject:      "server-firmware",
+		AltSubject:   "firmware",
+		Predicate:    "set-target",
+		AltPredicate: "target",
+		FlagSet:      flag.NewFlagSet("set server component target firmware version", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"component_id": c.FlagSet.Int("component-id", _nilDefaultInt, red("(Required)")+" The id of the component."),
+				"version":      c.FlagSet.String("version", _nilDefaultStr, red("(Required)")+" The target version."),
+				"url":          c.FlagSet.String("url", _nilDefaultStr, "The url of the firmware binary. Required if the version is not one of the available versions of the component, in which case it is added to them."),
+			}
+		},
+		ExecuteFunc: serverFirmwareSetTargetCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli server-firmware set-target --component-id 2001 --version 2.15.0
+metalcloud-cli server-firmware set-target --component-id 2001 --version 2.16.1 --url http://repo/bios-2.16.1.exe
+`,
+	},
+	{
+		Description:  "Refreshes the available firmware versions of a server's components from the vendor's catalog.",
+		Subject:      "server-firmware",
+		AltSubject:   "firmware",
+		Predicate:    "refresh-versions",
+		AltPredicate: "refresh",
+		FlagSet:      flag.NewFlagSet("refresh server components available firmware versions", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"component_id": c.FlagSet.Int("component-id", _nilDefaultInt, red("(Required)")+" The id of a component of the server."),
+			}
+		},
+		ExecuteFunc: serverFirmwareRefreshVersionsCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+}
+
+func serverFirmwareComponentsListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	server, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	list, err := client.ServerComponents(server.ServerID, getStringParam(c.Arguments["filter"]))
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "VERSION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "AVAILABLE VERSIONS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "UPDATEABLE",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "UPDATED",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, s := range *list {
+		data = append(data, []interface{}{
+			s.ServerComponentID,
+			s.ServerComponentName,
+			s.ServerComponentType,
+			s.ServerComponentFirmwareVersion,
+			strings.Join(s.ServerComponentFirmwareUpdateAvailableVersions, ","),
+			s.ServerComponentFirmwareUpdateable,
+			s.ServerComponentFirmwareStatus,
+			s.ServerComponentFirmwareUpdateTimestamp,
+		})
+	}
+
+	tableformatter.TableSorter(schema).OrderBy(schema[0].FieldName).Sort(data)
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Components of server %s (#%d)", server.ServerSerialNumber, server.ServerID)
+
+	return renderTable(c, table, "Components", topLine)
+}
+
+func serverFirmwareUpgradeCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	server, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	componentID, isComponent := getIntParamOk(c.Arguments["component_id"])
+	version := getStringParam(c.Arguments["version"])
+	url := getStringParam(c.Arguments["url"])
+
+	var component *metalcloud.ServerComponent
+	if isComponent {
+		component, err = client.ServerComponentGet(componentID)
+		if err != nil {
+			return "", err
+		}
+
+		if component.ServerID != server.ServerID {
+			return "", fmt.Errorf("component #%d is not a component of server #%d", componentID, server.ServerID)
+		}
+
+		if !component.ServerComponentFirmwareUpdateable {
+			return "", fmt.Errorf("the firmware of component %s (#%d) is not updateable", component.ServerComponentName, componentID)
+		}
+	} else if version != "" || url != "" {
+		return "", fmt.Errorf("-version and -url can only be used together with -component-id")
+	}
+
+	var upgraded []metalcloud.ServerComponent
+	if !isComponent {
+		upgraded, err = getServerComponentsWithTargetVersion(server.ServerID, client)
+		if err != nil {
+			return "", err
+		}
+
+		if len(upgraded) == 0 {
+			return "", fmt.Errorf("no updateable component of server %s (#%d) has a target version", server.ServerSerialNumber, server.ServerID)
+		}
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		lines := []string{}
+		for _, s := range upgraded {
+			lines = append(lines, fmt.Sprintf("  %s (#%d): %s -> %s", s.ServerComponentName, s.ServerComponentID, s.ServerComponentFirmwareVersion, s.ServerComponentFirmwareTargetVersion))
+		}
+
+		confirmationMessage := fmt.Sprintf("Upgrading the firmware of the following components of server %s (#%d):\n%s\nAre you sure? Type \"yes\" to continue:",
+			server.ServerSerialNumber,
+			server.ServerID,
+			strings.Join(lines, "\n"))
+
+		if isComponent {
+			confirmationMessage = fmt.Sprintf("Upgrading the firmware of component %s (#%d) of server %s (#%d).  Are you sure? Type \"yes\" to continue:",
+				component.ServerComponentName,
+				component.ServerComponentID,
+				server.ServerSerialNumber,
+				server.ServerID)
+		}
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	if isComponent {
+		return "", client.ServerFirmwareComponentUpgrade(server.ServerID, componentID, version, url)
+	}
+
+	return "", client.ServerFirmwareUpgrade(server.ServerID)
+}
+
+//getServerComponentsWithTargetVersion returns the updateable components of a server that have a target version,
+//which are the ones upgraded by a whole server upgrade.
+//Server component searches do not return the target versions so updateable components are retrieved one by one.
+func getServerComponentsWithTargetVersion(serverID int, client metalcloud.MetalCloudClient) ([]metalcloud.ServerComponent, error) {
+	list, err := client.ServerComponents(serverID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	components := []metalcloud.ServerComponent{}
+	for _, s := range *list {
+		if !s.ServerComponentFirmwareUpdateable {
+			continue
+		}
+
+		component, err := client.ServerComponentGet(s.ServerComponentID)
+		if err != nil {
+			return nil, err
+		}
+
+		if component.ServerComponentFirmwareTargetVersion != "" {
+			components = append(components, *component)
+		}
+	}
+
+	return components, nil
+}
+
+func serverFirmwareSetTargetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	componentID, ok := getIntParamOk(c.Arguments["component_id"])
+	if !ok {
+		return "", fmt.Errorf("-component-id is required")
+	}
+
+	version, ok := getStringParamOk(c.Arguments["version"])
+	if !ok {
+		return "", fmt.Errorf("-version is required")
+	}
+
+	component, err := client.ServerComponentGet(componentID)
+	if err != nil {
+		return "", err
+	}
+
+	if !component.ServerComponentFirmwareUpdateable {
+		return "", fmt.Errorf("the firmware of component %s (#%d) is not updateable", component.ServerComponentName, componentID)
+	}
+
+	if url, ok := getStringParamOk(c.Arguments["url"]); ok {
+		err = client.ServerFirmwareComponentTargetVersionAdd(componentID, version, url)
+		if err != nil {
+			return "", err
+		}
+	} else if !stringInSlice(version, component.ServerComponentFirmwareUpdateAvailableVersions) {
+		return "", fmt.Errorf("version %s is not available for component %s (#%d). Available versions are: %s. Use -url to add it",
+			version,
+			component.ServerComponentName,
+			componentID,
+			strings.Join(component.ServerComponentFirmwareUpdateAvailableVersions, ","))
+	}
+
+	return "", client.ServerFirmwareComponentTargetVersionSet(componentID, version)
+}
+
+func serverFirmwareRefreshVersionsCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	componentID, ok := getIntParamOk(c.Arguments["component_id"])
+	if !ok {
+		return "", fmt.Errorf("-component-id is required")
+	}
+
+	return "", client.ServerFirmwareComponentTargetVersionUpdate(componentID)
+}
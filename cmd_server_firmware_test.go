@@ -0,0 +1,249 @@
+package main
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestServerFirmwareComponentsListCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	server := metalcloud.Server{
+		ServerID:           100,
+		ServerSerialNumber: "SN100",
+	}
+
+	list := []metalcloud.ServerComponent{
+		{
+			ServerComponentID:                              2001,
+			ServerID:                                       100,
+			ServerComponentName:                            "BIOS",
+			ServerComponentType:                            "bios",
+			ServerComponentFirmwareVersion:                 "2.14.0",
+			ServerComponentFirmwareUpdateable:              true,
+			ServerComponentFirmwareUpdateAvailableVersions: []string{"2.15.0", "2.16.1"},
+		},
+	}
+
+	client.EXPECT().
+		ServerGet(100, false).
+		Return(&server, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponents(100, "").
+		Return(&list, nil).
+		AnyTimes()
+
+	expectedFirstRow := map[string]interface{}{
+		"ID":                 2001,
+		"NAME":               "BIOS",
+		"VERSION":            "2.14.0",
+		"AVAILABLE VERSIONS": "2.15.0,2.16.1",
+		"UPDATEABLE":         true,
+	}
+
+	cmd := MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+		"filter":            "",
+	})
+
+	testListCommand(serverFirmwareComponentsListCmd, &cmd, client, expectedFirstRow, t)
+}
+
+func TestServerFirmwareUpgradeCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	server := metalcloud.Server{
+		ServerID:           100,
+		ServerSerialNumber: "SN100",
+	}
+
+	client.EXPECT().
+		ServerGet(100, false).
+		Return(&server, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponentGet(2001).
+		Return(&metalcloud.ServerComponent{ServerComponentID: 2001, ServerID: 100, ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "2.14.0", ServerComponentFirmwareTargetVersion: "2.15.0"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponentGet(2002).
+		Return(&metalcloud.ServerComponent{ServerComponentID: 2002, ServerID: 101, ServerComponentFirmwareUpdateable: true}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerGet(101, false).
+		Return(&metalcloud.Server{ServerID: 101, ServerSerialNumber: "SN101"}, nil).
+		AnyTimes()
+
+	//searches do not return the server id and the target version of the components
+	client.EXPECT().
+		ServerComponents(100, "").
+		Return(&[]metalcloud.ServerComponent{
+			{ServerComponentID: 2001, ServerComponentFirmwareUpdateable: true, ServerComponentFirmwareVersion: "2.14.0"},
+			{ServerComponentID: 2003, ServerComponentFirmwareUpdateable: true},
+		}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponentGet(2003).
+		Return(&metalcloud.ServerComponent{ServerComponentID: 2003, ServerID: 100, ServerComponentFirmwareUpdateable: true}, nil).
+		Times(1)
+
+	//only components without a target version or not updateable
+	client.EXPECT().
+		ServerComponents(101, "").
+		Return(&[]metalcloud.ServerComponent{
+			{ServerComponentID: 2002, ServerComponentFirmwareUpdateable: true},
+			{ServerComponentID: 2004},
+		}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerFirmwareUpgrade(100).
+		Return(nil).
+		Times(1)
+
+	client.EXPECT().
+		ServerFirmwareComponentUpgrade(100, 2001, "2.15.0", "").
+		Return(nil).
+		Times(1)
+
+	cases := []CommandTestCase{
+		{
+			name: "all components",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_id_or_uuid": 100,
+				"autoconfirm":       true,
+			}),
+			good: true,
+		},
+		{
+			name: "no component with a target version",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_id_or_uuid": 101,
+				"autoconfirm":       true,
+			}),
+			good: false,
+		},
+		{
+			name: "one component",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_id_or_uuid": 100,
+				"component_id":      2001,
+				"version":           "2.15.0",
+				"autoconfirm":       true,
+			}),
+			good: true,
+		},
+		{
+			name: "component of another server",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_id_or_uuid": 100,
+				"component_id":      2002,
+				"autoconfirm":       true,
+			}),
+			good: false,
+		},
+		{
+			name: "version without component",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_id_or_uuid": 100,
+				"version":           "2.15.0",
+				"autoconfirm":       true,
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(serverFirmwareUpgradeCmd, cases, client, t)
+}
+
+func TestServerFirmwareSetTargetCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	client.EXPECT().
+		ServerComponentGet(2001).
+		Return(&metalcloud.ServerComponent{
+			ServerComponentID:                              2001,
+			ServerComponentFirmwareUpdateable:              true,
+			ServerComponentFirmwareUpdateAvailableVersions: []string{"2.15.0"},
+		}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerComponentGet(2002).
+		Return(&metalcloud.ServerComponent{ServerComponentID: 2002}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerFirmwareComponentTargetVersionAdd(2001, "2.16.1", "http://repo/bios-2.16.1.exe").
+		Return(nil).
+		Times(1)
+
+	client.EXPECT().
+		ServerFirmwareComponentTargetVersionSet(2001, gomock.Any()).
+		Return(nil).
+		Times(2)
+
+	cases := []CommandTestCase{
+		{
+			name: "available version",
+			cmd: MakeCommand(map[string]interface{}{
+				"component_id": 2001,
+				"version":      "2.15.0",
+			}),
+			good: true,
+		},
+		{
+			name: "new version with url",
+			cmd: MakeCommand(map[string]interface{}{
+				"component_id": 2001,
+				"version":      "2.16.1",
+				"url":          "http://repo/bios-2.16.1.exe",
+			}),
+			good: true,
+		},
+		{
+			name: "new version without url",
+			cmd: MakeCommand(map[string]interface{}{
+				"component_id": 2001,
+				"version":      "2.16.1",
+			}),
+			good: false,
+		},
+		{
+			name: "not updateable",
+			cmd: MakeCommand(map[string]interface{}{
+				"component_id": 2002,
+				"version":      "2.15.0",
+			}),
+			good: false,
+		},
+		{
+			name: "missing version",
+			cmd: MakeCommand(map[string]interface{}{
+				"component_id": 2001,
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(serverFirmwareSetTargetCmd, cases, client, t)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
@@ -451,3 +456,457 @@ func TestServerRegisterCmd(t *testing.T) {
 const _serverFixture1 = "{\"server_id\":310,\"agent_id\":44,\"datacenter_name\":\"es-madrid\",\"server_uuid\":\"44454C4C-5900-1033-8032-B9C04F434631\",\"server_serial_number\":\"9Y32CF1\",\"server_product_name\":\"PowerEdge 1950\",\"server_vendor\":\"Dell Inc.\",\"server_vendor_sku_id\":\"0\",\"server_ipmi_host\":\"10.255.237.28\",\"server_ipmi_internal_username\":\"ddd\",\"server_ipmi_internal_password_encrypted\":\"BSI\\\\JSONRPC\\\\Server\\\\Security\\\\Authorization\\\\DeveloperAuthorization: Not leaking database encrypted values for extra security.\",\"server_ipmi_version\":\"2\",\"server_ram_gbytes\":8,\"server_processor_count\":2,\"server_processor_core_mhz\":2333,\"server_processor_core_count\":4,\"server_processor_name\":\"Intel(R) Xeon(R) CPU           E5345  @ 2.33GHz\",\"server_processor_cpu_mark\":0,\"server_processor_threads\":1,\"server_type_id\":14,\"server_status\":\"available\",\"server_comments\":\"a\",\"server_details_xml\":null,\"server_network_total_capacity_mbps\":4000,\"server_ipmi_channel\":0,\"server_power_status\":\"off\",\"server_power_status_last_update_timestamp\":\"2020-08-19T08:42:22Z\",\"server_ilo_reset_timestamp\":\"0000-00-00T00:00:00Z\",\"server_boot_last_update_timestamp\":null,\"server_bdk_debug\":false,\"server_dhcp_status\":\"deny_requests\",\"server_bios_info_json\":\"{\\\"server_bios_vendor\\\":\\\"Dell Inc.\\\",\\\"server_bios_version\\\":\\\"2.7.0\\\"}\",\"server_vendor_info_json\":\"{\\\"management\\\":\\\"iDRAC\\\",\\\"version\\\":\\\"er] rpcRoundRobinConnectedAgentsOfType() failed with error: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n FetchError: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n\\\\n    at ClientRequest.<anonymous> (\\\\/var\\\\/datacenter-agents-binary-compiled-temp\\\\/Power\\\\/Power.portable.js:8:469877)\\\\n    at ClientRequest.emit (events.js:209:13)\\\\n    at TLSSocket.socketErrorListener (_http_client.js:406:9)\\\\n    at TLSSocket.emit (events.js:209:13)\\\\n    at errorOrDestroy (internal\\\\/streams\\\\/destroy.js:107:12)\\\\n    at onwriteError (_stream_writable.js:449:5)\\\\n    at onwrite (_stream_writable.js:470:5)\\\\n    at internal\\\\/streams\\\\/destroy.js:49:7\\\\n    at TLSSocket.Socket._destroy (net.js:595:3)\\\\n    at TLSSocket.destroy (internal\\\\/streams\\\\/destroy.js:37:8) Exception: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n FetchError: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n\\\\n    at ClientRequest.<anonymous> (\\\\/var\\\\/datacenter-agents-binary-compiled-temp\\\\/Power\\\\/Power.portable.js:8:469877)\\\\n    at ClientRequest.emit (events.js:209:13)\\\\n    at TLSSocket.socketErrorListener (_http_client.js:406:9)\\\\n    at TLSSocket.emit (events.js:209:13)\\\\n    at errorOrDestroy (internal\\\\/streams\\\\/destroy.js:107:12)\\\\n    at onwriteError (_stream_writable.js:449:5)\\\\n    at onwrite (_stream_writable.js:470:5)\\\\n    at internal\\\\/streams\\\\/destroy.js:49:7\\\\n    at TLSSocket.Socket._destroy (net.js:595:3)\\\\n    at TLSSocket.destroy (internal\\\\/streams\\\\/destroy.js:37:8)\\\\n    at \\\\/var\\\\/vhosts\\\\/bsiintegration.bigstepcloud.com\\\\/BSIWebSocketServer\\\\/node_modules\\\\/jsonrpc-bidirectional\\\\/src\\\\/Client.js:331:37\\\\n    at runMicrotasks (<anonymous>)\\\\n    at processTicksAndRejections (internal\\\\/process\\\\/task_queues.js:97:5) Exception: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n FetchError: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n\\\\n    at ClientRequest.<anonymous> (\\\\/var\\\\/datacenter-agents-binary-compiled-temp\\\\/Power\\\\/Power.portable.js:8:469877)\\\\n    at ClientRequest.emit (events.js:209:13)\\\\n    at TLSSocket.socketErrorListener (_http_client.js:406:9)\\\\n    at TLSSocket.emit (events.js:209:13)\\\\n    at errorOrDestroy (internal\\\\/streams\\\\/destroy.js:107:12)\\\\n    at onwriteError (_stream_writable.js:449:5)\\\\n    at onwrite (_stream_writable.js:470:5)\\\\n    at internal\\\\/streams\\\\/destroy.js:49:7\\\\n    at TLSSocket.Socket._destroy (net.js:595:3)\\\\n    at TLSSocket.destroy (internal\\\\/streams\\\\/destroy.js:37:8) Exception: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n FetchError: request to https:\\\\/\\\\/10.255.237.28\\\\/cgi-bin\\\\/webcgi\\\\/about failed, reason: write EPROTO 38858976:error:1425F102:SSL routines:ssl_choose_client_version:unsupported protocol:..\\\\/deps\\\\/openssl\\\\/openssl\\\\/ssl\\\\/statem\\\\/statem_lib.c:1922:\\\\n\\\\n    at ClientRequest.<anonymous> (\\\\/var\\\\/datacenter-agents-binary-compiled-temp\\\\/Power\\\\/Power.portable.js:8:469877)\\\\n    at ClientRequest.emit (events.js:209:13)\\\\n    at TLSSocket.socketErrorListener (_http_client.js:406:9)\\\\n    at TLSSocket.emit (events.js:209:13)\\\\n    at errorOrDestroy (internal\\\\/streams\\\\/destroy.js:107:12)\\\\n    at onwriteError (_stream_writable.js:449:5)\\\\n    at onwrite (_stream_writable.js:470:5)\\\\n    at internal\\\\/streams\\\\/destroy.js:49:7\\\\n    at TLSSocket.Socket._destroy (net.js:595:3)\\\\n    at TLSSocket.destroy (internal\\\\/streams\\\\/destroy.js:37:8)\\\\n    at \\\\/var\\\\/vhosts\\\\/bsiintegration.bigstepcloud.com\\\\/BSIWebSocketServer\\\\/node_modules\\\\/jsonrpc-bidirectional\\\\/src\\\\/Client.js:331:37\\\\n    at runMicrotasks (<anonymous>)\\\\n    at processTicksAndRejections (internal\\\\/process\\\\/task_queues.js:97:5)\\\\n    at \\\\/var\\\\/vhosts\\\\/bsiintegration.bigstepcloud.com\\\\/BSIWebSocketServer\\\\/node_modules\\\\/jsonrpc-bidirectional\\\\/src\\\\/Client.js:331:37\\\\n    at runMicrotasks (<anonymous>)\\\\n    at processTicksAndRejections (internal\\\\/process\\\\/tas\\\"}\",\"server_class\":\"bigdata\",\"server_created_timestamp\":\"2019-07-02T07:57:19Z\",\"subnet_oob_id\":2,\"subnet_oob_index\":28,\"server_boot_type\":\"classic\",\"server_disk_wipe\":true,\"server_disk_count\":0,\"server_disk_size_mbytes\":0,\"server_disk_type\":\"none\",\"server_requires_manual_cleaning\":false,\"chassis_rack_id\":null,\"server_custom_json\":\"{\\\"previous_ipmi_username\\\":\\\"a\\\",\\\"previous_ipmi_password_encrypted\\\":\\\"rq|aes-cbc|urfNNCbe2ouIRX3reLrILyM7tBD5I1aMPycR3YkCeFo1DGEGnNI3n6u7z63sBWpW\\\"}\",\"server_instance_custom_json\":null,\"server_last_cleanup_start\":\"2020-08-12T14:26:47Z\",\"server_allocation_timestamp\":null,\"server_dhcp_packet_sniffing_is_enabled\":true,\"snmp_community_password_dcencrypted\":null,\"server_mgmt_snmp_community_password_dcencrypted\":\"BSI\\\\JSONRPC\\\\Server\\\\Security\\\\Authorization\\\\DeveloperAuthorization: Not leaking database encrypted values for extra security.\",\"server_mgmt_snmp_port\":161,\"server_mgmt_snmp_version\":2,\"server_dhcp_relay_security_is_enabled\":true,\"server_keys_json\":\"{\\\"keys\\\": {\\\"r1\\\": {\\\"created\\\": \\\"2019-07-02T07:59:17Z\\\", \\\"salt_encrypted\\\": \\\"rq|aes-cbc|9721g561woNQzA0a3yWTcHcEYxJo7vXNc1SHmEUCxYdeOqsiVbT+X+leOHHP+XsR1gfOgs8lMhdXLOw0UUBP8g==\\\", \\\"aes_key_encrypted\\\": \\\"rq|aes-cbc|/V4Y7FMu9Uo4PyktBKl+jsAKpogNh+UC2F03jxMtJI2ieacgx/Ogso0Z9d3XlL99zh1pxAPVF24gzAogNIla0L0xBgUgLicJt41ajRYvdIo=\\\"}}, \\\"active_index\\\": \\\"r1\\\", \\\"keys_partition\\\": \\\"server_id_310\\\"}\",\"server_info_json\":null,\"server_ipmi_credentials_need_update\":false,\"server_gpu_count\":0,\"server_gpu_vendor\":\"\",\"server_gpu_model\":\"\",\"server_bmc_mac_address\":null,\"server_metrics_metadata_json\":null,\"server_interfaces\":[{\"server_interface_mac_address\":\"00:1d:09:64:f0:2b\",\"type\":\"ServerInterface\"},{\"server_interface_mac_address\":\"00:1d:09:64:f0:2d\",\"type\":\"ServerInterface\"},{\"server_interface_mac_address\":\"00:15:17:c0:4c:e6\",\"type\":\"ServerInterface\"},{\"server_interface_mac_address\":\"00:15:17:c0:4c:e7\",\"type\":\"ServerInterface\"}],\"server_disks\":[],\"server_tags\":[],\"type\":\"Server\"}"
 const _serverListFixture1 = "[\n                {\n                    \"server_id\": 16,\n                    \"server_type_name\": null,\n                    \"server_type_boot_type\": null,\n                    \"server_product_name\": null,\n                    \"datacenter_name\": \"us02-chi-qts01-dc\",\n                    \"server_status\": \"registering\",\n                    \"server_class\": \"bigdata\",\n                    \"server_created_timestamp\": \"2022-05-23T13:22:11Z\",\n                    \"server_vendor\": \"Dell Inc.\",\n                    \"server_serial_number\": null,\n                    \"server_uuid\": \"4c4c4544-0051-3810-8057-b7c04f533532\",\n                    \"server_vendor_sku_id\": null,\n                    \"server_boot_type\": \"classic\",\n                    \"server_allocation_timestamp\": null,\n                    \"instance_label\": [\n                        null\n                    ],\n                    \"instance_id\": [\n                        null\n                    ],\n                    \"instance_array_id\": [\n                        null\n                    ],\n                    \"infrastructure_id\": [\n                        null\n                    ],\n                    \"server_inventory_id\": null,\n                    \"server_rack_name\": null,\n                    \"server_rack_position_lower_unit\": null,\n                    \"server_rack_position_upper_unit\": null,\n                    \"server_ipmi_host\": \"172.18.44.42\",\n                    \"server_ipmi_internal_username\": \"root\",\n                    \"server_processor_name\": null,\n                    \"server_processor_count\": 0,\n                    \"server_processor_core_count\": 0,\n                    \"server_processor_core_mhz\": 0,\n                    \"server_processor_threads\": null,\n                    \"server_processor_cpu_mark\": null,\n                    \"server_disk_type\": \"none\",\n                    \"server_disk_count\": 0,\n                    \"server_disk_size_mbytes\": 0,\n                    \"server_ram_gbytes\": 0,\n                    \"server_network_total_capacity_mbps\": 0,\n                    \"server_dhcp_status\": \"quarantine\",\n                    \"server_dhcp_packet_sniffing_is_enabled\": true,\n                    \"server_dhcp_relay_security_is_enabled\": true,\n                    \"server_disk_wipe\": false,\n                    \"server_power_status\": \"off\",\n                    \"server_power_status_last_update_timestamp\": \"2022-05-23T13:24:41Z\",\n                    \"user_id\": [\n                        [\n                            null\n                        ]\n                    ],\n                    \"user_id_owner\": [\n                        null\n                    ],\n                    \"user_email\": [\n                        [\n                            null\n                        ]\n                    ],\n                    \"infrastructure_user_id\": [\n                        [\n                            null\n                        ]\n                    ]\n                }\n            ]"
 const _serverFixture2 = "{\n        \"server_id\": 16,\n        \"agent_id\": null,\n        \"datacenter_name\": \"us02-chi-qts01-dc\",\n        \"server_uuid\": \"4c4c4544-0051-3810-8057-b7c04f533532\",\n        \"server_serial_number\": null,\n        \"server_product_name\": null,\n        \"server_vendor\": \"Dell Inc.\",\n        \"server_vendor_sku_id\": null,\n        \"server_ipmi_host\": \"172.18.44.42\",\n        \"server_ipmi_internal_username\": \"root\",\n        \"server_ipmi_internal_password\": \"testcccc\",\n        \"server_ipmi_version\": \"2\",\n        \"server_ram_gbytes\": 0,\n        \"server_processor_count\": 0,\n        \"server_processor_core_mhz\": 0,\n        \"server_processor_core_count\": 0,\n        \"server_processor_name\": null,\n        \"server_processor_cpu_mark\": null,\n        \"server_processor_threads\": null,\n        \"server_type_id\": null,\n        \"server_status\": \"registering\",\n        \"server_comments\": null,\n        \"server_details_xml\": null,\n        \"server_network_total_capacity_mbps\": 0,\n        \"server_ipmi_channel\": 1,\n        \"server_power_status\": \"off\",\n        \"server_power_status_last_update_timestamp\": \"2022-05-23T13:24:41Z\",\n        \"server_ilo_reset_timestamp\": \"0000-00-00T00:00:00Z\",\n        \"server_boot_last_update_timestamp\": \"0000-00-00T00:00:00Z\",\n        \"server_bdk_debug\": false,\n        \"server_dhcp_status\": \"quarantine\",\n        \"server_bios_info_json\": null,\n        \"server_vendor_info_json\": null,\n        \"server_class\": \"bigdata\",\n        \"server_created_timestamp\": \"2022-05-23T13:22:11Z\",\n        \"subnet_oob_id\": 5,\n        \"subnet_oob_index\": 42,\n        \"server_boot_type\": \"classic\",\n        \"server_disk_wipe\": false,\n        \"server_disk_count\": 0,\n        \"server_disk_size_mbytes\": 0,\n        \"server_disk_type\": \"none\",\n        \"server_requires_manual_cleaning\": false,\n        \"chassis_rack_id\": null,\n        \"server_custom_json\": null,\n        \"server_instance_custom_json\": null,\n        \"server_last_cleanup_start\": null,\n        \"server_allocation_timestamp\": null,\n        \"server_dhcp_packet_sniffing_is_enabled\": true,\n        \"snmp_community_password_dcencrypted\": null,\n        \"server_mgmt_snmp_community_password_dcencrypted\": null,\n        \"server_mgmt_snmp_port\": 161,\n        \"server_mgmt_snmp_version\": 2,\n        \"server_dhcp_relay_security_is_enabled\": true,\n        \"server_keys_json\": null,\n        \"server_info_json\": null,\n        \"server_ipmi_credentials_need_update\": false,\n        \"server_gpu_count\": 0,\n        \"server_gpu_vendor\": null,\n        \"server_gpu_model\": null,\n        \"server_bmc_mac_address\": null,\n        \"server_metrics_metadata_json\": null,\n        \"server_secure_boot_is_enabled\": false,\n        \"server_chipset_name\": null,\n        \"server_requires_reregister\": false,\n        \"server_rack_name\": null,\n        \"server_rack_position_upper_unit\": null,\n        \"server_rack_position_lower_unit\": null,\n        \"server_inventory_id\": null,\n        \"server_registered_timestamp\": \"0000-00-00T00:00:00Z\",\n        \"server_interfaces\": [],\n        \"server_disks\": [],\n        \"server_tags\": [],\n        \"type\": \"Server\"\n    }"
+
+func TestServerRegisterBulkCmd(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	var lock sync.Mutex
+	registered := map[string]int{}
+	running, maxRunning := 0, 0
+
+	client.EXPECT().
+		ServerCreateAndRegister(gomock.Any()).
+		DoAndReturn(func(s metalcloud.ServerCreateAndRegister) (int, error) {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			lock.Lock()
+			defer lock.Unlock()
+			running--
+
+			if s.ServerManagementPassword == "wrong" {
+				return 0, fmt.Errorf("authentication failed")
+			}
+
+			registered[s.ServerManagementAddress]++
+			return 100 + len(registered), nil
+		}).
+		AnyTimes()
+
+	f, err := ioutil.TempFile("./", "testservers-*.csv")
+	if err != nil {
+		t.Error(err)
+	}
+
+	rows := []string{"datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass"}
+	for i := 1; i <= 20; i++ {
+		pass := "calvin"
+		if i == 7 {
+			pass = "wrong"
+		}
+		rows = append(rows, fmt.Sprintf("dc1,dell,10.0.0.%d,root,%s", i, pass))
+	}
+
+	f.WriteString(strings.Join(rows, "\n") + "\n")
+	f.Close()
+	defer syscall.Unlink(f.Name())
+
+	resultsFile := f.Name() + ".results.csv"
+	defer syscall.Unlink(resultsFile)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"read_config_from_file": f.Name(),
+		"concurrency":           4,
+		"format":                "json",
+	})
+
+	ret, err := serverRegisterBulkCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(20))
+	Expect(m[6].(map[string]interface{})["RESULT"]).To(Equal("failed"))
+	Expect(m[6].(map[string]interface{})["ERROR"]).To(Equal("authentication failed"))
+	Expect(registered).To(HaveLen(19))
+	Expect(maxRunning).To(BeNumerically("<=", 4))
+
+	content, err := ioutil.ReadFile(resultsFile)
+	Expect(err).To(BeNil())
+
+	results, err := parseServerRegistrationResults(content)
+	Expect(err).To(BeNil())
+	Expect(results).To(HaveLen(20))
+
+	//the results file is not overwritten without --resume
+	_, err = serverRegisterBulkCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	//after fixing the password only the failed row is registered
+	rows[7] = "dc1,dell,10.0.0.7,root,calvin"
+	Expect(ioutil.WriteFile(f.Name(), []byte(strings.Join(rows, "\n")+"\n"), 0600)).To(BeNil())
+
+	cmd.Arguments["resume"] = &[]bool{true}[0]
+
+	ret, err = serverRegisterBulkCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(20))
+	Expect(m[0].(map[string]interface{})["RESULT"]).To(Equal("skipped"))
+	Expect(m[6].(map[string]interface{})["RESULT"]).To(Equal("registered"))
+
+	Expect(registered).To(HaveLen(20))
+	for address, count := range registered {
+		if count != 1 {
+			t.Errorf("server %s registered %d times", address, count)
+		}
+	}
+
+	content, err = ioutil.ReadFile(resultsFile)
+	Expect(err).To(BeNil())
+
+	results, err = parseServerRegistrationResults(content)
+	Expect(err).To(BeNil())
+	Expect(results).To(HaveLen(20))
+	for _, r := range results {
+		Expect(r.serverID).NotTo(Equal(0))
+	}
+
+	cmd = MakeCommand(map[string]interface{}{
+		"read_config_from_file": f.Name(),
+		"concurrency":           0,
+	})
+
+	_, err = serverRegisterBulkCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestServerComponentsCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := map[int]metalcloud.Server{
+		100: {
+			ServerID:           100,
+			ServerSerialNumber: "SN100",
+			ServerDisks: []metalcloud.ServerDisk{
+				{ServerDiskID: 1, ServerDiskType: "SSD", ServerDiskSizeGB: 960, ServerDiskVendor: "Samsung", ServerDiskModel: "PM883", ServerDiskSerial: "S1"},
+			},
+			ServerInterfaces: []metalcloud.ServerInterface{
+				{ServerInterfaceMACAddress: "aa:aa:aa:aa:aa:01"},
+			},
+			NICDetails: map[string]metalcloud.ServerNICDetails{
+				"eth0": {ServerInterfaceMACAddress: "AA:AA:AA:AA:AA:01", ServerInterfaceCapacityMBPs: 25000},
+			},
+		},
+		101: {
+			ServerID:           101,
+			ServerSerialNumber: "SN101",
+			ServerDisks: []metalcloud.ServerDisk{
+				{ServerDiskID: 2, ServerDiskType: "SSD", ServerDiskSizeGB: 960, ServerDiskVendor: "Micron", ServerDiskModel: "5300", ServerDiskSerial: "S2"},
+			},
+			ServerInterfaces: []metalcloud.ServerInterface{
+				{ServerInterfaceMACAddress: "bb:bb:bb:bb:bb:01"},
+			},
+			NICDetails: map[string]metalcloud.ServerNICDetails{
+				"eth0": {ServerInterfaceMACAddress: "bb:bb:bb:bb:bb:01", ServerInterfaceCapacityMBPs: 10000},
+			},
+		},
+	}
+
+	components := map[int][]metalcloud.ServerComponent{
+		100: {
+			{ServerComponentID: 1001, ServerComponentType: "nic", ServerComponentName: "NIC.Slot.1", ServerComponentFirmwareVersion: "16.28", ServerComponentFirmwareJSON: `{"Manufacturer":"Mellanox","Model":"ConnectX-5","SerialNumber":"MT1"}`},
+		},
+		101: {
+			{ServerComponentID: 1011, ServerComponentType: "nic", ServerComponentName: "NIC.Slot.1", ServerComponentFirmwareVersion: "16.28", ServerComponentFirmwareJSON: `{"Manufacturer":"Mellanox","Model":"ConnectX-5","SerialNumber":"MT2"}`},
+			{ServerComponentID: 1012, ServerComponentType: "psu", ServerComponentName: "PSU.Slot.1", ServerComponentFirmwareVersion: "00.1D.7D"},
+		},
+	}
+
+	for id := range servers {
+		server := servers[id]
+		list := components[id]
+		client.EXPECT().ServerGet(id, false).Return(&server, nil).AnyTimes()
+		client.EXPECT().ServerComponents(id, "").Return(&list, nil).AnyTimes()
+	}
+
+	expectedFirstRow := map[string]interface{}{
+		"ID":     1,
+		"TYPE":   "disk",
+		"NAME":   "SSD 960 GB",
+		"VENDOR": "Samsung",
+		"SERIAL": "S1",
+	}
+
+	cmd := MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+	})
+
+	testListCommand(serverComponentsListCmd, &cmd, client, expectedFirstRow, t)
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+		"type":              "NIC",
+		"format":            "json",
+	})
+
+	ret, err := serverComponentsListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(JSONFirstRowEquals(ret, map[string]interface{}{
+		"VENDOR": "Mellanox",
+		"MODEL":  "ConnectX-5",
+		"SERIAL": "MT1",
+	})).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+		"type":              "interface",
+		"format":            "json",
+	})
+
+	ret, err = serverComponentsListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(JSONFirstRowEquals(ret, map[string]interface{}{
+		"NAME":   "interface 0",
+		"MODEL":  "25 Gbps",
+		"SERIAL": "aa:aa:aa:aa:aa:01",
+	})).To(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid":  100,
+		"server_id_or_uuid2": 101,
+		"format":             "json",
+	})
+
+	ret, err = serverComponentsDiffCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(4))
+
+	r := m[1].(map[string]interface{})
+	Expect(r["STATUS"]).To(Equal("different"))
+	Expect(r["PROPERTY"]).To(Equal("model"))
+	Expect(r["FIRST"]).To(Equal("PM883"))
+	Expect(r["SECOND"]).To(Equal("5300"))
+
+	r = m[2].(map[string]interface{})
+	Expect(r["TYPE"]).To(Equal("interface"))
+	Expect(r["FIRST"]).To(Equal("25 Gbps"))
+	Expect(r["SECOND"]).To(Equal("10 Gbps"))
+
+	r = m[3].(map[string]interface{})
+	Expect(r["STATUS"]).To(Equal("extra"))
+	Expect(r["TYPE"]).To(Equal("psu"))
+
+	cmd.Arguments["show_same"] = &[]bool{true}[0]
+
+	ret, err = serverComponentsDiffCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(5))
+
+	//the disk differs in two properties but is counted once
+	cmd.Arguments["format"] = &[]string{""}[0]
+
+	ret, err = serverComponentsDiffCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("2 different, 0 missing, 1 extra components"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid":  100,
+		"server_id_or_uuid2": 100,
+	})
+
+	_, err = serverComponentsDiffCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestServerDecommissionCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 3, ServerSerialNumber: "SN3", ServerStatus: "decommissioned"},
+		{ServerID: 1, ServerSerialNumber: "SN1", ServerStatus: "available"},
+		{ServerID: 2, ServerSerialNumber: "SN2", ServerStatus: "used", InstanceID: []int{200}, InstanceLabel: []string{"instance-200"}},
+	}
+
+	for _, s := range servers {
+		server := metalcloud.Server{ServerID: s.ServerID, ServerSerialNumber: s.ServerSerialNumber}
+		list := []metalcloud.ServerSearchResult{s}
+		client.EXPECT().ServerGet(s.ServerID, false).Return(&server, nil).AnyTimes()
+		client.EXPECT().ServersSearch(fmt.Sprintf("+server_id:%d", s.ServerID)).Return(&list, nil).AnyTimes()
+	}
+
+	client.EXPECT().
+		ServersSearch("removed_from_rack").
+		Return(&servers, nil).
+		AnyTimes()
+
+	//the filter is converted as in server list
+	client.EXPECT().
+		ServersSearch("+status:available").
+		Return(&[]metalcloud.ServerSearchResult{servers[1]}, nil).
+		AnyTimes()
+
+	interfaces := []metalcloud.SwitchInterfaceSearchResult{
+		{ServerID: 1, ServerInterfaceIndex: 0, NetworkEquipmentID: 10, NetworkEquipmentIdentifierString: "leaf01", NetworkEquipmentInterfaceIdentifierString: "Ethernet1/1"},
+	}
+
+	client.EXPECT().
+		SwitchInterfaceSearch("server_id:1").
+		Return(&interfaces, nil).
+		AnyTimes()
+
+	//after typing the serial number, with -autoconfirm and in the two confirmed batches
+	client.EXPECT().
+		ServerDecomission(1, false).
+		Return(nil).
+		Times(4)
+
+	var stdin bytes.Buffer
+	var stdout bytes.Buffer
+	SetConsoleIOChannel(&stdin, &stdout)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 1,
+	})
+
+	stdin.WriteString("SN2\n")
+	_, err := serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	stdin.Reset()
+	stdin.WriteString("SN1\n")
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//the switch interfaces are shown even if not asked to confirm
+	cmd.Arguments["autoconfirm"] = &[]bool{true}[0]
+
+	ret, err := serverDecommissionCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("leaf01 (#10) Ethernet1/1"))
+
+	//allocated to an instance
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 2,
+		"autoconfirm":       true,
+	})
+
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("instance-200"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"filter":      "removed_from_rack",
+		"autoconfirm": true,
+		"format":      "json",
+	})
+
+	ret, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(3))
+
+	results := map[int]string{}
+	for _, r := range m {
+		row := r.(map[string]interface{})
+		results[int(row["ID"].(float64))] = row["RESULT"].(string)
+	}
+	Expect(results).To(Equal(map[int]string{1: "decommissioned", 2: "skipped", 3: "skipped"}))
+	Expect(m[0].(map[string]interface{})["SWITCH INTERFACES"]).To(Equal("leaf01 Ethernet1/1"))
+
+	//a batch is confirmed once by typing the number of servers that will be removed
+	cmd = MakeCommand(map[string]interface{}{
+		"filter": "status:available",
+		"format": "json",
+	})
+
+	stdin.Reset()
+	stdin.WriteString("yes\n")
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	stdin.Reset()
+	stdin.WriteString("2\n")
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	stdin.Reset()
+	stdin.WriteString("1\n")
+	ret, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(1))
+	Expect(m[0].(map[string]interface{})["RESULT"]).To(Equal("decommissioned"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 1,
+		"filter":            "removed_from_rack",
+	})
+
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd = MakeCommand(map[string]interface{}{})
+
+	_, err = serverDecommissionCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestServerDeleteCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 3, ServerSerialNumber: "SN3", ServerStatus: "decommissioned"},
+		{ServerID: 2, ServerSerialNumber: "SN2", ServerStatus: "used", InstanceID: []int{200}},
+	}
+
+	client.EXPECT().
+		ServersSearch("SN2 SN3").
+		Return(&servers, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchInterfaceSearch("server_id:3").
+		Return(&[]metalcloud.SwitchInterfaceSearchResult{}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerDelete(3, true).
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"filter":      "SN2 SN3",
+		"skip_ipmi":   true,
+		"autoconfirm": true,
+		"format":      "json",
+	})
+
+	ret, err := serverDeleteCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	r := m[0].(map[string]interface{})
+	Expect(r["RESULT"]).To(Equal("skipped"))
+	Expect(r["REASON"]).To(Equal("allocated to instance #200"))
+
+	r = m[1].(map[string]interface{})
+	Expect(r["RESULT"]).To(Equal("deleted"))
+}
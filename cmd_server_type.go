@@ -0,0 +1,462 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+var serverTypeCmds = []Command{
+
+	{
+		Description:  "Lists server types.",
+		Subject:      "server-type",
+		AltSubject:   "srv-type",
+		Predicate:    "list",
+		AltPredicate: "ls",
+		FlagSet:      flag.NewFlagSet("list server types", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"datacenter": c.FlagSet.String("datacenter", _nilDefaultStr, "The optional parameter acts as a filter that restricts the returned results to server types of the specified datacenter."),
+				"format":     tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverTypeListCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Get server type.",
+		Subject:      "server-type",
+		AltSubject:   "srv-type",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("get server type", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_type": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Server type's id or label."),
+				"format":      outputFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"raw":         c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
+			}
+		},
+		ExecuteFunc: serverTypeGetCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Match a server's hardware against server types.",
+		Subject:      "server-type",
+		AltSubject:   "srv-type",
+		Predicate:    "match",
+		AltPredicate: "explain",
+		FlagSet:      flag.NewFlagSet("match server hardware against server types", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"server_id_or_uuid": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Server's ID or UUID"),
+				"server_type":       c.FlagSet.String("server-type", _nilDefaultStr, "The server type (id or label) to compare the server's hardware with. Defaults to the server's type."),
+				"format":            tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: serverTypeMatchCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+#lists the server types matching the hardware of the server and compares it with the server's type:
+metalcloud-cli server-type match --id 100
+`,
+	},
+}
+
+//serverTypeCount is the number of servers of a type in a datacenter
+type serverTypeCount struct {
+	available int
+	total     int
+}
+
+//getServerTypeCounts returns the number of servers matching the filter per server type name and datacenter.
+//Decommissioned servers are not counted.
+func getServerTypeCounts(filter string, client metalcloud.MetalCloudClient) (map[string]map[string]serverTypeCount, error) {
+	list, err := client.ServersSearch(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]map[string]serverTypeCount{}
+	for _, s := range *list {
+		if s.ServerStatus == "decommissioned" {
+			continue
+		}
+
+		if counts[s.ServerTypeName] == nil {
+			counts[s.ServerTypeName] = map[string]serverTypeCount{}
+		}
+
+		count := counts[s.ServerTypeName][s.DatacenterName]
+		count.total++
+		if s.ServerStatus == "available" {
+			count.available++
+		}
+		counts[s.ServerTypeName][s.DatacenterName] = count
+	}
+
+	return counts, nil
+}
+
+func serverTypeListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	datacenter, datacenterOk := getStringParamOk(c.Arguments["datacenter"])
+
+	var types *map[int]metalcloud.ServerType
+	var counts map[string]map[string]serverTypeCount
+	var err error
+
+	if datacenterOk {
+		types, err = client.ServerTypesForDatacenter(datacenter, false)
+		if err != nil {
+			return "", err
+		}
+
+		counts, err = getServerTypeCounts("datacenter_name:"+datacenter, client)
+	} else {
+		types, err = client.ServerTypes(false)
+		if err != nil {
+			return "", err
+		}
+
+		counts, err = getServerTypeCounts("*", client)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "CLASS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "DATACENTER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "AVAILABLE",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "TOTAL",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+	}
+
+	ids := []int{}
+	for id := range *types {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	data := [][]interface{}{}
+	for _, id := range ids {
+		t := (*types)[id]
+
+		datacenters := []string{}
+		for dc := range counts[t.ServerTypeName] {
+			if !datacenterOk || dc == datacenter {
+				datacenters = append(datacenters, dc)
+			}
+		}
+		sort.Strings(datacenters)
+
+		if len(datacenters) == 0 {
+			datacenters = append(datacenters, datacenter)
+		}
+
+		for _, dc := range datacenters {
+			count := counts[t.ServerTypeName][dc]
+
+			data = append(data, []interface{}{
+				t.ServerTypeID,
+				t.ServerTypeLabel,
+				t.ServerTypeName,
+				t.ServerClass,
+				dc,
+				count.available,
+				count.total,
+			})
+		}
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return renderTable(c, table, "Server types", "")
+}
+
+func serverTypeGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	serverType, err := getServerTypeFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	format := getStringParam(c.Arguments["format"])
+
+	if getBoolParam(c.Arguments["raw"]) {
+		return tableformatter.RenderRawObject(*serverType, format, "ServerType")
+	}
+
+	counts, err := getServerTypeCounts("*", client)
+	if err != nil {
+		return "", err
+	}
+
+	datacenters := []string{}
+	for dc := range counts[serverType.ServerTypeName] {
+		datacenters = append(datacenters, dc)
+	}
+	sort.Strings(datacenters)
+
+	availability := []string{}
+	for _, dc := range datacenters {
+		count := counts[serverType.ServerTypeName][dc]
+		availability = append(availability, fmt.Sprintf("%s: %d of %d", dc, count.available, count.total))
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "DISPLAY NAME",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "CLASS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "PROCESSOR",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "CPU",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "RAM",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "DISKS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "NETWORK",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "OOB PROVISIONING",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "EXPERIMENTAL",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "AVAILABLE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	data := [][]interface{}{{
+		serverType.ServerTypeID,
+		serverType.ServerTypeLabel,
+		serverType.ServerTypeName,
+		serverType.ServerTypeDisplayName,
+		serverType.ServerClass,
+		serverType.ServerProcessorName,
+		fmt.Sprintf("%d x %d cores @ %d MHz", serverType.ServerProcessorCount, serverType.ServerProcessorCoreCount, serverType.ServerProcessorCoreMHz),
+		fmt.Sprintf("%d GB", serverType.ServerRAMGbytes),
+		fmt.Sprintf("%d x %d GB %s", serverType.ServerDiskCount, serverType.ServerDiskSizeMBytes/1024, serverType.ServerDiskType),
+		fmt.Sprintf("%d Gbps", int(serverType.ServerNetworkTotalCapacityMBps/1000)),
+		serverType.ServerTypeSupportsOOBProvisioning,
+		serverType.ServerTypeIsExperimental,
+		strings.Join(availability, ", "),
+	}}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable("server type", "", format)
+}
+
+//serverTypeHardwareProperty is a hardware property of a server and the value required by a server type
+type serverTypeHardwareProperty struct {
+	name       string
+	server     string
+	serverType string
+}
+
+//getServerTypeHardwareProperties returns the hardware properties of the server next to those of the server type
+func getServerTypeHardwareProperties(server *metalcloud.Server, serverType *metalcloud.ServerType) []serverTypeHardwareProperty {
+	return []serverTypeHardwareProperty{
+		{"class", server.ServerClass, serverType.ServerClass},
+		{"processor count", fmt.Sprintf("%d", server.ServerProcessorCount), fmt.Sprintf("%d", serverType.ServerProcessorCount)},
+		{"processor cores", fmt.Sprintf("%d", server.ServerProcessorCoreCount), fmt.Sprintf("%d", serverType.ServerProcessorCoreCount)},
+		{"processor MHz", fmt.Sprintf("%d", server.ServerProcessorCoreMhz), fmt.Sprintf("%d", serverType.ServerProcessorCoreMHz)},
+		{"RAM GB", fmt.Sprintf("%d", server.ServerRAMGbytes), fmt.Sprintf("%d", serverType.ServerRAMGbytes)},
+		{"disk count", fmt.Sprintf("%d", server.ServerDiskCount), fmt.Sprintf("%d", serverType.ServerDiskCount)},
+		{"disk size MB", fmt.Sprintf("%d", server.ServerDiskSizeMbytes), fmt.Sprintf("%d", serverType.ServerDiskSizeMBytes)},
+		{"disk type", server.ServerDiskType, serverType.ServerDiskType},
+		{"network Mbps", fmt.Sprintf("%d", server.ServerNetworkTotalCapacityMbps), fmt.Sprintf("%d", serverType.ServerNetworkTotalCapacityMBps)},
+	}
+}
+
+func serverTypeMatchCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	server, err := getServerFromCommand("id", c, client, false)
+	if err != nil {
+		return "", err
+	}
+
+	var serverType *metalcloud.ServerType
+	if _, err := getParam(c, "server_type", "server-type"); err == nil {
+		serverType, err = getServerTypeFromCommand("server-type", c, client)
+		if err != nil {
+			return "", err
+		}
+	} else if server.ServerTypeID != 0 {
+		serverType, err = client.ServerTypeGet(server.ServerTypeID)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		return "", fmt.Errorf("server #%d has no server type. Use -server-type to compare it with a server type", server.ServerID)
+	}
+
+	hardwareConfiguration := metalcloud.HardwareConfiguration{
+		InstanceArrayRAMGbytes:          server.ServerRAMGbytes,
+		InstanceArrayProcessorCount:     server.ServerProcessorCount,
+		InstanceArrayProcessorCoreMHZ:   server.ServerProcessorCoreMhz,
+		InstanceArrayProcessorCoreCount: server.ServerProcessorCoreCount,
+		InstanceArrayDiskCount:          server.ServerDiskCount,
+		InstanceArrayDiskSizeMBytes:     server.ServerDiskSizeMbytes,
+	}
+
+	if server.ServerDiskType != "" {
+		hardwareConfiguration.InstanceArrayDiskTypes = []string{server.ServerDiskType}
+	}
+
+	matches, err := client.ServerTypesMatchHardwareConfiguration(server.DatacenterName, hardwareConfiguration)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []int{}
+	for id := range *matches {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	matching := []string{}
+	for _, id := range ids {
+		matching = append(matching, fmt.Sprintf("%s (#%d)", (*matches)[id].ServerTypeLabel, id))
+	}
+
+	if len(matching) == 0 {
+		matching = append(matching, "none")
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "PROPERTY",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "SERVER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "SERVER TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "MATCH",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+	}
+
+	data := [][]interface{}{}
+	for _, p := range getServerTypeHardwareProperties(server, serverType) {
+		data = append(data, []interface{}{
+			p.name,
+			p.server,
+			p.serverType,
+			strings.EqualFold(p.server, p.serverType),
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Server %s (#%d) compared to server type %s (#%d). Server types matching its hardware in datacenter %s: %s",
+		server.ServerSerialNumber,
+		server.ServerID,
+		serverType.ServerTypeLabel,
+		serverType.ServerTypeID,
+		server.DatacenterName,
+		strings.Join(matching, ", "))
+
+	return renderTable(c, table, "Hardware", topLine)
+}
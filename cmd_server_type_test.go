@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestServerTypeListCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	types := map[int]metalcloud.ServerType{
+		10: {ServerTypeID: 10, ServerTypeLabel: "m-4-64", ServerTypeName: "M.4.64", ServerClass: "bigdata"},
+		11: {ServerTypeID: 11, ServerTypeLabel: "m-8-128", ServerTypeName: "M.8.128", ServerClass: "bigdata"},
+	}
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 1, ServerTypeName: "M.4.64", DatacenterName: "dc1", ServerStatus: "available"},
+		{ServerID: 2, ServerTypeName: "M.4.64", DatacenterName: "dc1", ServerStatus: "used"},
+		{ServerID: 3, ServerTypeName: "M.4.64", DatacenterName: "dc1", ServerStatus: "decommissioned"},
+		{ServerID: 4, ServerTypeName: "M.4.64", DatacenterName: "dc2", ServerStatus: "available"},
+	}
+
+	dcServers := servers[:3]
+
+	client.EXPECT().
+		ServerTypes(false).
+		Return(&types, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerTypesForDatacenter("dc1", false).
+		Return(&types, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServersSearch("*").
+		Return(&servers, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServersSearch("datacenter_name:dc1").
+		Return(&dcServers, nil).
+		AnyTimes()
+
+	expectedFirstRow := map[string]interface{}{
+		"ID":         10,
+		"LABEL":      "m-4-64",
+		"DATACENTER": "dc1",
+		"AVAILABLE":  1,
+		"TOTAL":      2,
+	}
+
+	testListCommand(serverTypeListCmd, nil, client, expectedFirstRow, t)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"format": "json",
+	})
+
+	ret, err := serverTypeListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(3))
+
+	row := m[2].(map[string]interface{})
+	Expect(row["LABEL"]).To(Equal("m-8-128"))
+	Expect(row["DATACENTER"]).To(Equal(""))
+	Expect(row["TOTAL"]).To(Equal(0.0))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"datacenter": "dc1",
+		"format":     "json",
+	})
+
+	ret, err = serverTypeListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	row = m[1].(map[string]interface{})
+	Expect(row["LABEL"]).To(Equal("m-8-128"))
+	Expect(row["DATACENTER"]).To(Equal("dc1"))
+}
+
+func TestServerTypeGetCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	serverType := metalcloud.ServerType{
+		ServerTypeID:                   10,
+		ServerTypeLabel:                "m-4-64",
+		ServerTypeName:                 "M.4.64",
+		ServerProcessorCount:           2,
+		ServerProcessorCoreCount:       8,
+		ServerProcessorCoreMHz:         2400,
+		ServerRAMGbytes:                64,
+		ServerDiskCount:                2,
+		ServerDiskSizeMBytes:           983040,
+		ServerDiskType:                 "SSD",
+		ServerNetworkTotalCapacityMBps: 40000,
+	}
+
+	servers := []metalcloud.ServerSearchResult{
+		{ServerID: 1, ServerTypeName: "M.4.64", DatacenterName: "dc1", ServerStatus: "available"},
+		{ServerID: 2, ServerTypeName: "M.4.64", DatacenterName: "dc1", ServerStatus: "used"},
+		{ServerID: 4, ServerTypeName: "M.4.64", DatacenterName: "dc2", ServerStatus: "available"},
+		{ServerID: 5, ServerTypeName: "M.8.128", DatacenterName: "dc2", ServerStatus: "available"},
+	}
+
+	client.EXPECT().
+		ServerTypeGet(10).
+		Return(&serverType, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerTypeGetByLabel("m-4-64").
+		Return(&serverType, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServersSearch("*").
+		Return(&servers, nil).
+		AnyTimes()
+
+	cases := []CommandTestCase{
+		{
+			name: "get-id",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_type": 10,
+				"format":      "json",
+			}),
+			good: true,
+		},
+		{
+			name: "get-label",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_type": "m-4-64",
+				"format":      "json",
+			}),
+			good: true,
+		},
+		{
+			name: "get-raw",
+			cmd: MakeCommand(map[string]interface{}{
+				"server_type": 10,
+				"raw":         true,
+				"format":      "json",
+			}),
+			good: true,
+		},
+		{
+			name: "no id",
+			cmd:  MakeCommand(map[string]interface{}{}),
+			good: false,
+		},
+	}
+
+	expectedFirstRow := map[string]interface{}{
+		"ID":    10,
+		"LABEL": "m-4-64",
+	}
+
+	testGetCommand(serverTypeGetCmd, cases, client, expectedFirstRow, t)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"server_type": 10,
+		"format":      "json",
+	})
+
+	ret, err := serverTypeGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(JSONFirstRowEquals(ret, map[string]interface{}{
+		"CPU":       "2 x 8 cores @ 2400 MHz",
+		"DISKS":     "2 x 960 GB SSD",
+		"NETWORK":   "40 Gbps",
+		"AVAILABLE": "dc1: 1 of 2, dc2: 1 of 1",
+	})).To(BeNil())
+}
+
+func TestServerTypeMatchCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	server := metalcloud.Server{
+		ServerID:                 100,
+		ServerSerialNumber:       "SN100",
+		ServerTypeID:             10,
+		DatacenterName:           "dc1",
+		ServerProcessorCount:     2,
+		ServerProcessorCoreCount: 8,
+		ServerProcessorCoreMhz:   2400,
+		ServerRAMGbytes:          64,
+		ServerDiskCount:          2,
+		ServerDiskSizeMbytes:     983040,
+		ServerDiskType:           "SSD",
+	}
+
+	serverType := metalcloud.ServerType{
+		ServerTypeID:             10,
+		ServerTypeLabel:          "m-4-64",
+		ServerProcessorCount:     2,
+		ServerProcessorCoreCount: 8,
+		ServerProcessorCoreMHz:   2400,
+		ServerRAMGbytes:          128,
+		ServerDiskCount:          2,
+		ServerDiskSizeMBytes:     983040,
+		ServerDiskType:           "ssd",
+	}
+
+	matches := map[int]metalcloud.ServerType{
+		12: {ServerTypeID: 12, ServerTypeLabel: "m-2-64"},
+	}
+
+	hardwareConfiguration := metalcloud.HardwareConfiguration{
+		InstanceArrayRAMGbytes:          64,
+		InstanceArrayProcessorCount:     2,
+		InstanceArrayProcessorCoreMHZ:   2400,
+		InstanceArrayProcessorCoreCount: 8,
+		InstanceArrayDiskCount:          2,
+		InstanceArrayDiskSizeMBytes:     983040,
+		InstanceArrayDiskTypes:          []string{"SSD"},
+	}
+
+	client.EXPECT().
+		ServerGet(100, false).
+		Return(&server, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerTypeGet(10).
+		Return(&serverType, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		ServerTypesMatchHardwareConfiguration("dc1", hardwareConfiguration).
+		Return(&matches, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+		"format":            "json",
+	})
+
+	ret, err := serverTypeMatchCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	mismatches := []string{}
+	for _, r := range m {
+		row := r.(map[string]interface{})
+		if row["MATCH"] == false {
+			mismatches = append(mismatches, row["PROPERTY"].(string))
+		}
+	}
+	Expect(mismatches).To(Equal([]string{"RAM GB"}))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"server_id_or_uuid": 100,
+	})
+
+	ret, err = serverTypeMatchCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("m-2-64 (#12)"))
+
+	server.ServerTypeID = 0
+
+	_, err = serverTypeMatchCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
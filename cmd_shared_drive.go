@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 
 	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
@@ -20,12 +21,124 @@ var sharedDriveCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"infrastructure_id_or_label": c.FlagSet.String("infra", _nilDefaultStr, red("(Required)")+" Infrastructure's id or label. Note that the 'label' this be ambiguous in certain situations."),
-				"format":                     c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                     tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: sharedDriveListCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Creates a shared drive.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "create",
+		AltPredicate: "new",
+		FlagSet:      flag.NewFlagSet("create shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"infrastructure_id_or_label": c.FlagSet.String("infra", _nilDefaultStr, red("(Required)")+" Infrastructure's id or label. Note that the 'label' this be ambiguous in certain situations."),
+				"shared_drive_label":         c.FlagSet.String("label", _nilDefaultStr, red("(Required)")+" The label of the shared drive"),
+				"shared_drive_storage_type":  c.FlagSet.String("type", _nilDefaultStr, "Possible values: "+strings.Join(storageTypes, ", ")),
+				"shared_drive_size_mbytes":   c.FlagSet.Int("size", _nilDefaultInt, "(Optional, default = 2048) Shared drive's size in MBytes"),
+				"shared_drive_has_gfs":       c.FlagSet.Bool("gfs", false, green("(Flag)")+" If set the shared drive is formatted with the GFS2 clustered file system"),
+				"shared_drive_io_limit":      c.FlagSet.String("io-limit", _nilDefaultStr, "The IO limit policy of the shared drive"),
+				"return_id":                  c.FlagSet.Bool("return-id", false, "(Optional) Will print the ID of the created shared drive. Useful for automating tasks."),
+			}
+		},
+		ExecuteFunc: sharedDriveCreateCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli shared-drive create --infra my-infra --label gfs-data --type iscsi_ssd --size 102400 --gfs
+metalcloud-cli shared-drive attach --id gfs-data --ia workers
+`,
+	},
+	{
+		Description:  "Gets a shared drive.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("get shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"shared_drive_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Shared drive's id or label. Note that using the label can be ambiguous and is slower."),
+				"show_iscsi_credentials":   c.FlagSet.Bool("show-iscsi-credentials", false, green("(Flag)")+" If set returns the shared drive's iscsi credentials"),
+				"format":                   outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"raw":                      c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
+			}
+		},
+		ExecuteFunc: sharedDriveGetCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Edit a shared drive.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "edit",
+		AltPredicate: "alter",
+		FlagSet:      flag.NewFlagSet("edit shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"shared_drive_id_or_label":  c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Shared drive's id or label. Note that using the label can be ambiguous and is slower."),
+				"shared_drive_label":        c.FlagSet.String("label", _nilDefaultStr, "The new label of the shared drive"),
+				"shared_drive_storage_type": c.FlagSet.String("type", _nilDefaultStr, "Possible values: "+strings.Join(storageTypes, ", ")),
+				"shared_drive_size_mbytes":  c.FlagSet.Int("size", _nilDefaultInt, "Shared drive's size in MBytes"),
+				"shared_drive_has_gfs":      c.FlagSet.Bool("gfs", false, green("(Flag)")+" If set the shared drive is formatted with the GFS2 clustered file system"),
+				"no_shared_drive_has_gfs":   c.FlagSet.Bool("no-gfs", false, green("(Flag)")+" If set the shared drive is not formatted with the GFS2 clustered file system"),
+				"shared_drive_io_limit":     c.FlagSet.String("io-limit", _nilDefaultStr, "The IO limit policy of the shared drive"),
+			}
+		},
+		ExecuteFunc: sharedDriveEditCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Delete a shared drive.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"shared_drive_id_or_label": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Shared drive's id or label. Note that using the label can be ambiguous and is slower."),
+				"autoconfirm":              c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: sharedDriveDeleteCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Attach a shared drive to an instance array.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "attach",
+		AltPredicate: "connect",
+		FlagSet:      flag.NewFlagSet("attach shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"shared_drive_id_or_label":   c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Shared drive's id or label. Note that using the label can be ambiguous and is slower."),
+				"instance_array_id_or_label": c.FlagSet.String("ia", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+			}
+		},
+		ExecuteFunc: sharedDriveAttachCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Detach a shared drive from an instance array.",
+		Subject:      "shared-drive",
+		AltSubject:   "shared-drives",
+		Predicate:    "detach",
+		AltPredicate: "disconnect",
+		FlagSet:      flag.NewFlagSet("detach shared drive", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"shared_drive_id_or_label":   c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Shared drive's id or label. Note that using the label can be ambiguous and is slower."),
+				"instance_array_id_or_label": c.FlagSet.String("ia", _nilDefaultStr, red("(Required)")+" InstanceArray's id or label. Note that the label can be ambigous."),
+			}
+		},
+		ExecuteFunc: sharedDriveDetachCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
 }
 
 func sharedDriveListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -97,32 +210,15 @@ func sharedDriveListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 
 	data := [][]interface{}{}
 	for _, sd := range *sdList {
-		status := sd.SharedDriveServiceStatus
-
-		if sd.SharedDriveServiceStatus != "ordered" && sd.SharedDriveOperation.SharedDriveServiceStatus == "edit" && sd.SharedDriveOperation.SharedDriveDeployStatus == "not_started" {
-			status = "edited"
-		}
-
-		if sd.SharedDriveServiceStatus != "ordered" && sd.SharedDriveOperation.SharedDriveServiceStatus == "delete" && sd.SharedDriveOperation.SharedDriveDeployStatus == "not_started" {
-			status = "marked for delete"
-		}
-
-		attachedInstanceArrays := []string{}
-
-		for _, instanceArrayID := range sd.SharedDriveAttachedInstanceArrays {
-			ia, err := client.InstanceArrayGet(instanceArrayID)
-			if err != nil {
-				return "", err
-			}
-			attachedInstanceArrays = append(attachedInstanceArrays, fmt.Sprintf("%s (#%d)", ia.InstanceArrayLabel, ia.InstanceArrayID))
+		attachedInstanceArraysList, err := getSharedDriveAttachedInstanceArrays(sd, client)
+		if err != nil {
+			return "", err
 		}
 
-		attachedInstanceArraysList := strings.Join(attachedInstanceArrays, ",")
-
 		data = append(data, []interface{}{
 			sd.SharedDriveID,
 			sd.SharedDriveOperation.SharedDriveLabel,
-			status,
+			getSharedDriveStatus(sd),
 			sd.SharedDriveOperation.SharedDriveSizeMbytes,
 			sd.SharedDriveOperation.SharedDriveStorageType,
 			attachedInstanceArraysList,
@@ -137,5 +233,327 @@ func sharedDriveListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 		Schema: schema,
 	}
 
-	return table.RenderTable("Shared drives", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Shared drives", "")
+}
+
+//getSharedDriveStatus returns the service status of a shared drive taking into account the changes that are not yet deployed
+func getSharedDriveStatus(sd metalcloud.SharedDrive) string {
+	status := sd.SharedDriveServiceStatus
+
+	if sd.SharedDriveServiceStatus != "ordered" && sd.SharedDriveOperation.SharedDriveServiceStatus == "edit" && sd.SharedDriveOperation.SharedDriveDeployStatus == "not_started" {
+		status = "edited"
+	}
+
+	if sd.SharedDriveServiceStatus != "ordered" && sd.SharedDriveOperation.SharedDriveServiceStatus == "delete" && sd.SharedDriveOperation.SharedDriveDeployStatus == "not_started" {
+		status = "marked for delete"
+	}
+
+	return status
+}
+
+//getSharedDriveAttachedInstanceArrays returns the labels and ids of the instance arrays a shared drive is attached to
+func getSharedDriveAttachedInstanceArrays(sd metalcloud.SharedDrive, client metalcloud.MetalCloudClient) (string, error) {
+	attachedInstanceArrays := []string{}
+
+	for _, instanceArrayID := range sd.SharedDriveAttachedInstanceArrays {
+		ia, err := client.InstanceArrayGet(instanceArrayID)
+		if err != nil {
+			return "", err
+		}
+		attachedInstanceArrays = append(attachedInstanceArrays, fmt.Sprintf("%s (#%d)", ia.InstanceArrayLabel, ia.InstanceArrayID))
+	}
+
+	return strings.Join(attachedInstanceArrays, ","), nil
+}
+
+func sharedDriveCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	infra, err := getInfrastructureFromCommand("infra", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	sd := metalcloud.SharedDrive{
+		SharedDriveLabel:         getStringParam(c.Arguments["shared_drive_label"]),
+		SharedDriveStorageType:   getStringParam(c.Arguments["shared_drive_storage_type"]),
+		SharedDriveSizeMbytes:    getIntParam(c.Arguments["shared_drive_size_mbytes"]),
+		SharedDriveHasGFS:        getBoolParam(c.Arguments["shared_drive_has_gfs"]),
+		SharedDriveIOLimitPolicy: getStringParam(c.Arguments["shared_drive_io_limit"]),
+	}
+
+	if sd.SharedDriveLabel == "" {
+		return "", fmt.Errorf("-label is required")
+	}
+
+	if sd.SharedDriveStorageType != "" && !stringInSlice(sd.SharedDriveStorageType, storageTypes) {
+		return "", fmt.Errorf("invalid type %s. Possible values: %s", sd.SharedDriveStorageType, strings.Join(storageTypes, ", "))
+	}
+
+	retSD, err := client.SharedDriveCreate(infra.InfrastructureID, sd)
+	if err != nil {
+		return "", err
+	}
+
+	if getBoolParam(c.Arguments["return_id"]) {
+		return fmt.Sprintf("%d", retSD.SharedDriveID), nil
+	}
+
+	return "", err
+}
+
+func sharedDriveGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retSD, err := getSharedDriveFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	format := getStringParam(c.Arguments["format"])
+
+	if getBoolParam(c.Arguments["raw"]) {
+		return tableformatter.RenderRawObject(*retSD, format, "SharedDrive")
+	}
+
+	attachedInstanceArraysList, err := getSharedDriveAttachedInstanceArrays(*retSD, client)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "LABEL",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "STATUS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "SIZE (MB)",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "GFS",
+			FieldType: tableformatter.TypeBool,
+			FieldSize: 5,
+		},
+		{
+			FieldName: "ATTACHED TO",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 40,
+		},
+		{
+			FieldName: "IO LIMIT",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "WWN",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "SUBDOMAIN",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+	}
+
+	data := [][]interface{}{{
+		retSD.SharedDriveID,
+		retSD.SharedDriveOperation.SharedDriveLabel,
+		getSharedDriveStatus(*retSD),
+		retSD.SharedDriveOperation.SharedDriveSizeMbytes,
+		retSD.SharedDriveOperation.SharedDriveStorageType,
+		retSD.SharedDriveOperation.SharedDriveHasGFS,
+		attachedInstanceArraysList,
+		retSD.SharedDriveIOLimitPolicy,
+		retSD.SharedDriveWWN,
+		retSD.SharedDriveSubdomain,
+	}}
+
+	if getBoolParam(c.Arguments["show_iscsi_credentials"]) {
+		schema = append(schema, tableformatter.SchemaField{
+			FieldName: "CREDENTIALS",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 5,
+		})
+
+		iscsi := retSD.SharedDriveCredentials.ISCSI
+		data[0] = append(data[0], fmt.Sprintf("Target: %s Port:%d IQN:%s LUN ID:%d",
+			iscsi.StorageIPAddress,
+			iscsi.StoragePort,
+			iscsi.TargetIQN,
+			iscsi.LunID))
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable("shared drive", "", format)
+}
+
+func sharedDriveEditCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retSD, err := getSharedDriveFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	sdo := retSD.SharedDriveOperation
+
+	if v, ok := getStringParamOk(c.Arguments["shared_drive_storage_type"]); ok && !stringInSlice(v, storageTypes) {
+		return "", fmt.Errorf("invalid type %s. Possible values: %s", v, strings.Join(storageTypes, ", "))
+	}
+
+	updateIfStringParamSet(c.Arguments["shared_drive_label"], &sdo.SharedDriveLabel)
+	updateIfStringParamSet(c.Arguments["shared_drive_storage_type"], &sdo.SharedDriveStorageType)
+	updateIfIntParamSet(c.Arguments["shared_drive_size_mbytes"], &sdo.SharedDriveSizeMbytes)
+	updateIfStringParamSet(c.Arguments["shared_drive_io_limit"], &sdo.SharedDriveIOLimitPolicy)
+
+	if getBoolParam(c.Arguments["shared_drive_has_gfs"]) && getBoolParam(c.Arguments["no_shared_drive_has_gfs"]) {
+		return "", fmt.Errorf("-gfs and -no-gfs cannot be used together")
+	}
+
+	if getBoolParam(c.Arguments["shared_drive_has_gfs"]) {
+		sdo.SharedDriveHasGFS = true
+	}
+
+	if getBoolParam(c.Arguments["no_shared_drive_has_gfs"]) {
+		sdo.SharedDriveHasGFS = false
+	}
+
+	_, err = client.SharedDriveEdit(retSD.SharedDriveID, sdo)
+
+	return "", err
+}
+
+func sharedDriveDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retSD, err := getSharedDriveFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	retInfra, err := client.InfrastructureGet(retSD.InfrastructureID)
+	if err != nil {
+		return "", err
+	}
+
+	attachedInstanceArraysList, err := getSharedDriveAttachedInstanceArrays(*retSD, client)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		var confirmationMessage string
+
+		if attachedInstanceArraysList != "" {
+			confirmationMessage = fmt.Sprintf("Deleting shared drive %s (%d), attached to instance arrays %s - from infrastructure %s (%d).  Are you sure? Type \"yes\" to continue:",
+				retSD.SharedDriveLabel, retSD.SharedDriveID,
+				attachedInstanceArraysList,
+				retInfra.InfrastructureLabel, retInfra.InfrastructureID)
+		} else {
+			confirmationMessage = fmt.Sprintf("Deleting shared drive %s (%d), unattached - from infrastructure %s (%d).  Are you sure? Type \"yes\" to continue:",
+				retSD.SharedDriveLabel, retSD.SharedDriveID,
+				retInfra.InfrastructureLabel, retInfra.InfrastructureID)
+		}
+
+		//this is simply so that we don't output a text on the command line
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		return "", client.SharedDriveDelete(retSD.SharedDriveID)
+	}
+
+	return "", fmt.Errorf("Operation not confirmed. Aborting")
+}
+
+func sharedDriveAttachCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retSD, err := getSharedDriveFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	retIA, err := getInstanceArrayFromCommand("ia", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	if retSD.InfrastructureID != retIA.InfrastructureID {
+		return "", fmt.Errorf("shared drive %s (#%d) and instance array %s (#%d) are not in the same infrastructure", retSD.SharedDriveLabel, retSD.SharedDriveID, retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	_, err = client.SharedDriveAttachInstanceArray(retSD.SharedDriveID, retIA.InstanceArrayID)
+
+	return "", err
+}
+
+func sharedDriveDetachCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	retSD, err := getSharedDriveFromCommand("id", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	retIA, err := getInstanceArrayFromCommand("ia", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	attached := false
+	for _, id := range retSD.SharedDriveAttachedInstanceArrays {
+		if id == retIA.InstanceArrayID {
+			attached = true
+		}
+	}
+
+	if !attached {
+		return "", fmt.Errorf("shared drive %s (#%d) is not attached to instance array %s (#%d)", retSD.SharedDriveLabel, retSD.SharedDriveID, retIA.InstanceArrayLabel, retIA.InstanceArrayID)
+	}
+
+	_, err = client.SharedDriveDetachInstanceArray(retSD.SharedDriveID, retIA.InstanceArrayID)
+
+	return "", err
+}
+
+func getSharedDriveFromCommand(paramName string, c *Command, client metalcloud.MetalCloudClient) (*metalcloud.SharedDrive, error) {
+
+	m, err := getParam(c, "shared_drive_id_or_label", paramName)
+	if err != nil {
+		return nil, err
+	}
+
+	id, label, isID := idOrLabel(m)
+
+	if isID {
+		return client.SharedDriveGet(id)
+	}
+
+	return client.SharedDriveGetByLabel(label)
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestSharedDriveCreateCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	infra := metalcloud.Infrastructure{
+		InfrastructureID:    100,
+		InfrastructureLabel: "test",
+	}
+
+	client.EXPECT().
+		InfrastructureGetByLabel(infra.InfrastructureLabel).
+		Return(&infra, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SharedDriveCreate(infra.InfrastructureID, metalcloud.SharedDrive{
+			SharedDriveLabel:       "gfs-data",
+			SharedDriveStorageType: "iscsi_ssd",
+			SharedDriveSizeMbytes:  2048,
+			SharedDriveHasGFS:      true,
+		}).
+		Return(&metalcloud.SharedDrive{SharedDriveID: 10}, nil).
+		AnyTimes()
+
+	cases := []CommandTestCase{
+		{
+			name: "good1",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label": "test",
+				"shared_drive_label":         "gfs-data",
+				"shared_drive_storage_type":  "iscsi_ssd",
+				"shared_drive_size_mbytes":   2048,
+				"shared_drive_has_gfs":       true,
+			}),
+			good: true,
+			id:   10,
+		},
+		{
+			name: "missing label",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label": "test",
+			}),
+			good: false,
+		},
+		{
+			name: "invalid type",
+			cmd: MakeCommand(map[string]interface{}{
+				"infrastructure_id_or_label": "test",
+				"shared_drive_label":         "gfs-data",
+				"shared_drive_storage_type":  "nfs",
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(sharedDriveCreateCmd, cases, client, t)
+}
+
+func TestSharedDriveGetAndEditCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sd := metalcloud.SharedDrive{
+		SharedDriveID:                     10,
+		SharedDriveLabel:                  "gfs-data",
+		InfrastructureID:                  100,
+		SharedDriveServiceStatus:          "active",
+		SharedDriveAttachedInstanceArrays: []int{200},
+		SharedDriveOperation: metalcloud.SharedDriveOperation{
+			SharedDriveID:          10,
+			SharedDriveLabel:       "gfs-data",
+			SharedDriveStorageType: "iscsi_ssd",
+			SharedDriveSizeMbytes:  2048,
+			SharedDriveHasGFS:      true,
+		},
+	}
+
+	client.EXPECT().
+		SharedDriveGetByLabel(sd.SharedDriveLabel).
+		Return(&sd, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InstanceArrayGet(200).
+		Return(&metalcloud.InstanceArray{InstanceArrayID: 200, InstanceArrayLabel: "workers"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SharedDriveEdit(sd.SharedDriveID, metalcloud.SharedDriveOperation{
+			SharedDriveID:          10,
+			SharedDriveLabel:       "gfs-data",
+			SharedDriveStorageType: "iscsi_ssd",
+			SharedDriveSizeMbytes:  4096,
+			SharedDriveHasGFS:      false,
+		}).
+		Return(&sd, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"shared_drive_id_or_label": "gfs-data",
+		"format":                   "json",
+	})
+
+	ret, err := sharedDriveGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	r := m[0].(map[string]interface{})
+	Expect(r["ATTACHED TO"]).To(Equal("workers (#200)"))
+	Expect(r["GFS"]).To(Equal(true))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"shared_drive_id_or_label": "gfs-data",
+		"shared_drive_size_mbytes": 4096,
+		"no_shared_drive_has_gfs":  true,
+	})
+
+	_, err = sharedDriveEditCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	cmd.Arguments["shared_drive_has_gfs"] = &[]bool{true}[0]
+	_, err = sharedDriveEditCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestSharedDriveDeleteCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sd := metalcloud.SharedDrive{
+		SharedDriveID:    10,
+		SharedDriveLabel: "gfs-data",
+		InfrastructureID: 100,
+	}
+
+	client.EXPECT().
+		SharedDriveGet(sd.SharedDriveID).
+		Return(&sd, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		InfrastructureGet(sd.InfrastructureID).
+		Return(&metalcloud.Infrastructure{InfrastructureID: 100, InfrastructureLabel: "test"}, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SharedDriveDelete(sd.SharedDriveID).
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"shared_drive_id_or_label": 10,
+		"autoconfirm":              true,
+	})
+
+	_, err := sharedDriveDeleteCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
+
+func TestSharedDriveAttachDetachCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sd := metalcloud.SharedDrive{
+		SharedDriveID:                     10,
+		SharedDriveLabel:                  "gfs-data",
+		InfrastructureID:                  100,
+		SharedDriveAttachedInstanceArrays: []int{200},
+	}
+
+	ia1 := metalcloud.InstanceArray{InstanceArrayID: 200, InstanceArrayLabel: "workers", InfrastructureID: 100}
+	ia2 := metalcloud.InstanceArray{InstanceArrayID: 201, InstanceArrayLabel: "masters", InfrastructureID: 100}
+	ia3 := metalcloud.InstanceArray{InstanceArrayID: 300, InstanceArrayLabel: "other", InfrastructureID: 101}
+
+	client.EXPECT().
+		SharedDriveGet(sd.SharedDriveID).
+		Return(&sd, nil).
+		AnyTimes()
+
+	for _, ia := range []metalcloud.InstanceArray{ia1, ia2, ia3} {
+		i := ia
+		client.EXPECT().InstanceArrayGet(i.InstanceArrayID).Return(&i, nil).AnyTimes()
+	}
+
+	client.EXPECT().
+		SharedDriveAttachInstanceArray(sd.SharedDriveID, ia2.InstanceArrayID).
+		Return(&sd, nil).
+		Times(1)
+
+	client.EXPECT().
+		SharedDriveDetachInstanceArray(sd.SharedDriveID, ia1.InstanceArrayID).
+		Return(&sd, nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"shared_drive_id_or_label":   10,
+		"instance_array_id_or_label": 201,
+	})
+	_, err := sharedDriveAttachCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//instance arrays of other infrastructures cannot be attached
+	cmd.Arguments["instance_array_id_or_label"] = &[]int{300}[0]
+	_, err = sharedDriveAttachCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+
+	cmd.Arguments["instance_array_id_or_label"] = &[]int{200}[0]
+	_, err = sharedDriveDetachCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	//the drive is not attached to this instance array
+	cmd.Arguments["instance_array_id_or_label"] = &[]int{201}[0]
+	_, err = sharedDriveDetachCmd(&cmd, client)
+	Expect(err).NotTo(BeNil())
+}
@@ -22,7 +22,7 @@ var stageDefinitionsCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list stage definitions", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: stageDefinitionsListCmd,
@@ -216,7 +216,7 @@ func stageDefinitionsListCmd(c *Command, client metalcloud.MetalCloudClient) (st
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Stage Definitions", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Stage Definitions", "")
 }
 
 func stageDefinitionCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -19,7 +19,7 @@ var storageCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list storage", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":              c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":              tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"filter":              c.FlagSet.String("filter", "*", "filter to use when searching for servers. Check the documentation for examples. Defaults to '*'"),
 				"show_credentials":    c.FlagSet.Bool("show-credentials", false, green("(Flag)") + " If set returns the servers' IPMI credentials. (Slow for large queries)"),
 				"show_decommissioned": c.FlagSet.Bool("show-decommissioned", false, green("(Flag)") + " If set returns decommissioned servers which are normally hidden"),
@@ -188,5 +188,5 @@ func storageListCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 		title = title + fmt.Sprintf(" %d decommissioned", statusCounts["decommissioned"])
 	}
 
-	return table.RenderTable(title, "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, title, "")
 }
@@ -21,7 +21,7 @@ var subnetPoolCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list subnet pools", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":     c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":     tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"filter":     c.FlagSet.String("filter", "*", "Filter to restrict the results. Defaults to '*'"),
 				"datacenter": c.FlagSet.String("datacenter", _nilDefaultStr, "Quick filter to restrict the results to show only the subnets of a datacenter."),
 			}
@@ -39,7 +39,7 @@ var subnetPoolCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"subnet_pool_id": c.FlagSet.Int("id", _nilDefaultInt, red("(Required)") + " Subnetpool's id"),
-				"format":         c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":         outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"raw":            c.FlagSet.Bool("raw", false, green("(Flag)") + " When set the return will be a full dump of the object. This is useful when copying configurations. Only works with json and yaml formats."),
 			}
 		},
@@ -194,7 +194,7 @@ func subnetPoolListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Subnet pools", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Subnet pools", "")
 }
 
 func subnetPoolGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -21,7 +21,7 @@ var switchCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list switches", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":           c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":           tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"datacenter_name":  c.FlagSet.String("datacenter", "", "The optional parameter acts as a filter that restricts the returned results to switch devices located in the specified datacenter."),
 				"switch_type":      c.FlagSet.String("switch-type", "", "The optional parameter acts as a filter that restricts the returned results to switch devices of the specified type."),
 				"show_credentials": c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the switch management credentials. (Slow for large queries)"),
@@ -229,7 +229,7 @@ volumeTemplateID: 0
 			c.Arguments = map[string]interface{}{
 				"network_device_id_or_identifier_string": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Switch id or identifier string. "),
 				"show_credentials":                       c.FlagSet.Bool("show-credentials", false, green("(Flag)")+" If set returns the switch credentials"),
-				"format":                                 c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                                 outputFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"raw":                                    c.FlagSet.Bool("raw", false, green("(Flag)")+" When set the return will be a full dump of the object. This is useful when copying configurations. Only works with json and yaml formats."),
 			}
 		},
@@ -262,7 +262,7 @@ volumeTemplateID: 0
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"network_device_id_or_identifier_string": c.FlagSet.String("id", _nilDefaultStr, red("(Required)")+" Switch id or identifier string. "),
-				"format":                                 c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":                                 tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"raw":                                    c.FlagSet.Bool("raw", false, green("(Flag)")+" When set the return will be a full dump of the object. This is useful when copying configurations. Only works with json and yaml formats."),
 			}
 		},
@@ -373,7 +373,7 @@ func switchListCmd(c *Command, client metalcloud.MetalCloudClient) (string, erro
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Switches", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Switches", "")
 }
 
 func switchCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -672,7 +672,7 @@ func switchInterfacesListCmd(c *Command, client metalcloud.MetalCloudClient) (st
 			Data:   data,
 			Schema: schema,
 		}
-		ret, err := table.RenderTable(fmt.Sprintf("Interfaces of switch %s (#%d)", sw.NetworkEquipmentIdentifierString, sw.NetworkEquipmentID), "", format)
+		ret, err := renderTable(c, table, fmt.Sprintf("Interfaces of switch %s (#%d)", sw.NetworkEquipmentIdentifierString, sw.NetworkEquipmentID), "")
 		if err != nil {
 			return "", err
 		}
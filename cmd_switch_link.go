@@ -0,0 +1,327 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"github.com/metalsoft-io/tableformatter"
+)
+
+var switchLinkCmds = []Command{
+
+	{
+		Description:  "Lists switch links.",
+		Subject:      "switch-link",
+		AltSubject:   "sw-link",
+		Predicate:    "list",
+		AltPredicate: "ls",
+		FlagSet:      flag.NewFlagSet("list switch links", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_device_id_or_identifier_string": c.FlagSet.String("switch", _nilDefaultStr, "The optional parameter acts as a filter that restricts the returned results to links of the switch with the specified id or identifier string."),
+				"type":                                   c.FlagSet.String("type", _nilDefaultStr, "The optional parameter acts as a filter that restricts the returned results to links of the specified type."),
+				"format":                                 tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: switchLinkListCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Create switch link.",
+		Subject:      "switch-link",
+		AltSubject:   "sw-link",
+		Predicate:    "create",
+		AltPredicate: "new",
+		FlagSet:      flag.NewFlagSet("create switch link", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_device_id_or_identifier_string1": c.FlagSet.String("switch1", _nilDefaultStr, red("(Required)")+" First Switch's id or identifier string. "),
+				"network_device_id_or_identifier_string2": c.FlagSet.String("switch2", _nilDefaultStr, red("(Required)")+" Second Switch's id or identifier string. "),
+				"type":      c.FlagSet.String("type", _nilDefaultStr, red("(Required)")+" The type of link. For example `mlag`."),
+				"return_id": c.FlagSet.Bool("return-id", false, "Will print the ID of the created object. Useful for automating tasks."),
+			}
+		},
+		ExecuteFunc: switchLinkCreateCmd,
+		Endpoint:    DeveloperEndpoint,
+		Example: `
+metalcloud-cli switch-link create --switch1 leaf01 --switch2 leaf02 --type mlag
+`,
+	},
+	{
+		Description:  "Get switch link.",
+		Subject:      "switch-link",
+		AltSubject:   "sw-link",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("get switch link", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_device_id_or_identifier_string1": c.FlagSet.String("switch1", _nilDefaultStr, red("(Required)")+" First Switch's id or identifier string. "),
+				"network_device_id_or_identifier_string2": c.FlagSet.String("switch2", _nilDefaultStr, red("(Required)")+" Second Switch's id or identifier string. "),
+				"type":   c.FlagSet.String("type", _nilDefaultStr, red("(Required)")+" The type of link. For example `mlag`."),
+				"format": outputFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"raw":    c.FlagSet.Bool("raw", false, green("(Flag)")+" If set returns the raw object serialized using specified format"),
+			}
+		},
+		ExecuteFunc: switchLinkGetCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+	{
+		Description:  "Delete switch link.",
+		Subject:      "switch-link",
+		AltSubject:   "sw-link",
+		Predicate:    "delete",
+		AltPredicate: "rm",
+		FlagSet:      flag.NewFlagSet("delete switch link", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_device_id_or_identifier_string1": c.FlagSet.String("switch1", _nilDefaultStr, red("(Required)")+" First Switch's id or identifier string. "),
+				"network_device_id_or_identifier_string2": c.FlagSet.String("switch2", _nilDefaultStr, red("(Required)")+" Second Switch's id or identifier string. "),
+				"type":        c.FlagSet.String("type", _nilDefaultStr, red("(Required)")+" The type of link. For example `mlag`."),
+				"autoconfirm": c.FlagSet.Bool("autoconfirm", false, green("(Flag)")+" If set it will assume action is confirmed"),
+			}
+		},
+		ExecuteFunc: switchLinkDeleteCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
+}
+
+func switchLinkListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	switchID := 0
+	if _, err := getParam(c, "network_device_id_or_identifier_string", "switch"); err == nil {
+		sw, err := getSwitchFromCommandLine("switch", c, client)
+		if err != nil {
+			return "", err
+		}
+		switchID = sw.NetworkEquipmentID
+	}
+
+	linkType := getStringParam(c.Arguments["type"])
+
+	list, err := client.SwitchDeviceLinks()
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "SWITCH1",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "SWITCH2",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 6,
+		},
+	}
+
+	//switches are usually members of more than one link
+	switches := map[int]*metalcloud.SwitchDevice{}
+	getSwitch := func(id int) (*metalcloud.SwitchDevice, error) {
+		if sw, ok := switches[id]; ok {
+			return sw, nil
+		}
+		sw, err := client.SwitchDeviceGet(id, false)
+		if err != nil {
+			return nil, err
+		}
+		switches[id] = sw
+		return sw, nil
+	}
+
+	data := [][]interface{}{}
+
+	for _, l := range *list {
+
+		if switchID != 0 && l.NetworkEquipmentID1 != switchID && l.NetworkEquipmentID2 != switchID {
+			continue
+		}
+
+		if linkType != "" && l.NetworkEquipmentLinkType != linkType {
+			continue
+		}
+
+		sw1, err := getSwitch(l.NetworkEquipmentID1)
+		if err != nil {
+			return "", err
+		}
+
+		sw2, err := getSwitch(l.NetworkEquipmentID2)
+		if err != nil {
+			return "", err
+		}
+
+		data = append(data, []interface{}{
+			l.NetworkEquipmentLinkID,
+			fmt.Sprintf("%s (#%d)", sw1.NetworkEquipmentIdentifierString, sw1.NetworkEquipmentID),
+			fmt.Sprintf("%s (#%d)", sw2.NetworkEquipmentIdentifierString, sw2.NetworkEquipmentID),
+			l.NetworkEquipmentLinkType,
+		})
+	}
+
+	tableformatter.TableSorter(schema).OrderBy(schema[0].FieldName).Sort(data)
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return renderTable(c, table, "Switch links", "")
+}
+
+func switchLinkCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	sw1, err := getSwitchFromCommandLineWithPrivateParam("network_device_id_or_identifier_string1", "switch1", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	sw2, err := getSwitchFromCommandLineWithPrivateParam("network_device_id_or_identifier_string2", "switch2", c, client)
+	if err != nil {
+		return "", err
+	}
+
+	if sw1.NetworkEquipmentID == sw2.NetworkEquipmentID {
+		return "", fmt.Errorf("a switch cannot be linked to itself")
+	}
+
+	linkType, ok := getStringParamOk(c.Arguments["type"])
+	if !ok {
+		return "", fmt.Errorf("-type is required")
+	}
+
+	ret, err := client.SwitchDeviceLinkCreate(sw1.NetworkEquipmentID, sw2.NetworkEquipmentID, linkType)
+	if err != nil {
+		return "", err
+	}
+
+	if getBoolParam(c.Arguments["return_id"]) {
+		return fmt.Sprintf("%d", ret.NetworkEquipmentLinkID), nil
+	}
+
+	return "", nil
+}
+
+func switchLinkGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	link, sw1, sw2, err := getSwitchLinkFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	format := getStringParam(c.Arguments["format"])
+
+	if getBoolParam(c.Arguments["raw"]) {
+		return tableformatter.RenderRawObject(*link, format, "SwitchLink")
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "SWITCH1",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "SWITCH2",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 30,
+		},
+		{
+			FieldName: "TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 6,
+		},
+	}
+
+	data := [][]interface{}{{
+		link.NetworkEquipmentLinkID,
+		fmt.Sprintf("%s (#%d)", sw1.NetworkEquipmentIdentifierString, sw1.NetworkEquipmentID),
+		fmt.Sprintf("%s (#%d)", sw2.NetworkEquipmentIdentifierString, sw2.NetworkEquipmentID),
+		link.NetworkEquipmentLinkType,
+	}}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	return table.RenderTransposedTable("switch link", "", format)
+}
+
+func switchLinkDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	link, sw1, sw2, err := getSwitchLinkFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := confirmCommand(c, func() string {
+
+		confirmationMessage := fmt.Sprintf("Deleting %s switch link %s - %s.  Are you sure? Type \"yes\" to continue:",
+			link.NetworkEquipmentLinkType,
+			sw1.NetworkEquipmentIdentifierString,
+			sw2.NetworkEquipmentIdentifierString)
+
+		//this is simply so that we don't output a text on the command line under go test
+		if strings.HasSuffix(os.Args[0], ".test") {
+			confirmationMessage = ""
+		}
+
+		return confirmationMessage
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return "", fmt.Errorf("Operation not confirmed. Aborting")
+	}
+
+	return "", client.SwitchDeviceLinkDelete(sw1.NetworkEquipmentID, sw2.NetworkEquipmentID, link.NetworkEquipmentLinkType)
+}
+
+//getSwitchLinkFromCommand returns the link between the switches given with -switch1 and -switch2 and the two switches
+func getSwitchLinkFromCommand(c *Command, client metalcloud.MetalCloudClient) (*metalcloud.SwitchDeviceLink, *metalcloud.SwitchDevice, *metalcloud.SwitchDevice, error) {
+
+	sw1, err := getSwitchFromCommandLineWithPrivateParam("network_device_id_or_identifier_string1", "switch1", c, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sw2, err := getSwitchFromCommandLineWithPrivateParam("network_device_id_or_identifier_string2", "switch2", c, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	linkType, ok := getStringParamOk(c.Arguments["type"])
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("-type is required")
+	}
+
+	link, err := client.SwitchDeviceLinkGet(sw1.NetworkEquipmentID, sw2.NetworkEquipmentID, linkType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return link, sw1, sw2, nil
+}
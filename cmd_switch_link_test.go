@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestSwitchLinkListCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	list := map[int]metalcloud.SwitchDeviceLink{
+		1: {
+			NetworkEquipmentLinkID:   1,
+			NetworkEquipmentID1:      10,
+			NetworkEquipmentID2:      11,
+			NetworkEquipmentLinkType: "mlag",
+		},
+		2: {
+			NetworkEquipmentLinkID:   2,
+			NetworkEquipmentID1:      12,
+			NetworkEquipmentID2:      13,
+			NetworkEquipmentLinkType: "mlag",
+		},
+	}
+
+	client.EXPECT().
+		SwitchDeviceLinks().
+		Return(&list, nil).
+		AnyTimes()
+
+	for _, id := range []int{10, 11, 12, 13} {
+		sw := metalcloud.SwitchDevice{
+			NetworkEquipmentID:               id,
+			NetworkEquipmentIdentifierString: fmt.Sprintf("leaf%d", id),
+		}
+		client.EXPECT().SwitchDeviceGet(id, false).Return(&sw, nil).AnyTimes()
+	}
+
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("leaf13", false).
+		Return(&metalcloud.SwitchDevice{NetworkEquipmentID: 13, NetworkEquipmentIdentifierString: "leaf13"}, nil).
+		AnyTimes()
+
+	expectedFirstRow := map[string]interface{}{
+		"ID":      1,
+		"SWITCH1": "leaf10 (#10)",
+		"SWITCH2": "leaf11 (#11)",
+		"TYPE":    "mlag",
+	}
+
+	testListCommand(switchLinkListCmd, nil, client, expectedFirstRow, t)
+
+	//only the links of the switch are returned
+	cmd := MakeCommand(map[string]interface{}{
+		"network_device_id_or_identifier_string": "leaf13",
+		"format":                                 "json",
+	})
+
+	ret, err := switchLinkListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(1))
+	Expect(m[0].(map[string]interface{})["SWITCH2"]).To(Equal("leaf13 (#13)"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"type":   "other",
+		"format": "json",
+	})
+
+	ret, err = switchLinkListCmd(&cmd, client)
+	Expect(err).To(BeNil())
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(0))
+}
+
+func TestSwitchLinkCreateCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sw1 := metalcloud.SwitchDevice{NetworkEquipmentID: 10, NetworkEquipmentIdentifierString: "leaf10"}
+	sw2 := metalcloud.SwitchDevice{NetworkEquipmentID: 11, NetworkEquipmentIdentifierString: "leaf11"}
+
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString(sw1.NetworkEquipmentIdentifierString, false).
+		Return(&sw1, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceGet(sw2.NetworkEquipmentID, false).
+		Return(&sw2, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceLinkCreate(10, 11, "mlag").
+		Return(&metalcloud.SwitchDeviceLink{NetworkEquipmentLinkID: 5}, nil).
+		AnyTimes()
+
+	cases := []CommandTestCase{
+		{
+			name: "good1",
+			cmd: MakeCommand(map[string]interface{}{
+				"network_device_id_or_identifier_string1": "leaf10",
+				"network_device_id_or_identifier_string2": 11,
+				"type":      "mlag",
+				"return_id": true,
+			}),
+			good: true,
+			id:   5,
+		},
+		{
+			name: "missing type",
+			cmd: MakeCommand(map[string]interface{}{
+				"network_device_id_or_identifier_string1": "leaf10",
+				"network_device_id_or_identifier_string2": 11,
+			}),
+			good: false,
+		},
+		{
+			name: "same switch",
+			cmd: MakeCommand(map[string]interface{}{
+				"network_device_id_or_identifier_string1": 11,
+				"network_device_id_or_identifier_string2": 11,
+				"type": "mlag",
+			}),
+			good: false,
+		},
+		{
+			name: "missing second switch",
+			cmd: MakeCommand(map[string]interface{}{
+				"network_device_id_or_identifier_string1": "leaf10",
+				"type": "mlag",
+			}),
+			good: false,
+		},
+	}
+
+	testCreateCommand(switchLinkCreateCmd, cases, client, t)
+}
+
+func TestSwitchLinkGetAndDeleteCmd(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sw1 := metalcloud.SwitchDevice{NetworkEquipmentID: 10, NetworkEquipmentIdentifierString: "leaf10"}
+	sw2 := metalcloud.SwitchDevice{NetworkEquipmentID: 11, NetworkEquipmentIdentifierString: "leaf11"}
+
+	link := metalcloud.SwitchDeviceLink{
+		NetworkEquipmentLinkID:   5,
+		NetworkEquipmentID1:      10,
+		NetworkEquipmentID2:      11,
+		NetworkEquipmentLinkType: "mlag",
+	}
+
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString(sw1.NetworkEquipmentIdentifierString, false).
+		Return(&sw1, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString(sw2.NetworkEquipmentIdentifierString, false).
+		Return(&sw2, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceLinkGet(10, 11, "mlag").
+		Return(&link, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceLinkDelete(10, 11, "mlag").
+		Return(nil).
+		Times(1)
+
+	cmd := MakeCommand(map[string]interface{}{
+		"network_device_id_or_identifier_string1": "leaf10",
+		"network_device_id_or_identifier_string2": "leaf11",
+		"type":   "mlag",
+		"format": "json",
+	})
+
+	ret, err := switchLinkGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+
+	r := m[0].(map[string]interface{})
+	Expect(int(r["ID"].(float64))).To(Equal(5))
+	Expect(r["SWITCH1"]).To(Equal("leaf10 (#10)"))
+	Expect(r["TYPE"]).To(Equal("mlag"))
+
+	cmd = MakeCommand(map[string]interface{}{
+		"network_device_id_or_identifier_string1": "leaf10",
+		"network_device_id_or_identifier_string2": "leaf11",
+		"type":        "mlag",
+		"autoconfirm": true,
+	})
+
+	_, err = switchLinkDeleteCmd(&cmd, client)
+	Expect(err).To(BeNil())
+}
@@ -21,7 +21,7 @@ var switchPairCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list switch pairs", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: switchPairListCmd,
@@ -45,6 +45,24 @@ var switchPairCmds = []Command{
 		ExecuteFunc: switchPairCreateCmd,
 		Endpoint:    DeveloperEndpoint,
 	},
+	{
+		Description:  "Get switch pair.",
+		Subject:      "switch-pair",
+		AltSubject:   "sw-pair",
+		Predicate:    "get",
+		AltPredicate: "show",
+		FlagSet:      flag.NewFlagSet("Get switch pair", flag.ExitOnError),
+		InitFunc: func(c *Command) {
+			c.Arguments = map[string]interface{}{
+				"network_device_id_or_identifier_string1": c.FlagSet.String("switch1", _nilDefaultStr, red("(Required)")+" First Switch's id or identifier string. "),
+				"network_device_id_or_identifier_string2": c.FlagSet.String("switch2", _nilDefaultStr, red("(Required)")+" Second Switch's id or identifier string. "),
+				"type":   c.FlagSet.String("type", "mlag", "The type of link. The default and only link type supported is `mlag`"),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+			}
+		},
+		ExecuteFunc: switchPairGetCmd,
+		Endpoint:    DeveloperEndpoint,
+	},
 	{
 		Description:  "Delete a switch pair.",
 		Subject:      "switch-pair",
@@ -128,7 +146,7 @@ func switchPairListCmd(c *Command, client metalcloud.MetalCloudClient) (string,
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Switch links", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Switch links", "")
 
 }
 
@@ -158,6 +176,75 @@ func switchPairCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string
 	return "", err
 }
 
+func switchPairGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+
+	link, sw1, sw2, err := getSwitchLinkFromCommand(c, client)
+	if err != nil {
+		return "", err
+	}
+
+	schema := []tableformatter.SchemaField{
+		{
+			FieldName: "ID",
+			FieldType: tableformatter.TypeInt,
+			FieldSize: 6,
+		},
+		{
+			FieldName: "IDENTIFIER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 20,
+		},
+		{
+			FieldName: "DATACENTER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "DRIVER",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "POSITION",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+		{
+			FieldName: "MGMT IP",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 15,
+		},
+		{
+			FieldName: "LINK TYPE",
+			FieldType: tableformatter.TypeString,
+			FieldSize: 10,
+		},
+	}
+
+	data := [][]interface{}{}
+
+	for _, sw := range []*metalcloud.SwitchDevice{sw1, sw2} {
+		data = append(data, []interface{}{
+			sw.NetworkEquipmentID,
+			sw.NetworkEquipmentIdentifierString,
+			sw.DatacenterName,
+			sw.NetworkEquipmentDriver,
+			sw.NetworkEquipmentProvisionerPosition,
+			sw.NetworkEquipmentManagementAddress,
+			link.NetworkEquipmentLinkType,
+		})
+	}
+
+	table := tableformatter.Table{
+		Data:   data,
+		Schema: schema,
+	}
+
+	topLine := fmt.Sprintf("Switch pair #%d", link.NetworkEquipmentLinkID)
+
+	return renderTable(c, table, "Switch pair members", topLine)
+}
+
 func switchPairDeleteCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
 
 	sw1, err := getSwitchFromCommandLineWithPrivateParam("network_device_id_or_identifier_string1", "switch1", c, client)
@@ -151,3 +151,64 @@ func TestSwitchPairDelete(t *testing.T) {
 }
 
 const _switchDeviceLinkFixture1 = "{\"network_equipment_link_id\": 7,\"network_equipment_id_1\": 7,\"network_equipment_id_2\": 8,\"network_equipment_link_type\": \"mlag\",\"network_equipment_link_properties\": []}"
+
+func TestSwitchPairGet(t *testing.T) {
+	RegisterTestingT(t)
+	ctrl := gomock.NewController(t)
+
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	sw1 := metalcloud.SwitchDevice{
+		NetworkEquipmentID:                  7,
+		NetworkEquipmentIdentifierString:    "leaf07",
+		DatacenterName:                      "dc1",
+		NetworkEquipmentProvisionerPosition: "leaf",
+	}
+
+	sw2 := metalcloud.SwitchDevice{
+		NetworkEquipmentID:                  8,
+		NetworkEquipmentIdentifierString:    "leaf08",
+		DatacenterName:                      "dc1",
+		NetworkEquipmentProvisionerPosition: "leaf",
+	}
+
+	var swl metalcloud.SwitchDeviceLink
+
+	err := json.Unmarshal([]byte(_switchDeviceLinkFixture1), &swl)
+	if err != nil {
+		t.Error(err)
+	}
+
+	client.EXPECT().
+		SwitchDeviceGet(7, false).
+		Return(&sw1, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceGetByIdentifierString("leaf08", false).
+		Return(&sw2, nil).
+		AnyTimes()
+
+	client.EXPECT().
+		SwitchDeviceLinkGet(7, 8, "mlag").
+		Return(&swl, nil).
+		AnyTimes()
+
+	cmd := MakeCommand(map[string]interface{}{
+		"network_device_id_or_identifier_string1": 7,
+		"network_device_id_or_identifier_string2": "leaf08",
+		"type":   "mlag",
+		"format": "json",
+	})
+
+	ret, err := switchPairGetCmd(&cmd, client)
+	Expect(err).To(BeNil())
+
+	var m []interface{}
+	Expect(json.Unmarshal([]byte(ret), &m)).To(BeNil())
+	Expect(m).To(HaveLen(2))
+
+	r := m[1].(map[string]interface{})
+	Expect(r["IDENTIFIER"]).To(Equal("leaf08"))
+	Expect(r["LINK TYPE"]).To(Equal("mlag"))
+}
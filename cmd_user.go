@@ -55,7 +55,7 @@ var userCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list users", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":       c.FlagSet.String("format", "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":       tableFormatFlag(c, "", "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"filter":       c.FlagSet.String("filter", "*", "Properties to use when filtering, for example '+user_is_billable:0 +user_language=en'. Defaults to '*'. Valid filter properties are: " + strings.Join(userFilterProperties, ", ") + "."),
 			}
 		},
@@ -139,5 +139,5 @@ func userListCmd(c *Command, client metalcloud.MetalCloudClient) (string, error)
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Users", topLine, getStringParam(c.Arguments["format"]))
-}
\ No newline at end of file
+	return renderTable(c, table, "Users", topLine)
+}
\ No newline at end of file
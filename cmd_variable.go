@@ -22,7 +22,7 @@ var variablesCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list variables", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"usage":  c.FlagSet.String("usage", _nilDefaultStr, "Variable's usage"),
 			}
 		},
@@ -125,7 +125,7 @@ func variablesListCmd(c *Command, client metalcloud.MetalCloudClient) (string, e
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Variables", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Variables", "")
 }
 
 func variableCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -20,7 +20,7 @@ var volumeTemplateCmds = []Command{
 		FlagSet:      flag.NewFlagSet("list volume templates", flag.ExitOnError),
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
-				"format":     c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":     tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 				"local_only": c.FlagSet.Bool("local-only", false, "Show only templates that support local install"),
 				"pxe_only":   c.FlagSet.Bool("pxe-only", false, "Show only templates that support pxe booting"),
 			}
@@ -170,7 +170,7 @@ func volumeTemplatesListCmd(c *Command, client metalcloud.MetalCloudClient) (str
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Volume templates", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Volume templates", "")
 }
 
 func volumeTemplateCreateFromDriveCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -22,7 +22,7 @@ var workflowCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"usage":  c.FlagSet.String("usage", _nilDefaultStr, "Workflow usage. One of infrastructure, network_equipment, server, free_standing, storage_pool, user, os_template"),
-				"format": c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: workflowsListCmd,
@@ -38,7 +38,7 @@ var workflowCmds = []Command{
 		InitFunc: func(c *Command) {
 			c.Arguments = map[string]interface{}{
 				"workflow_id_or_label": c.FlagSet.String("id", _nilDefaultStr, "Workflow's id or label."),
-				"format":               c.FlagSet.String("format", _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+				"format":               tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
 			}
 		},
 		ExecuteFunc: workflowGetCmd,
@@ -176,7 +176,7 @@ func workflowsListCmd(c *Command, client metalcloud.MetalCloudClient) (string, e
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Workflows", "", getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Workflows", "")
 }
 
 func workflowGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
@@ -237,7 +237,7 @@ func workflowGetCmd(c *Command, client metalcloud.MetalCloudClient) (string, err
 		Data:   data,
 		Schema: schema,
 	}
-	return table.RenderTable("Stages", topLine, getStringParam(c.Arguments["format"]))
+	return renderTable(c, table, "Stages", topLine)
 }
 
 func workflowCreateCmd(c *Command, client metalcloud.MetalCloudClient) (string, error) {
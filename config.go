@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigDir = ".metalcloud"
+const defaultConfigFileName = "config.yaml"
+
+//ConnectionProfile holds the details needed to connect to a metal cloud controller
+type ConnectionProfile struct {
+	Name      string `yaml:"name"`
+	Endpoint  string `yaml:"endpoint,omitempty"`
+	UserEmail string `yaml:"user,omitempty"`
+	APIKey    string `yaml:"apiKey,omitempty"`
+	Admin     bool   `yaml:"admin,omitempty"`
+}
+
+//CLIConfig represents the contents of the configuration file
+type CLIConfig struct {
+	CurrentContext string              `yaml:"currentContext,omitempty"`
+	Contexts       []ConnectionProfile `yaml:"contexts"`
+}
+
+//getConfigFilePath returns the path of the config file. It can be overridden with METALCLOUD_CONFIG.
+func getConfigFilePath() (string, error) {
+	if v := os.Getenv("METALCLOUD_CONFIG"); v != "" {
+		return v, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, defaultConfigDir, defaultConfigFileName), nil
+}
+
+//loadConfig reads the config file. A missing file is treated as an empty configuration.
+func loadConfig(path string) (*CLIConfig, error) {
+	config := CLIConfig{}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &config, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %s", path, err)
+	}
+
+	return &config, nil
+}
+
+//saveConfig writes the config file, creating the parent directory if needed. The file contains api keys so it is only readable by the owner.
+func saveConfig(path string, config *CLIConfig) error {
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+//getContext returns the context with the given name
+func (cfg *CLIConfig) getContext(name string) (*ConnectionProfile, bool) {
+	for i := range cfg.Contexts {
+		if cfg.Contexts[i].Name == name {
+			return &cfg.Contexts[i], true
+		}
+	}
+	return nil, false
+}
+
+//setContext adds the context or replaces an existing one with the same name
+func (cfg *CLIConfig) setContext(profile ConnectionProfile) {
+	if ctx, ok := cfg.getContext(profile.Name); ok {
+		*ctx = profile
+		return
+	}
+	cfg.Contexts = append(cfg.Contexts, profile)
+}
+
+//deleteContext removes a context. Returns false if the context was not found.
+func (cfg *CLIConfig) deleteContext(name string) bool {
+	for i, ctx := range cfg.Contexts {
+		if ctx.Name == name {
+			cfg.Contexts = append(cfg.Contexts[:i], cfg.Contexts[i+1:]...)
+			if cfg.CurrentContext == name {
+				cfg.CurrentContext = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+//resolveConnectionProfile returns the profile to use. The profile is selected using the profileName param,
+//METALCLOUD_PROFILE or the config file's current context, in this order. Environment variables override the profile's values.
+func resolveConnectionProfile(profileName string) (ConnectionProfile, error) {
+	profile := ConnectionProfile{}
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		return profile, err
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return profile, err
+	}
+
+	name := profileName
+	if name == "" {
+		name = os.Getenv("METALCLOUD_PROFILE")
+	}
+	if name == "" {
+		name = config.CurrentContext
+	}
+
+	if name != "" {
+		ctx, ok := config.getContext(name)
+		if !ok {
+			return profile, fmt.Errorf("profile %s not found in %s", name, path)
+		}
+		profile = *ctx
+	}
+
+	if v := os.Getenv("METALCLOUD_USER_EMAIL"); v != "" {
+		profile.UserEmail = v
+	}
+
+	if v := os.Getenv("METALCLOUD_API_KEY"); v != "" {
+		profile.APIKey = v
+	}
+
+	if v := os.Getenv("METALCLOUD_ENDPOINT"); v != "" {
+		profile.Endpoint = v
+	}
+
+	if v, ok := os.LookupEnv("METALCLOUD_ADMIN"); ok {
+		profile.Admin = v == "true"
+	}
+
+	return profile, nil
+}
+
+//extractProfileArgument removes the global --profile argument from the command line and returns its value.
+//The argument is only recognized before the subject or right after the subject and predicate, the positions
+//where it cannot be the value of another flag.
+func extractProfileArgument(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+
+	profile := ""
+	remaining := []string{args[0]}
+	i := 1
+
+	for i < len(args) {
+		n, value, err := matchProfileArgument(args, i)
+		if err != nil {
+			return "", nil, err
+		}
+		if n == 0 {
+			break
+		}
+		profile = value
+		i += n
+	}
+
+	for words := 0; words < 2 && i < len(args) && !strings.HasPrefix(args[i], "-"); words++ {
+		remaining = append(remaining, args[i])
+		i++
+	}
+
+	n, value, err := matchProfileArgument(args, i)
+	if err != nil {
+		return "", nil, err
+	}
+	if n > 0 {
+		profile = value
+		i += n
+	}
+
+	remaining = append(remaining, args[i:]...)
+
+	return profile, remaining, nil
+}
+
+//matchProfileArgument returns the number of arguments used by a --profile argument found at position i and its value
+func matchProfileArgument(args []string, i int) (int, string, error) {
+	if i >= len(args) {
+		return 0, "", nil
+	}
+
+	a := args[i]
+
+	if a == "--profile" || a == "-profile" {
+		if i+1 >= len(args) {
+			return 0, "", fmt.Errorf("--profile requires a value")
+		}
+		return 2, args[i+1], nil
+	}
+
+	if strings.HasPrefix(a, "--profile=") || strings.HasPrefix(a, "-profile=") {
+		return 1, a[strings.Index(a, "=")+1:], nil
+	}
+
+	return 0, "", nil
+}
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//firewallRuleSpec is the representation of a firewall rule used by firewall-rule sync and export.
+//Ports and addresses use the same syntax as the firewall-rule add command. Empty values mean any.
+//Addresses can also be CIDRs, which are sent to the API as the range of their addresses.
+//Rules are enabled unless enabled is set to false.
+type firewallRuleSpec struct {
+	Protocol    string `yaml:"protocol,omitempty"`
+	Port        string `yaml:"port,omitempty"`
+	Source      string `yaml:"source,omitempty"`
+	Destination string `yaml:"destination,omitempty"`
+	Type        string `yaml:"type,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Enabled     *bool  `yaml:"enabled,omitempty"`
+}
+
+//firewallRuleSpecColumns are the columns of the csv format, in the order in which they are written
+var firewallRuleSpecColumns = []string{"protocol", "port", "source", "destination", "type", "description", "enabled"}
+
+var firewallRuleProtocols = []string{"all", "icmp", "tcp", "udp"}
+
+var firewallRuleIPAddressTypes = []string{"ipv4", "ipv6"}
+
+//getFirewallRuleSpecsFormat returns the format of a rules file, using the extension if no format is given
+func getFirewallRuleSpecsFormat(format string, fileName string) (string, error) {
+	if format == "" {
+		format = "yaml"
+		if strings.HasSuffix(strings.ToLower(fileName), ".csv") {
+			format = "csv"
+		}
+	}
+
+	if format != "yaml" && format != "csv" {
+		return "", fmt.Errorf("format %s not supported. Supported values are 'yaml','csv'", format)
+	}
+
+	return format, nil
+}
+
+//parseFirewallRuleSpecs reads a list of rules in yaml or csv format. The csv format requires a header with the column names.
+func parseFirewallRuleSpecs(content []byte, format string) ([]firewallRuleSpec, error) {
+	specs := []firewallRuleSpec{}
+
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(content, &specs); err != nil {
+			return nil, err
+		}
+
+	case "csv":
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.TrimLeadingSpace = true
+
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(records) == 0 {
+			return specs, nil
+		}
+
+		header := records[0]
+		for _, column := range header {
+			if !stringInSlice(strings.ToLower(strings.TrimSpace(column)), firewallRuleSpecColumns) {
+				return nil, fmt.Errorf("unknown column %s. Possible values: %s", column, strings.Join(firewallRuleSpecColumns, ", "))
+			}
+		}
+
+		for _, record := range records[1:] {
+			m := map[string]string{}
+			for i, column := range header {
+				m[strings.ToLower(strings.TrimSpace(column))] = strings.TrimSpace(record[i])
+			}
+
+			spec := firewallRuleSpec{
+				Protocol:    m["protocol"],
+				Port:        m["port"],
+				Source:      m["source"],
+				Destination: m["destination"],
+				Type:        m["type"],
+				Description: m["description"],
+			}
+
+			if m["enabled"] != "" {
+				enabled, err := strconv.ParseBool(m["enabled"])
+				if err != nil {
+					return nil, fmt.Errorf("invalid enabled value %s. Possible values: true, false", m["enabled"])
+				}
+				spec.Enabled = &enabled
+			}
+
+			specs = append(specs, spec)
+		}
+
+	default:
+		return nil, fmt.Errorf("format %s not supported. Supported values are 'yaml','csv'", format)
+	}
+
+	return specs, nil
+}
+
+//renderFirewallRuleSpecs writes a list of rules in yaml or csv format
+func renderFirewallRuleSpecs(specs []firewallRuleSpec, format string) (string, error) {
+	switch format {
+	case "yaml":
+		if len(specs) == 0 {
+			return "[]\n", nil
+		}
+
+		bytes, err := yaml.Marshal(specs)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+
+	case "csv":
+		var sb strings.Builder
+
+		writer := csv.NewWriter(&sb)
+		writer.Write(firewallRuleSpecColumns)
+		for _, s := range specs {
+			enabled := ""
+			if s.Enabled != nil {
+				enabled = strconv.FormatBool(*s.Enabled)
+			}
+			writer.Write([]string{s.Protocol, s.Port, s.Source, s.Destination, s.Type, s.Description, enabled})
+		}
+		writer.Flush()
+
+		return sb.String(), writer.Error()
+	}
+
+	return "", fmt.Errorf("format %s not supported. Supported values are 'yaml','csv'", format)
+}
+
+//firewallRuleFromSpec converts a rule read from a file to a firewall rule, validating its values
+func firewallRuleFromSpec(spec firewallRuleSpec) (metalcloud.FirewallRule, error) {
+	var err error
+
+	fw := metalcloud.FirewallRule{
+		FirewallRuleProtocol:      spec.Protocol,
+		FirewallRuleIPAddressType: spec.Type,
+		FirewallRuleDescription:   spec.Description,
+		FirewallRuleEnabled:       spec.Enabled == nil || *spec.Enabled,
+	}
+
+	if fw.FirewallRuleIPAddressType == "" {
+		fw.FirewallRuleIPAddressType = "ipv4"
+	}
+
+	if !stringInSlice(fw.FirewallRuleIPAddressType, firewallRuleIPAddressTypes) {
+		return fw, fmt.Errorf("invalid type %s. Possible values: %s", spec.Type, strings.Join(firewallRuleIPAddressTypes, ", "))
+	}
+
+	if fw.FirewallRuleProtocol != "" && !stringInSlice(fw.FirewallRuleProtocol, firewallRuleProtocols) {
+		return fw, fmt.Errorf("invalid protocol %s. Possible values: %s", spec.Protocol, strings.Join(firewallRuleProtocols, ", "))
+	}
+
+	if spec.Port != "" && spec.Port != "any" {
+		fw.FirewallRulePortRangeStart, fw.FirewallRulePortRangeEnd, err = portStringToRange(spec.Port)
+		if err != nil {
+			return fw, err
+		}
+	}
+
+	if spec.Source != "" && spec.Source != "any" {
+		fw.FirewallRuleSourceIPAddressRangeStart, fw.FirewallRuleSourceIPAddressRangeEnd, err = addressStringToRange(spec.Source)
+		if err != nil {
+			return fw, err
+		}
+	}
+
+	if spec.Destination != "" && spec.Destination != "any" {
+		fw.FirewallRuleDestinationIPAddressRangeStart, fw.FirewallRuleDestinationIPAddressRangeEnd, err = addressStringToRange(spec.Destination)
+		if err != nil {
+			return fw, err
+		}
+	}
+
+	//the addresses must be of the rule's type. The API does not support CIDRs so they are converted to the range of their addresses.
+	for _, r := range []struct {
+		start *string
+		end   *string
+	}{
+		{&fw.FirewallRuleSourceIPAddressRangeStart, &fw.FirewallRuleSourceIPAddressRangeEnd},
+		{&fw.FirewallRuleDestinationIPAddressRangeStart, &fw.FirewallRuleDestinationIPAddressRangeEnd},
+	} {
+		if *r.start == "" {
+			continue
+		}
+
+		start, err := parseIPRangeBoundary(*r.start, fw.FirewallRuleIPAddressType, false)
+		if err != nil {
+			return fw, err
+		}
+
+		end, err := parseIPRangeBoundary(*r.end, fw.FirewallRuleIPAddressType, true)
+		if err != nil {
+			return fw, err
+		}
+
+		if strings.Contains(*r.start, "/") {
+			*r.start = start.String()
+		}
+		if strings.Contains(*r.end, "/") {
+			*r.end = end.String()
+		}
+	}
+
+	return fw, nil
+}
+
+//firewallRuleToSpec converts a firewall rule to the representation used in files
+func firewallRuleToSpec(fw metalcloud.FirewallRule) firewallRuleSpec {
+	enabled := fw.FirewallRuleEnabled
+
+	return firewallRuleSpec{
+		Protocol:    fw.FirewallRuleProtocol,
+		Port:        portRangeToString(fw.FirewallRulePortRangeStart, fw.FirewallRulePortRangeEnd),
+		Source:      addressRangeToString(fw.FirewallRuleSourceIPAddressRangeStart, fw.FirewallRuleSourceIPAddressRangeEnd),
+		Destination: addressRangeToString(fw.FirewallRuleDestinationIPAddressRangeStart, fw.FirewallRuleDestinationIPAddressRangeEnd),
+		Type:        fw.FirewallRuleIPAddressType,
+		Description: fw.FirewallRuleDescription,
+		Enabled:     &enabled,
+	}
+}
+
+//portRangeToString is the reverse of portStringToRange. An empty string is returned for any port.
+func portRangeToString(start int, end int) string {
+	if start == 0 {
+		return ""
+	}
+	if end == 0 || start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+//addressRangeToString is the reverse of addressStringToRange. An empty string is returned for any address.
+func addressRangeToString(start string, end string) string {
+	if start == "" {
+		return ""
+	}
+	if end == "" || start == end {
+		return start
+	}
+	return fmt.Sprintf("%s-%s", start, end)
+}
+
+//firewallRulesSame returns true if the rules filter the same traffic
+func firewallRulesSame(a, b metalcloud.FirewallRule) bool {
+	return fwRulesEqual(a, b) && a.FirewallRuleIPAddressType == b.FirewallRuleIPAddressType
+}
+
+//ipRange is an inclusive range of addresses, stored in the 16 bytes form
+type ipRange struct {
+	start net.IP
+	end   net.IP
+}
+
+//contains returns true if the address is in the range
+func (r ipRange) contains(ip net.IP) bool {
+	ip = ip.To16()
+	return bytes.Compare(r.start, ip) <= 0 && bytes.Compare(ip, r.end) <= 0
+}
+
+//covers returns true if all the addresses of o are in the range
+func (r ipRange) covers(o ipRange) bool {
+	return bytes.Compare(r.start, o.start) <= 0 && bytes.Compare(o.end, r.end) <= 0
+}
+
+//overlaps returns true if the ranges have addresses in common
+func (r ipRange) overlaps(o ipRange) bool {
+	return bytes.Compare(r.start, o.end) <= 0 && bytes.Compare(o.start, r.end) <= 0
+}
+
+//isAny returns true if the range contains all the addresses of the given type
+func (r ipRange) isAny(ipAddressType string) bool {
+	all, err := getIPRange("", "", ipAddressType)
+	return err == nil && r.covers(all)
+}
+
+//getIPRange returns the range of a rule's address. Empty addresses match all the addresses of the type.
+//A CIDR such as 10.0.0.0/24 is also accepted instead of an address.
+func getIPRange(start string, end string, ipAddressType string) (ipRange, error) {
+	if start == "" {
+		if ipAddressType == "ipv6" {
+			return ipRange{
+				start: net.IPv6zero.To16(),
+				end:   net.IP(bytes.Repeat([]byte{0xff}, net.IPv6len)),
+			}, nil
+		}
+		return ipRange{
+			start: net.IPv4zero.To16(),
+			end:   net.IPv4bcast.To16(),
+		}, nil
+	}
+
+	if end == "" {
+		end = start
+	}
+
+	s, err := parseIPRangeBoundary(start, ipAddressType, false)
+	if err != nil {
+		return ipRange{}, err
+	}
+
+	e, err := parseIPRangeBoundary(end, ipAddressType, true)
+	if err != nil {
+		return ipRange{}, err
+	}
+
+	if bytes.Compare(s, e) > 0 {
+		return ipRange{}, fmt.Errorf("invalid address range %s-%s", start, end)
+	}
+
+	return ipRange{start: s, end: e}, nil
+}
+
+//parseIPRangeBoundary parses an address or a CIDR. For a CIDR the first address is returned or the last one if last is set.
+func parseIPRangeBoundary(s string, ipAddressType string, last bool) (net.IP, error) {
+	var ip net.IP
+
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse address %s", s)
+		}
+
+		ip = network.IP
+		if last {
+			ip = make(net.IP, len(network.IP))
+			for i := range network.IP {
+				ip[i] = network.IP[i] | ^network.Mask[i]
+			}
+		}
+	} else {
+		ip = net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("cannot parse address %s", s)
+		}
+	}
+
+	isIPv4 := ip.To4() != nil
+	if isIPv4 != (ipAddressType != "ipv6") {
+		return nil, fmt.Errorf("address %s is not of type %s", s, ipAddressType)
+	}
+
+	return ip.To16(), nil
+}
+
+//getPortRange returns the range of a rule's ports. A rule without ports matches all the ports.
+func getPortRange(start int, end int) (int, int) {
+	if start == 0 {
+		return 0, 65535
+	}
+	if end == 0 {
+		return start, start
+	}
+	return start, end
+}
+
+//protocolCovers returns true if all the traffic of protocol b is also of protocol a
+func protocolCovers(a string, b string) bool {
+	return a == "" || a == "all" || a == b
+}
+
+//firewallRuleRanges are the ranges of traffic filtered by a rule
+type firewallRuleRanges struct {
+	ipAddressType string
+	protocol      string
+	portStart     int
+	portEnd       int
+	source        ipRange
+	destination   ipRange
+}
+
+func getFirewallRuleRanges(fw metalcloud.FirewallRule) (firewallRuleRanges, error) {
+	var err error
+
+	r := firewallRuleRanges{
+		ipAddressType: fw.FirewallRuleIPAddressType,
+		protocol:      fw.FirewallRuleProtocol,
+	}
+
+	if r.ipAddressType == "" {
+		r.ipAddressType = "ipv4"
+	}
+
+	r.portStart, r.portEnd = getPortRange(fw.FirewallRulePortRangeStart, fw.FirewallRulePortRangeEnd)
+
+	r.source, err = getIPRange(fw.FirewallRuleSourceIPAddressRangeStart, fw.FirewallRuleSourceIPAddressRangeEnd, r.ipAddressType)
+	if err != nil {
+		return r, err
+	}
+
+	r.destination, err = getIPRange(fw.FirewallRuleDestinationIPAddressRangeStart, fw.FirewallRuleDestinationIPAddressRangeEnd, r.ipAddressType)
+	if err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+//covers returns true if all the traffic filtered by o is also filtered by r
+func (r firewallRuleRanges) covers(o firewallRuleRanges) bool {
+	return r.ipAddressType == o.ipAddressType &&
+		protocolCovers(r.protocol, o.protocol) &&
+		r.portStart <= o.portStart && o.portEnd <= r.portEnd &&
+		r.source.covers(o.source) &&
+		r.destination.covers(o.destination)
+}
+
+//overlaps returns true if some traffic is filtered by both r and o
+func (r firewallRuleRanges) overlaps(o firewallRuleRanges) bool {
+	return r.ipAddressType == o.ipAddressType &&
+		(protocolCovers(r.protocol, o.protocol) || protocolCovers(o.protocol, r.protocol)) &&
+		r.portStart <= o.portEnd && o.portStart <= r.portEnd &&
+		r.source.overlaps(o.source) &&
+		r.destination.overlaps(o.destination)
+}
+
+//getFirewallRuleConflicts returns, for each rule, the rules before it that shadow it, that it covers or that overlap with it.
+//A rule is shadowed if all its traffic is already matched by a rule before it. Rules are numbered from 0, as in firewall-rule list.
+func getFirewallRuleConflicts(rules []metalcloud.FirewallRule) map[int][]string {
+	conflicts := map[int][]string{}
+
+	ranges := []*firewallRuleRanges{}
+	for _, fw := range rules {
+		r, err := getFirewallRuleRanges(fw)
+		if err != nil {
+			//rules that cannot be parsed are not compared
+			ranges = append(ranges, nil)
+			continue
+		}
+		ranges = append(ranges, &r)
+	}
+
+	for j := range ranges {
+		if ranges[j] == nil {
+			continue
+		}
+		for i := 0; i < j; i++ {
+			if ranges[i] == nil {
+				continue
+			}
+
+			switch {
+			case ranges[i].covers(*ranges[j]):
+				conflicts[j] = append(conflicts[j], fmt.Sprintf("shadowed by rule %d", i))
+			case ranges[j].covers(*ranges[i]):
+				conflicts[j] = append(conflicts[j], fmt.Sprintf("covers rule %d", i))
+			case ranges[i].overlaps(*ranges[j]):
+				conflicts[j] = append(conflicts[j], fmt.Sprintf("overlaps rule %d", i))
+			}
+		}
+	}
+
+	return conflicts
+}
+
+//firewallPacket is the traffic evaluated by firewall-rule check. A nil destination only matches the rules
+//that are not restricted to a destination, as there is no way to know if the traffic would match the others.
+type firewallPacket struct {
+	ipAddressType string
+	protocol      string
+	port          int
+	source        net.IP
+	destination   net.IP
+}
+
+func getFirewallPacket(protocol string, port int, source string, destination string) (firewallPacket, error) {
+	p := firewallPacket{
+		protocol: protocol,
+		port:     port,
+	}
+
+	if !stringInSlice(protocol, []string{"icmp", "tcp", "udp"}) {
+		return p, fmt.Errorf("invalid protocol %s. Possible values: icmp, tcp, udp", protocol)
+	}
+
+	if protocol == "icmp" {
+		if port != 0 {
+			return p, fmt.Errorf("icmp packets do not have a port")
+		}
+	} else if port < 1 || port > 65535 {
+		return p, fmt.Errorf("a port between 1 and 65535 is required for %s packets", protocol)
+	}
+
+	p.source = net.ParseIP(source)
+	if p.source == nil {
+		return p, fmt.Errorf("cannot parse source address %s", source)
+	}
+
+	p.ipAddressType = "ipv6"
+	if p.source.To4() != nil {
+		p.ipAddressType = "ipv4"
+	}
+
+	if destination != "" {
+		p.destination = net.ParseIP(destination)
+		if p.destination == nil {
+			return p, fmt.Errorf("cannot parse destination address %s", destination)
+		}
+
+		if (p.destination.To4() != nil) != (p.ipAddressType == "ipv4") {
+			return p, fmt.Errorf("the source and destination addresses must be of the same type")
+		}
+	}
+
+	return p, nil
+}
+
+//matches returns true if the packet is part of the traffic filtered by r. Ports are not checked for icmp packets.
+func (r firewallRuleRanges) matches(p firewallPacket) bool {
+	return r.ipAddressType == p.ipAddressType &&
+		protocolCovers(r.protocol, p.protocol) &&
+		(p.protocol == "icmp" || (r.portStart <= p.port && p.port <= r.portEnd)) &&
+		r.source.contains(p.source) &&
+		((p.destination == nil && r.destination.isAny(r.ipAddressType)) || (p.destination != nil && r.destination.contains(p.destination)))
+}
+
+//getFirewallRuleMatch returns the index of the first enabled rule that matches the packet or -1 if no rule matches.
+//Rules that cannot be parsed are skipped and a warning is returned for each of them, as they could change the verdict.
+func getFirewallRuleMatch(rules []metalcloud.FirewallRule, p firewallPacket) (int, []string) {
+	warnings := []string{}
+
+	for i, fw := range rules {
+		if !fw.FirewallRuleEnabled {
+			continue
+		}
+
+		r, err := getFirewallRuleRanges(fw)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("rule %d was skipped: %s", i, err))
+			continue
+		}
+
+		if r.matches(p) {
+			return i, warnings
+		}
+	}
+
+	return -1, warnings
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"testing"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseFirewallRuleSpecs(t *testing.T) {
+	RegisterTestingT(t)
+
+	yamlContent := `
+- protocol: tcp
+  port: 22
+  source: 10.0.0.0/24
+  description: ssh
+- protocol: udp
+  port: 53-54
+  type: ipv6
+  enabled: false
+`
+	csvContent := "protocol, port, source, destination, type, description, enabled\ntcp,22,10.0.0.0/24,,,ssh,\nudp,53-54,,,ipv6,,false\n"
+
+	disabled := false
+	expected := []firewallRuleSpec{
+		{Protocol: "tcp", Port: "22", Source: "10.0.0.0/24", Description: "ssh"},
+		{Protocol: "udp", Port: "53-54", Type: "ipv6", Enabled: &disabled},
+	}
+
+	specs, err := parseFirewallRuleSpecs([]byte(yamlContent), "yaml")
+	Expect(err).To(BeNil())
+	Expect(specs).To(Equal(expected))
+
+	specs, err = parseFirewallRuleSpecs([]byte(csvContent), "csv")
+	Expect(err).To(BeNil())
+	Expect(specs).To(Equal(expected))
+
+	_, err = parseFirewallRuleSpecs([]byte("protocol,ports\ntcp,22\n"), "csv")
+	Expect(err).NotTo(BeNil())
+
+	_, err = parseFirewallRuleSpecs([]byte("protocol,enabled\ntcp,maybe\n"), "csv")
+	Expect(err).NotTo(BeNil())
+
+	//rules are enabled unless disabled in the file
+	fw, err := firewallRuleFromSpec(expected[0])
+	Expect(err).To(BeNil())
+	Expect(fw.FirewallRuleEnabled).To(BeTrue())
+
+	fw, err = firewallRuleFromSpec(expected[1])
+	Expect(err).To(BeNil())
+	Expect(fw.FirewallRuleEnabled).To(BeFalse())
+
+	//export and read back
+	for _, format := range []string{"yaml", "csv"} {
+		out, err := renderFirewallRuleSpecs(expected, format)
+		Expect(err).To(BeNil())
+
+		specs, err = parseFirewallRuleSpecs([]byte(out), format)
+		Expect(err).To(BeNil())
+		Expect(specs).To(Equal(expected))
+	}
+
+	format, err := getFirewallRuleSpecsFormat("", "rules.CSV")
+	Expect(err).To(BeNil())
+	Expect(format).To(Equal("csv"))
+
+	_, err = getFirewallRuleSpecsFormat("json", "rules.json")
+	Expect(err).NotTo(BeNil())
+}
+
+func TestFirewallRuleFromSpec(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		spec     firewallRuleSpec
+		expected metalcloud.FirewallRule
+		good     bool
+	}{
+		{
+			spec: firewallRuleSpec{Protocol: "tcp", Port: "80-443", Source: "10.0.0.1-10.0.0.10"},
+			expected: metalcloud.FirewallRule{
+				FirewallRuleProtocol:                  "tcp",
+				FirewallRulePortRangeStart:            80,
+				FirewallRulePortRangeEnd:              443,
+				FirewallRuleSourceIPAddressRangeStart: "10.0.0.1",
+				FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.10",
+				FirewallRuleIPAddressType:             "ipv4",
+				FirewallRuleEnabled:                   true,
+			},
+			good: true,
+		},
+		{
+			spec: firewallRuleSpec{Port: "any", Destination: "2001:db8::/32", Type: "ipv6"},
+			expected: metalcloud.FirewallRule{
+				FirewallRuleDestinationIPAddressRangeStart: "2001:db8::",
+				FirewallRuleDestinationIPAddressRangeEnd:   "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+				FirewallRuleIPAddressType:                  "ipv6",
+				FirewallRuleEnabled:                        true,
+			},
+			good: true,
+		},
+		{
+			spec: firewallRuleSpec{Protocol: "tcp", Port: "22", Source: "10.0.0.0/24"},
+			expected: metalcloud.FirewallRule{
+				FirewallRuleProtocol:                  "tcp",
+				FirewallRulePortRangeStart:            22,
+				FirewallRulePortRangeEnd:              22,
+				FirewallRuleSourceIPAddressRangeStart: "10.0.0.0",
+				FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.255",
+				FirewallRuleIPAddressType:             "ipv4",
+				FirewallRuleEnabled:                   true,
+			},
+			good: true,
+		},
+		{
+			spec: firewallRuleSpec{Protocol: "sctp"},
+			good: false,
+		},
+		{
+			spec: firewallRuleSpec{Type: "ipv5"},
+			good: false,
+		},
+		{
+			spec: firewallRuleSpec{Port: "80-"},
+			good: false,
+		},
+		{
+			spec: firewallRuleSpec{Source: "2001:db8::1"},
+			good: false,
+		},
+		{
+			spec: firewallRuleSpec{Source: "10.0.0.300"},
+			good: false,
+		},
+	}
+
+	for _, c := range cases {
+		fw, err := firewallRuleFromSpec(c.spec)
+		if c.good {
+			Expect(err).To(BeNil())
+			Expect(fw).To(Equal(c.expected))
+		} else {
+			Expect(err).NotTo(BeNil())
+		}
+	}
+}
+
+func TestGetFirewallRuleConflicts(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules := []metalcloud.FirewallRule{
+		//0: ssh from a /24
+		{
+			FirewallRuleProtocol:                  "tcp",
+			FirewallRulePortRangeStart:            22,
+			FirewallRuleSourceIPAddressRangeStart: "10.0.0.0/24",
+			FirewallRuleIPAddressType:             "ipv4",
+		},
+		//1: ssh from a single address of the /24 is shadowed by 0
+		{
+			FirewallRuleProtocol:                  "tcp",
+			FirewallRulePortRangeStart:            22,
+			FirewallRulePortRangeEnd:              22,
+			FirewallRuleSourceIPAddressRangeStart: "10.0.0.10",
+			FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.10",
+			FirewallRuleIPAddressType:             "ipv4",
+		},
+		//2: a port range including 22 from a range crossing the /24 overlaps 0 and covers 1
+		{
+			FirewallRuleProtocol:                  "tcp",
+			FirewallRulePortRangeStart:            20,
+			FirewallRulePortRangeEnd:              30,
+			FirewallRuleSourceIPAddressRangeStart: "10.0.0.5",
+			FirewallRuleSourceIPAddressRangeEnd:   "10.0.1.5",
+			FirewallRuleIPAddressType:             "ipv4",
+		},
+		//3: udp does not conflict with tcp
+		{
+			FirewallRuleProtocol:       "udp",
+			FirewallRulePortRangeStart: 22,
+			FirewallRuleIPAddressType:  "ipv4",
+		},
+		//4: the same rule for ipv6 does not conflict with ipv4 rules
+		{
+			FirewallRuleProtocol:       "tcp",
+			FirewallRulePortRangeStart: 22,
+			FirewallRuleIPAddressType:  "ipv6",
+		},
+		//5: all protocols on all ports covers 4
+		{
+			FirewallRuleIPAddressType: "ipv6",
+		},
+	}
+
+	conflicts := getFirewallRuleConflicts(rules)
+
+	Expect(conflicts).To(Equal(map[int][]string{
+		1: {"shadowed by rule 0"},
+		2: {"overlaps rule 0", "covers rule 1"},
+		5: {"covers rule 4"},
+	}))
+}
+
+func TestGetFirewallPacket(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		name        string
+		protocol    string
+		port        int
+		source      string
+		destination string
+		ipType      string
+		good        bool
+	}{
+		{name: "tcp ipv4", protocol: "tcp", port: 443, source: "10.0.0.5", ipType: "ipv4", good: true},
+		{name: "udp ipv6", protocol: "udp", port: 53, source: "2001:db8::1", destination: "2001:db8::2", ipType: "ipv6", good: true},
+		{name: "icmp without port", protocol: "icmp", source: "10.0.0.5", ipType: "ipv4", good: true},
+		{name: "icmp with port", protocol: "icmp", port: 22, source: "10.0.0.5"},
+		{name: "tcp without port", protocol: "tcp", source: "10.0.0.5"},
+		{name: "port too large", protocol: "tcp", port: 65536, source: "10.0.0.5"},
+		{name: "protocol all", protocol: "all", port: 22, source: "10.0.0.5"},
+		{name: "invalid protocol", protocol: "sctp", port: 22, source: "10.0.0.5"},
+		{name: "invalid source", protocol: "tcp", port: 22, source: "10.0.0"},
+		{name: "cidr source", protocol: "tcp", port: 22, source: "10.0.0.0/24"},
+		{name: "invalid destination", protocol: "tcp", port: 22, source: "10.0.0.5", destination: "host"},
+		{name: "mixed address types", protocol: "tcp", port: 22, source: "10.0.0.5", destination: "2001:db8::2"},
+	}
+
+	for _, c := range cases {
+		p, err := getFirewallPacket(c.protocol, c.port, c.source, c.destination)
+		if !c.good {
+			if err == nil {
+				t.Errorf("case %s: expected error", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("case %s: unexpected error %s", c.name, err)
+			continue
+		}
+
+		Expect(p.ipAddressType).To(Equal(c.ipType))
+	}
+}
+
+func TestGetFirewallRuleMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules := []metalcloud.FirewallRule{
+		{
+			//0: ssh from the office network
+			FirewallRuleProtocol:                  "tcp",
+			FirewallRulePortRangeStart:            22,
+			FirewallRulePortRangeEnd:              22,
+			FirewallRuleSourceIPAddressRangeStart: "10.0.0.0/24",
+			FirewallRuleSourceIPAddressRangeEnd:   "10.0.0.0/24",
+			FirewallRuleIPAddressType:             "ipv4",
+			FirewallRuleEnabled:                   true,
+		},
+		{
+			//1: disabled, would allow everything
+			FirewallRuleProtocol:      "all",
+			FirewallRuleIPAddressType: "ipv4",
+			FirewallRuleEnabled:       false,
+		},
+		{
+			//2: web ports from an address range to a single destination
+			FirewallRuleProtocol:                       "tcp",
+			FirewallRulePortRangeStart:                 80,
+			FirewallRulePortRangeEnd:                   443,
+			FirewallRuleSourceIPAddressRangeStart:      "192.168.1.10",
+			FirewallRuleSourceIPAddressRangeEnd:        "192.168.1.20",
+			FirewallRuleDestinationIPAddressRangeStart: "172.16.0.1",
+			FirewallRuleDestinationIPAddressRangeEnd:   "172.16.0.1",
+			FirewallRuleIPAddressType:                  "ipv4",
+			FirewallRuleEnabled:                        true,
+		},
+		{
+			//3: invalid rule, skipped with a warning
+			FirewallRuleProtocol:                  "tcp",
+			FirewallRuleSourceIPAddressRangeStart: "not-an-address",
+			FirewallRuleIPAddressType:             "ipv4",
+			FirewallRuleEnabled:                   true,
+		},
+		{
+			//4: dns from an ipv6 network
+			FirewallRuleProtocol:                  "udp",
+			FirewallRulePortRangeStart:            53,
+			FirewallRulePortRangeEnd:              53,
+			FirewallRuleSourceIPAddressRangeStart: "2001:db8::/32",
+			FirewallRuleSourceIPAddressRangeEnd:   "2001:db8::/32",
+			FirewallRuleIPAddressType:             "ipv6",
+			FirewallRuleEnabled:                   true,
+		},
+		{
+			//5: any ipv6 traffic
+			FirewallRuleProtocol:      "all",
+			FirewallRuleIPAddressType: "ipv6",
+			FirewallRuleEnabled:       true,
+		},
+		{
+			//6: icmp from anywhere, type missing means ipv4
+			FirewallRuleProtocol: "icmp",
+			FirewallRuleEnabled:  true,
+		},
+		{
+			//7: a port range applies to both tcp and udp when the protocol is not set
+			FirewallRulePortRangeStart: 8000,
+			FirewallRulePortRangeEnd:   8100,
+			FirewallRuleEnabled:        true,
+		},
+	}
+
+	cases := []struct {
+		name        string
+		protocol    string
+		port        int
+		source      string
+		destination string
+		expected    int
+	}{
+		{name: "ssh from the office", protocol: "tcp", port: 22, source: "10.0.0.5", expected: 0},
+		{name: "ssh from the first office address", protocol: "tcp", port: 22, source: "10.0.0.0", expected: 0},
+		{name: "ssh from the last office address", protocol: "tcp", port: 22, source: "10.0.0.255", expected: 0},
+		{name: "ssh from outside the office", protocol: "tcp", port: 22, source: "10.0.1.5", expected: -1},
+		{name: "udp on the ssh port", protocol: "udp", port: 22, source: "10.0.0.5", expected: -1},
+		{name: "web start of port range", protocol: "tcp", port: 80, source: "192.168.1.10", destination: "172.16.0.1", expected: 2},
+		{name: "web without destination does not match the rule restricted to a destination", protocol: "tcp", port: 80, source: "192.168.1.10", expected: -1},
+		{name: "web end of port range", protocol: "tcp", port: 443, source: "192.168.1.20", destination: "172.16.0.1", expected: 2},
+		{name: "web port outside range", protocol: "tcp", port: 444, source: "192.168.1.15", expected: -1},
+		{name: "web source outside range", protocol: "tcp", port: 443, source: "192.168.1.21", expected: -1},
+		{name: "web other destination", protocol: "tcp", port: 443, source: "192.168.1.15", destination: "172.16.0.2", expected: -1},
+		{name: "dns over ipv6", protocol: "udp", port: 53, source: "2001:db8:1::5", expected: 4},
+		{name: "tcp dns over ipv6 is matched by the catch all rule", protocol: "tcp", port: 53, source: "2001:db8:1::5", expected: 5},
+		{name: "ipv4 mapped ipv6 address is ipv4", protocol: "tcp", port: 22, source: "::ffff:10.0.0.5", expected: 0},
+		{name: "ping over ipv4", protocol: "icmp", source: "8.8.8.8", expected: 6},
+		{name: "ping over ipv6", protocol: "icmp", source: "2001:4860::8888", expected: 5},
+		{name: "tcp on rule without protocol", protocol: "tcp", port: 8080, source: "8.8.8.8", expected: 7},
+		{name: "udp on rule without protocol", protocol: "udp", port: 8100, source: "8.8.8.8", expected: 7},
+		{name: "default verdict", protocol: "udp", port: 8101, source: "8.8.8.8", expected: -1},
+	}
+
+	for _, c := range cases {
+		p, err := getFirewallPacket(c.protocol, c.port, c.source, c.destination)
+		if err != nil {
+			t.Errorf("case %s: unexpected error %s", c.name, err)
+			continue
+		}
+
+		ret, warnings := getFirewallRuleMatch(rules, p)
+		if ret != c.expected {
+			t.Errorf("case %s: expected rule %d, got %d", c.name, c.expected, ret)
+		}
+
+		//the invalid rule is reported only if it was reached
+		if (ret == -1 || ret > 3) != (len(warnings) == 1) {
+			t.Errorf("case %s: unexpected warnings %v", c.name, warnings)
+		}
+	}
+
+	p, err := getFirewallPacket("tcp", 22, "10.0.0.5", "")
+	Expect(err).To(BeNil())
+
+	ret, warnings := getFirewallRuleMatch([]metalcloud.FirewallRule{}, p)
+	Expect(ret).To(Equal(-1))
+	Expect(warnings).To(BeEmpty())
+
+	_, warnings = getFirewallRuleMatch(rules, firewallPacket{ipAddressType: "ipv4", protocol: "udp", port: 1, source: p.source})
+	Expect(warnings).To(HaveLen(1))
+	Expect(warnings[0]).To(HavePrefix("rule 3 was skipped: "))
+}
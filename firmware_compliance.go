@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//firmwareComplianceStatuses are the statuses of a component in the order they are reported
+var firmwareComplianceStatuses = []string{"compliant", "outdated", "unknown"}
+
+//firmwareComponentCompliance is the firmware status of a server's component
+type firmwareComponentCompliance struct {
+	server    metalcloud.ServerSearchResult
+	component metalcloud.ServerComponent
+	expected  string
+	status    string
+	reason    string
+}
+
+//getFirmwarePolicyRuleProperty returns the value of a property firmware policy rules can be evaluated on
+func getFirmwarePolicyRuleProperty(property string, s metalcloud.ServerSearchResult, c metalcloud.ServerComponent) (string, bool) {
+	switch property {
+	case "server_component_name":
+		return c.ServerComponentName, true
+	case "server_component_type":
+		return c.ServerComponentType, true
+	case "server_component_firmware_version":
+		return c.ServerComponentFirmwareVersion, true
+	case "server_vendor":
+		return s.ServerVendor, true
+	case "server_product_name":
+		return s.ServerProductName, true
+	case "server_type_name":
+		return s.ServerTypeName, true
+	}
+	return "", false
+}
+
+//firmwarePolicyRuleOperations are the operations firmware policy rules can use. Strings are compared case insensitive.
+var firmwarePolicyRuleOperations = map[string]func(actual string, value string) bool{
+	"string_equal":     strings.EqualFold,
+	"string_not_equal": func(actual string, value string) bool { return !strings.EqualFold(actual, value) },
+	"string_contains": func(actual string, value string) bool {
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(value))
+	},
+}
+
+//validateFirmwarePolicy checks that the rules of the policy can be evaluated
+func validateFirmwarePolicy(policy metalcloud.ServerFirmwareUpgradePolicy) error {
+	for i, r := range policy.ServerFirmwareUpgradePolicyRules {
+		if _, ok := getFirmwarePolicyRuleProperty(r.Property, metalcloud.ServerSearchResult{}, metalcloud.ServerComponent{}); !ok {
+			return fmt.Errorf("rule %d of firmware policy %s (#%d): unsupported property %s", i, policy.ServerFirmwareUpgradePolicyLabel, policy.ServerFirmwareUpgradePolicyID, r.Property)
+		}
+		if _, ok := firmwarePolicyRuleOperations[r.Operation]; !ok {
+			return fmt.Errorf("rule %d of firmware policy %s (#%d): unsupported operation %s", i, policy.ServerFirmwareUpgradePolicyLabel, policy.ServerFirmwareUpgradePolicyID, r.Operation)
+		}
+	}
+	return nil
+}
+
+//firmwarePolicyMatches returns true if the policy is assigned to an instance array of the server and all its rules match the component.
+//A policy without rules matches nothing. The policy must be valid.
+func firmwarePolicyMatches(policy metalcloud.ServerFirmwareUpgradePolicy, s metalcloud.ServerSearchResult, c metalcloud.ServerComponent) bool {
+	if len(policy.ServerFirmwareUpgradePolicyRules) == 0 {
+		return false
+	}
+
+	assigned := false
+	for _, id := range s.InstanceArrayID {
+		for _, policyID := range policy.InstanceArrayIDList {
+			if id == policyID {
+				assigned = true
+			}
+		}
+	}
+	if !assigned {
+		return false
+	}
+
+	for _, r := range policy.ServerFirmwareUpgradePolicyRules {
+		actual, _ := getFirmwarePolicyRuleProperty(r.Property, s, c)
+		if !firmwarePolicyRuleOperations[r.Operation](actual, r.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+//splitFirmwareVersion splits a version in runs of letters and runs of digits, for example A22-b1 in a, 22, b and 1
+func splitFirmwareVersion(version string) []string {
+	parts := []string{}
+	part := []rune{}
+	for _, r := range strings.ToLower(version) {
+		if len(part) > 0 && (!unicode.IsLetter(r) && !unicode.IsDigit(r) || unicode.IsDigit(r) != unicode.IsDigit(part[0])) {
+			parts = append(parts, string(part))
+			part = []rune{}
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			part = append(part, r)
+		}
+	}
+	if len(part) > 0 {
+		parts = append(parts, string(part))
+	}
+	return parts
+}
+
+//compareFirmwareVersions compares two versions such as 2.10.1 and 2.9.3 part by part, numerically when both parts are numbers.
+//Letters and digits are separate parts so A9 is older than A22.
+//Returns -1, 0 or 1 if a is older, the same or newer than b.
+func compareFirmwareVersions(a string, b string) int {
+	partsA := splitFirmwareVersion(a)
+	partsB := splitFirmwareVersion(b)
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+
+		switch {
+		case errA == nil && errB == nil && numA < numB:
+			return -1
+		case errA == nil && errB == nil && numA > numB:
+			return 1
+		case errA == nil && errB == nil:
+			continue
+		case partsA[i] < partsB[i]:
+			return -1
+		case partsA[i] > partsB[i]:
+			return 1
+		}
+	}
+
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	}
+
+	return 0
+}
+
+//getLatestFirmwareVersion returns the newest of the versions or an empty string if there are none
+func getLatestFirmwareVersion(versions []string) string {
+	latest := ""
+	for _, v := range versions {
+		if latest == "" || compareFirmwareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+//getFirmwareComponentCompliance checks the version of the component against its target version.
+//Components without a target version are checked against the latest available version if a policy matches them.
+func getFirmwareComponentCompliance(s metalcloud.ServerSearchResult, c metalcloud.ServerComponent, policies []metalcloud.ServerFirmwareUpgradePolicy) firmwareComponentCompliance {
+	ret := firmwareComponentCompliance{
+		server:    s,
+		component: c,
+		status:    "unknown",
+	}
+
+	if !c.ServerComponentFirmwareUpdateable {
+		ret.reason = "firmware not updateable"
+		return ret
+	}
+
+	if c.ServerComponentFirmwareVersion == "" {
+		ret.reason = "version not reported"
+		return ret
+	}
+
+	if c.ServerComponentFirmwareTargetVersion != "" {
+		ret.expected = c.ServerComponentFirmwareTargetVersion
+		ret.reason = "target version"
+	} else {
+		for _, p := range policies {
+			if !firmwarePolicyMatches(p, s, c) {
+				continue
+			}
+
+			ret.expected = getLatestFirmwareVersion(c.ServerComponentFirmwareUpdateAvailableVersions)
+			ret.reason = fmt.Sprintf("latest version, policy %s (#%d)", p.ServerFirmwareUpgradePolicyLabel, p.ServerFirmwareUpgradePolicyID)
+
+			if ret.expected == "" {
+				ret.reason = fmt.Sprintf("no available versions, policy %s (#%d)", p.ServerFirmwareUpgradePolicyLabel, p.ServerFirmwareUpgradePolicyID)
+				return ret
+			}
+
+			break
+		}
+	}
+
+	if ret.expected == "" {
+		ret.reason = "no target version or matching policy"
+		return ret
+	}
+
+	if compareFirmwareVersions(c.ServerComponentFirmwareVersion, ret.expected) >= 0 {
+		ret.status = "compliant"
+	} else {
+		ret.status = "outdated"
+	}
+
+	return ret
+}
+
+//getServerFirmwareCompliance returns the compliance of the components of a server.
+//Server searches do not return the target versions so updateable components are retrieved one by one.
+//Components that cannot be retrieved are reported as unknown with the error as the reason, as is the
+//server itself, without a component, if its components cannot be listed.
+func getServerFirmwareCompliance(s metalcloud.ServerSearchResult, policies []metalcloud.ServerFirmwareUpgradePolicy, client metalcloud.MetalCloudClient) []firmwareComponentCompliance {
+	list, err := client.ServerComponents(s.ServerID, "")
+	if err != nil {
+		return []firmwareComponentCompliance{{server: s, status: "unknown", reason: err.Error()}}
+	}
+
+	ret := []firmwareComponentCompliance{}
+	for _, c := range *list {
+		if c.ServerComponentFirmwareUpdateable {
+			component, err := client.ServerComponentGet(c.ServerComponentID)
+			if err != nil {
+				ret = append(ret, firmwareComponentCompliance{server: s, component: c, status: "unknown", reason: err.Error()})
+				continue
+			}
+			c = *component
+		}
+
+		ret = append(ret, getFirmwareComponentCompliance(s, c, policies))
+	}
+
+	return ret
+}
+
+//getServersFirmwareCompliance returns the compliance of the components of the servers, retrieving at most concurrency servers in parallel.
+//The components are sorted by server id and component id.
+func getServersFirmwareCompliance(servers []metalcloud.ServerSearchResult, policies []metalcloud.ServerFirmwareUpgradePolicy, concurrency int, client metalcloud.MetalCloudClient) []firmwareComponentCompliance {
+	jobs := make(chan metalcloud.ServerSearchResult)
+	results := make(chan []firmwareComponentCompliance)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				results <- getServerFirmwareCompliance(s, policies, client)
+			}
+		}()
+	}
+
+	go func() {
+		for _, s := range servers {
+			jobs <- s
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	ret := []firmwareComponentCompliance{}
+	for r := range results {
+		ret = append(ret, r...)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].server.ServerID != ret[j].server.ServerID {
+			return ret[i].server.ServerID < ret[j].server.ServerID
+		}
+		return ret[i].component.ServerComponentID < ret[j].component.ServerComponentID
+	})
+
+	return ret
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestCompareFirmwareVersions(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"2.10.1", "2.9.3", 1},
+		{"2.9.3", "2.10.1", -1},
+		{"2.10.1", "2.10.1", 0},
+		{"2.10", "2.10.1", -1},
+		{"1.0.0-b2", "1.0.0-b1", 1},
+		{"A22", "a22", 0},
+		{"U30 v2.14", "U30 v2.15", -1},
+		{"A9", "A22", -1},
+		{"1.0.0b10", "1.0.0b9", 1},
+		{"P89", "p89", 0},
+	}
+
+	for _, c := range cases {
+		if ret := compareFirmwareVersions(c.a, c.b); ret != c.expected {
+			t.Errorf("case %s %s: expected %d got %d", c.a, c.b, c.expected, ret)
+		}
+	}
+
+	Expect(getLatestFirmwareVersion([]string{"2.9.3", "2.10.1", "2.10.0"})).To(Equal("2.10.1"))
+	Expect(getLatestFirmwareVersion([]string{})).To(Equal(""))
+}
+
+func TestValidateFirmwarePolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := metalcloud.ServerFirmwareUpgradePolicy{
+		ServerFirmwareUpgradePolicyRules: []metalcloud.ServerFirmwareUpgradePolicyRule{
+			{Operation: "string_equal", Property: "server_component_type", Value: "bios"},
+		},
+	}
+	Expect(validateFirmwarePolicy(policy)).To(BeNil())
+
+	policy.ServerFirmwareUpgradePolicyRules[0].Operation = "regex"
+	Expect(validateFirmwarePolicy(policy)).NotTo(BeNil())
+
+	policy.ServerFirmwareUpgradePolicyRules[0].Operation = "string_equal"
+	policy.ServerFirmwareUpgradePolicyRules[0].Property = "server_rack_name"
+	Expect(validateFirmwarePolicy(policy)).NotTo(BeNil())
+}
+
+func TestGetFirmwareComponentCompliance(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := metalcloud.ServerSearchResult{
+		ServerID:          1,
+		ServerVendor:      "Dell",
+		ServerProductName: "PowerEdge R640",
+		InstanceArrayID:   []int{50},
+	}
+
+	policies := []metalcloud.ServerFirmwareUpgradePolicy{
+		{
+			ServerFirmwareUpgradePolicyID:    10,
+			ServerFirmwareUpgradePolicyLabel: "bios-latest",
+			ServerFirmwareUpgradePolicyRules: []metalcloud.ServerFirmwareUpgradePolicyRule{
+				{Operation: "string_equal", Property: "server_component_type", Value: "BIOS"},
+				{Operation: "string_contains", Property: "server_product_name", Value: "r640"},
+			},
+			InstanceArrayIDList: []int{40, 50},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		component metalcloud.ServerComponent
+		status    string
+		expected  string
+	}{
+		{
+			name: "target version reached",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                  "nic",
+				ServerComponentFirmwareUpdateable:    true,
+				ServerComponentFirmwareVersion:       "20.5.13",
+				ServerComponentFirmwareTargetVersion: "20.5.13",
+			},
+			status:   "compliant",
+			expected: "20.5.13",
+		},
+		{
+			name: "target version not reached",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                            "bios",
+				ServerComponentFirmwareUpdateable:              true,
+				ServerComponentFirmwareVersion:                 "2.9.3",
+				ServerComponentFirmwareTargetVersion:           "2.10.1",
+				ServerComponentFirmwareUpdateAvailableVersions: []string{"2.10.1", "2.11.0"},
+			},
+			status:   "outdated",
+			expected: "2.10.1",
+		},
+		{
+			name: "policy, latest version",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                            "bios",
+				ServerComponentFirmwareUpdateable:              true,
+				ServerComponentFirmwareVersion:                 "2.11.0",
+				ServerComponentFirmwareUpdateAvailableVersions: []string{"2.10.1", "2.11.0"},
+			},
+			status:   "compliant",
+			expected: "2.11.0",
+		},
+		{
+			name: "policy, older version",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                            "bios",
+				ServerComponentFirmwareUpdateable:              true,
+				ServerComponentFirmwareVersion:                 "2.9.3",
+				ServerComponentFirmwareUpdateAvailableVersions: []string{"2.10.1", "2.11.0"},
+			},
+			status:   "outdated",
+			expected: "2.11.0",
+		},
+		{
+			name: "policy, no available versions",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:               "bios",
+				ServerComponentFirmwareUpdateable: true,
+				ServerComponentFirmwareVersion:    "2.9.3",
+			},
+			status: "unknown",
+		},
+		{
+			name: "no target version or policy",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                            "nic",
+				ServerComponentFirmwareUpdateable:              true,
+				ServerComponentFirmwareVersion:                 "20.5.13",
+				ServerComponentFirmwareUpdateAvailableVersions: []string{"21.0.0"},
+			},
+			status: "unknown",
+		},
+		{
+			name: "not updateable",
+			component: metalcloud.ServerComponent{
+				ServerComponentType:                  "bios",
+				ServerComponentFirmwareVersion:       "2.9.3",
+				ServerComponentFirmwareTargetVersion: "2.10.1",
+			},
+			status: "unknown",
+		},
+	}
+
+	for _, c := range cases {
+		ret := getFirmwareComponentCompliance(server, c.component, policies)
+		if ret.status != c.status {
+			t.Errorf("case %s: expected status %s got %s (%s)", c.name, c.status, ret.status, ret.reason)
+		}
+		if ret.expected != c.expected {
+			t.Errorf("case %s: expected version %s got %s", c.name, c.expected, ret.expected)
+		}
+	}
+
+	//the policy does not apply to the servers of other instance arrays
+	server.InstanceArrayID = []int{60}
+	Expect(firmwarePolicyMatches(policies[0], server, cases[2].component)).To(BeFalse())
+
+	server.InstanceArrayID = []int{50}
+	Expect(firmwarePolicyMatches(policies[0], server, cases[2].component)).To(BeTrue())
+
+	//a policy without rules matches nothing
+	Expect(firmwarePolicyMatches(metalcloud.ServerFirmwareUpgradePolicy{InstanceArrayIDList: []int{50}}, server, cases[2].component)).To(BeFalse())
+}
+
+func TestGetServerFirmwareComplianceErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	server := metalcloud.ServerSearchResult{ServerID: 1}
+
+	client.EXPECT().
+		ServerComponents(1, "").
+		Return(&[]metalcloud.ServerComponent{
+			{ServerComponentID: 11, ServerComponentFirmwareUpdateable: true},
+			{ServerComponentID: 12, ServerComponentFirmwareVersion: "1.0"},
+		}, nil).
+		Times(1)
+
+	client.EXPECT().
+		ServerComponentGet(11).
+		Return(nil, fmt.Errorf("timeout")).
+		Times(1)
+
+	ret := getServerFirmwareCompliance(server, nil, client)
+	Expect(ret).To(HaveLen(2))
+	Expect(ret[0].status).To(Equal("unknown"))
+	Expect(ret[0].reason).To(Equal("timeout"))
+	Expect(ret[1].reason).To(Equal("firmware not updateable"))
+
+	client.EXPECT().
+		ServerComponents(1, "").
+		Return(nil, fmt.Errorf("timeout")).
+		Times(1)
+
+	ret = getServerFirmwareCompliance(server, nil, client)
+	Expect(ret).To(HaveLen(1))
+	Expect(ret[0].status).To(Equal("unknown"))
+	Expect(ret[0].reason).To(Equal("timeout"))
+}
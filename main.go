@@ -48,23 +48,32 @@ func main() {
 
 	SetConsoleIOChannel(os.Stdin, os.Stdout)
 
-	clients, err := initClients()
+	profileName, args, err := extractProfileArgument(os.Args)
 	if err != nil {
-		fmt.Fprintf(GetStdout(), "Could not initialize metal cloud client %s\n", err)
+		fmt.Fprintf(GetStdout(), "%s\n", err)
 		os.Exit(-1)
 	}
 
-	if len(os.Args) < 2 {
+	clients, err := initClientsForProfile(profileName)
+	if err != nil {
+		if !isConfigCommand(args) {
+			fmt.Fprintf(GetStdout(), "Could not initialize metal cloud client %s\n", err)
+			os.Exit(-1)
+		}
+		clients = map[string]metalcloud.MetalCloudClient{"": nil}
+	}
+
+	if len(args) < 2 {
 		fmt.Fprintf(GetStdout(), "Invalid command! Use 'help' for a list of commands.\n")
 		os.Exit(-1)
 	}
 
-	if os.Args[1] == "help" {
+	if args[1] == "help" {
 		fmt.Fprintf(GetStdout(), "%s\n", getHelp(clients, false))
 		os.Exit(0)
 	}
 
-	if len(os.Args) == 1 {
+	if len(args) == 1 {
 		fmt.Fprint(GetStdout(), "Invalid command! Use 'help' for a list of commands\n")
 		os.Exit(-1)
 	}
@@ -73,7 +82,7 @@ func main() {
 
 	commands := getCommands(clients)
 
-	err = executeCommand(os.Args, commands, clients)
+	err = executeCommand(args, commands, clients)
 
 	if err != nil {
 		fmt.Fprintf(GetStdout(), "%s\n", err)
@@ -137,6 +146,9 @@ func executeCommand(args []string, commands []Command, clients map[string]metalc
 		cmd.Arguments["no_color"] = cmd.FlagSet.Bool("no-color", false, "Disable coloring.")
 	}
 
+	addOutputFlags(cmd)
+	addTableFlags(cmd)
+
 	//disable default usage
 	cmd.FlagSet.Usage = func() {}
 
@@ -163,7 +175,14 @@ func executeCommand(args []string, commands []Command, clients map[string]metalc
 		return fmt.Errorf("Client not set for endpoint %s on command %s %s", cmd.Endpoint, subject, predicate)
 	}
 
-	ret, err := cmd.ExecuteFunc(cmd, client)
+	spec, err := prepareOutput(cmd)
+	if err != nil {
+		return helpMessage(err, subject, predicate)
+	}
+
+	ret, err := withOutput(spec, func() (string, error) {
+		return cmd.ExecuteFunc(cmd, client)
+	})
 	if err != nil {
 		return helpMessage(err, subject, predicate)
 	}
@@ -276,7 +295,7 @@ func getHelp(clients map[string]metalcloud.MetalCloudClient, showArguments bool)
 	for _, c := range cmds {
 		c.InitFunc(&c)
 	}
-	sb.WriteString(fmt.Sprintf("Syntax: %s <command> [args]\nAccepted commands:\n", os.Args[0]))
+	sb.WriteString(fmt.Sprintf("Syntax: %s <command> [args] [--profile <name>]\nAccepted commands:\n", os.Args[0]))
 	for _, c := range cmds {
 		sb.WriteString(fmt.Sprintln(getCommandHelp(c, false)))
 	}
@@ -288,11 +307,17 @@ func isLoggingEnabled() bool {
 
 }
 
-func isAdmin() bool {
-	return os.Getenv("METALCLOUD_ADMIN") == "true"
+//initClientsForProfile resolves the connection profile and initializes the clients for it
+func initClientsForProfile(profileName string) (map[string]metalcloud.MetalCloudClient, error) {
+	profile, err := resolveConnectionProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return initClients(profile)
 }
 
-func initClients() (map[string]metalcloud.MetalCloudClient, error) {
+func initClients(profile ConnectionProfile) (map[string]metalcloud.MetalCloudClient, error) {
 
 	clients := map[string]metalcloud.MetalCloudClient{}
 	endpointSuffixes := map[string]string{
@@ -304,11 +329,11 @@ func initClients() (map[string]metalcloud.MetalCloudClient, error) {
 
 	for clientName, suffix := range endpointSuffixes {
 
-		if (clientName == DeveloperEndpoint || clientName == ExtendedEndpoint) && !isAdmin() {
+		if (clientName == DeveloperEndpoint || clientName == ExtendedEndpoint) && !profile.Admin {
 			continue
 		}
 
-		client, err := initClient(suffix)
+		client, err := initClient(profile, suffix)
 		if err != nil {
 			return nil, err
 		}
@@ -317,23 +342,23 @@ func initClients() (map[string]metalcloud.MetalCloudClient, error) {
 	return clients, nil
 }
 
-func initClient(endpointSuffix string) (metalcloud.MetalCloudClient, error) {
-	if v := os.Getenv("METALCLOUD_USER_EMAIL"); v == "" {
-		return nil, fmt.Errorf("METALCLOUD_USER_EMAIL must be set")
+func initClient(profile ConnectionProfile, endpointSuffix string) (metalcloud.MetalCloudClient, error) {
+	if profile.UserEmail == "" {
+		return nil, fmt.Errorf("METALCLOUD_USER_EMAIL must be set or a profile with a user must be selected")
 	}
 
-	if v := os.Getenv("METALCLOUD_API_KEY"); v == "" {
-		return nil, fmt.Errorf("METALCLOUD_API_KEY must be set")
+	if profile.APIKey == "" {
+		return nil, fmt.Errorf("METALCLOUD_API_KEY must be set or a profile with an api key must be selected")
 	}
 
-	if v := os.Getenv("METALCLOUD_ENDPOINT"); v == "" {
-		return nil, fmt.Errorf("METALCLOUD_ENDPOINT must be set")
+	if profile.Endpoint == "" {
+		return nil, fmt.Errorf("METALCLOUD_ENDPOINT must be set or a profile with an endpoint must be selected")
 	}
 
-	apiKey := os.Getenv("METALCLOUD_API_KEY")
-	user := os.Getenv("METALCLOUD_USER_EMAIL")
+	apiKey := profile.APIKey
+	user := profile.UserEmail
 
-	endpointHost := strings.TrimRight(os.Getenv("METALCLOUD_ENDPOINT"), "/")
+	endpointHost := strings.TrimRight(profile.Endpoint, "/")
 	endpoint := fmt.Sprintf("%s%s", endpointHost, endpointSuffix)
 
 	loggingEnabled := isLoggingEnabled()
@@ -374,20 +399,28 @@ func getCommands(clients map[string]metalcloud.MetalCloudClient) []Command {
 		osAssetsCmds,
 		osTemplatesCmds,
 		serversCmds,
+		serverTypeCmds,
+		serverFirmwareCmds,
+		firmwarePolicyCmds,
 		switchCmds,
 		switchPairCmds,
+		switchLinkCmds,
 		storageCmds,
 		subnetPoolCmds,
 		stageDefinitionsCmds,
 		workflowCmds,
 		versionCmds,
 		applyCmds,
+		exportCmds,
+		schemaCmds,
 		networkProfileCmds,
+		externalConnectionCmds,
 		networkCmds,
 		jobsCmds,
 		shellCompletionCmds,
 		userCmds,
 		reportsCmds,
+		configCmds,
 	}
 
 	filteredCommands := []Command{}
@@ -41,52 +41,35 @@ func TestValidateAPIKey(t *testing.T) {
 
 func TestInitClient(t *testing.T) {
 
-	envs := []string{
-		"METALCLOUD_USER_EMAIL",
-		"METALCLOUD_API_KEY",
-		"METALCLOUD_ENDPOINT",
-	}
-	//remember the current env values, clear them during the test
-	currentEnvVals := map[string]string{}
-	for _, e := range envs {
-		if v, ok := os.LookupEnv(e); ok {
-			currentEnvVals[e] = v
-			os.Unsetenv(e)
-		}
-	}
+	profile := ConnectionProfile{}
 
-	if _, err := initClient("METALCLOUD_ENDPOINT"); err == nil {
-		t.Errorf("Should have been able to test for missing env")
+	if _, err := initClient(profile, "METALCLOUD_ENDPOINT"); err == nil {
+		t.Errorf("Should have been able to test for missing user")
 	}
 
-	os.Setenv("METALCLOUD_USER_EMAIL", "user")
+	profile.UserEmail = "user"
 
-	if _, err := initClient("METALCLOUD_ENDPOINT"); err == nil {
-		t.Errorf("Should have been able to test for missing env")
+	if _, err := initClient(profile, "METALCLOUD_ENDPOINT"); err == nil {
+		t.Errorf("Should have been able to test for missing api key")
 	}
 
-	os.Setenv("METALCLOUD_API_KEY", fmt.Sprintf("%d:%s", rand.Intn(100), RandStringBytes(63)))
+	profile.APIKey = fmt.Sprintf("%d:%s", rand.Intn(100), RandStringBytes(63))
 
-	if _, err := initClient("METALCLOUD_ENDPOINT"); err == nil {
-		t.Errorf("Should have been able to test for missing env")
+	if _, err := initClient(profile, "METALCLOUD_ENDPOINT"); err == nil {
+		t.Errorf("Should have been able to test for missing endpoint")
 	}
 
-	os.Setenv("METALCLOUD_ENDPOINT", "endpoint")
+	profile.Endpoint = "endpoint"
 
-	if _, err := initClient("METALCLOUD_ENDPOINT"); err == nil {
+	if _, err := initClient(profile, "METALCLOUD_ENDPOINT"); err == nil {
 		t.Errorf("Should have been able to test for missing env")
 	}
 
-	client, err := initClient("METALCLOUD_ENDPOINT")
+	client, err := initClient(profile, "METALCLOUD_ENDPOINT")
 	if client == nil || err == nil {
 		t.Errorf("cannot initialize metalcloud client %v", err)
 	}
 
-	//put back the env values
-	for k, v := range currentEnvVals {
-		os.Setenv(k, v)
-	}
-
 }
 
 func TestInitClients(t *testing.T) {
@@ -97,21 +80,24 @@ func TestInitClients(t *testing.T) {
 		"METALCLOUD_API_KEY",
 		"METALCLOUD_ENDPOINT",
 		"METALCLOUD_ADMIN",
+		"METALCLOUD_PROFILE",
+		"METALCLOUD_CONFIG",
 	}
 
 	currentEnvVals := map[string]string{}
 	for _, e := range envs {
 		if v, ok := os.LookupEnv(e); ok {
 			currentEnvVals[e] = v
-			os.Unsetenv(e)
 		}
+		os.Unsetenv(e)
 	}
 
+	os.Setenv("METALCLOUD_CONFIG", "/tmp/metalcloud-cli-test-missing-config.yaml")
 	os.Setenv("METALCLOUD_USER_EMAIL", "user@user.com")
 	os.Setenv("METALCLOUD_API_KEY", fmt.Sprintf("%d:%s", rand.Intn(100), RandStringBytes(63)))
 	os.Setenv("METALCLOUD_ENDPOINT", "http://test1/1")
 
-	clients, err := initClients()
+	clients, err := initClientsForProfile("")
 	Expect(err).To(BeNil())
 	Expect(clients).To(Not(BeNil()))
 	Expect(clients[UserEndpoint]).To(Not(BeNil()))
@@ -120,13 +106,20 @@ func TestInitClients(t *testing.T) {
 
 	os.Setenv("METALCLOUD_ADMIN", "true")
 
-	clients, err = initClients()
+	clients, err = initClientsForProfile("")
 	Expect(clients).To(Not(BeNil()))
 	Expect(clients[UserEndpoint]).To(Not(BeNil()))
 	Expect(clients[ExtendedEndpoint]).To(Not(BeNil()))
 	Expect(clients[DeveloperEndpoint]).To(Not(BeNil()))
 
+	//a profile that is not in the config file is an error
+	_, err = initClientsForProfile("missing")
+	Expect(err).NotTo(BeNil())
+
 	//put back the env values
+	for _, e := range envs {
+		os.Unsetenv(e)
+	}
 	for k, v := range currentEnvVals {
 		os.Setenv(k, v)
 	}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/metalsoft-io/tableformatter"
+	jq "github.com/savaki/jq"
+	"github.com/savaki/jq/scanner"
+)
+
+//outputFormatsHelp describes the values supported by the global -o flag
+const outputFormatsHelp = "The output format. Supported values are 'json','csv','yaml','wide','name','jsonpath=<template>' and 'go-template=<template>'. Eg: -o jsonpath='{[*].ID}'"
+
+//addOutputFlags adds the -o and -output flags to the command
+func addOutputFlags(c *Command) {
+	if f := c.FlagSet.Lookup("output"); f != nil {
+		return
+	}
+
+	output := c.FlagSet.String("output", _nilDefaultStr, outputFormatsHelp)
+	if f := c.FlagSet.Lookup("o"); f == nil {
+		c.FlagSet.StringVar(output, "o", _nilDefaultStr, "Shorthand for -output.")
+	}
+}
+
+//outputFormatValue is the value of the -format flag of the commands that print their results in the json, csv or yaml formats
+type outputFormatValue struct {
+	value *string
+	table bool
+}
+
+func (v *outputFormatValue) String() string {
+	if v == nil || v.value == nil {
+		return ""
+	}
+	return *v.value
+}
+
+func (v *outputFormatValue) Set(s string) error {
+	*v.value = s
+	return nil
+}
+
+//outputFormatFlag registers the -format flag of a command that prints objects. The -o flag can be used with these commands.
+func outputFormatFlag(c *Command, value string, usage string) *string {
+	p := &value
+	c.FlagSet.Var(&outputFormatValue{value: p}, "format", usage)
+	return p
+}
+
+//tableFormatFlag registers the -format flag of a command that prints tables using renderTable.
+//The --columns, --sort-by and --no-headers flags are added to these commands.
+func tableFormatFlag(c *Command, value string, usage string) *string {
+	p := &value
+	c.FlagSet.Var(&outputFormatValue{value: p, table: true}, "format", usage)
+	return p
+}
+
+//getOutputFormatFlag returns the value of the -format flag if it was registered using outputFormatFlag or tableFormatFlag
+func getOutputFormatFlag(c *Command) (*outputFormatValue, bool) {
+	f := c.FlagSet.Lookup("format")
+	if f == nil {
+		return nil, false
+	}
+
+	v, ok := f.Value.(*outputFormatValue)
+	return v, ok
+}
+
+//hasOutputFormatFlag returns true if the command renders its output using the -format flag
+func hasOutputFormatFlag(c *Command) bool {
+	_, ok := getOutputFormatFlag(c)
+	return ok
+}
+
+//outputSpec is the parsed value of the -o flag
+type outputSpec struct {
+	kind     string
+	template string
+}
+
+//prepareOutput parses the -o flag and changes the arguments of the command so that it returns json when the output needs to be processed
+func prepareOutput(c *Command) (*outputSpec, error) {
+	f := c.FlagSet.Lookup("output")
+	if f == nil || f.Value.String() == _nilDefaultStr {
+		return nil, nil
+	}
+
+	output := f.Value.String()
+
+	spec := outputSpec{kind: output}
+	if i := strings.Index(output, "="); i != -1 {
+		spec.kind = output[:i]
+		spec.template = output[i+1:]
+	}
+
+	switch spec.kind {
+	case "wide":
+		if _, ok := c.Arguments["wide"]; ok {
+			c.FlagSet.Set("wide", "true")
+		}
+		return &spec, nil
+
+	case "json", "csv", "yaml":
+		if !hasOutputFormatFlag(c) {
+			return nil, fmt.Errorf("-o %s is not supported by this command", spec.kind)
+		}
+		c.FlagSet.Set("format", spec.kind)
+		return nil, nil
+
+	case "name", "jsonpath", "go-template":
+		if !hasOutputFormatFlag(c) {
+			return nil, fmt.Errorf("-o %s is not supported by this command", spec.kind)
+		}
+		if spec.kind != "name" && spec.template == "" {
+			return nil, fmt.Errorf("-o %s requires a template. Eg: -o %s=<template>", spec.kind, spec.kind)
+		}
+		c.FlagSet.Set("format", "json")
+		return &spec, nil
+	}
+
+	return nil, fmt.Errorf("invalid output format %s. %s", output, outputFormatsHelp)
+}
+
+//withOutput runs the command using the output requested with -o
+func withOutput(spec *outputSpec, run func() (string, error)) (string, error) {
+	if spec == nil {
+		return run()
+	}
+
+	if spec.kind == "wide" {
+		//tables wider than this are folded into one yaml cell per row, which is exactly what wide output avoids
+		foldAtLength := tableformatter.DefaultFoldAtLength
+		tableformatter.DefaultFoldAtLength = math.MaxInt32
+		defer func() { tableformatter.DefaultFoldAtLength = foldAtLength }()
+
+		return run()
+	}
+
+	ret, err := run()
+	if err != nil {
+		return "", err
+	}
+
+	var data interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(ret))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&data); err != nil {
+		return "", fmt.Errorf("-o %s is not supported by this command: the output is not json", spec.kind)
+	}
+
+	var s string
+
+	switch spec.kind {
+	case "name":
+		s, err = getOutputNames(data)
+	case "jsonpath":
+		s, err = evaluateJSONPathTemplate(spec.template, []byte(ret))
+	case "go-template":
+		s, err = evaluateGoTemplate(spec.template, data)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	return s, nil
+}
+
+//nameKeys are the keys used by -o name, in order of preference
+var nameKeys = []string{"label", "name", "id"}
+
+//getOutputNames returns the label, name or id of each object, one per line
+func getOutputNames(data interface{}) (string, error) {
+	objects, ok := data.([]interface{})
+	if !ok {
+		objects = []interface{}{data}
+	}
+
+	names := []string{}
+
+	for _, o := range objects {
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("-o name requires objects")
+		}
+
+		name := getObjectName(m)
+		if name == "" {
+			return "", fmt.Errorf("-o name is not supported by this command: the objects have no label, name or id")
+		}
+
+		names = append(names, name)
+	}
+
+	return strings.Join(names, "\n"), nil
+}
+
+//getObjectName returns the first non empty field named label, name or id. Fields such as server_id or subnetPoolLabel are used if there is no exact match.
+func getObjectName(m map[string]interface{}) string {
+	for _, exact := range []bool{true, false} {
+		for _, key := range nameKeys {
+			for _, k := range sortedKeys(m) {
+				v := m[k]
+				lk := strings.ToLower(k)
+				if (exact && lk != key) || (!exact && !strings.HasSuffix(lk, key)) {
+					continue
+				}
+
+				if s := formatJSONPathValue(v); s != "" && s != "0" {
+					return s
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func evaluateGoTemplate(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid go-template: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error executing go-template: %s", err)
+	}
+
+	return buf.String(), nil
+}
+
+//evaluateJSONPathTemplate evaluates a template such as 'id: {[0].ID}{"\n"}' on json data. Expressions are enclosed in braces.
+//If the template has no braces it is evaluated as a single expression.
+func evaluateJSONPathTemplate(text string, data []byte) (string, error) {
+	if !strings.Contains(text, "{") {
+		text = "{" + text + "}"
+	}
+
+	var sb strings.Builder
+
+	for len(text) > 0 {
+		start := strings.Index(text, "{")
+		if start == -1 {
+			sb.WriteString(text)
+			break
+		}
+
+		end := strings.Index(text[start:], "}")
+		if end == -1 {
+			return "", fmt.Errorf("invalid jsonpath %s: unclosed {", text)
+		}
+		end += start
+
+		sb.WriteString(text[:start])
+
+		expr := strings.TrimSpace(text[start+1 : end])
+
+		if strings.HasPrefix(expr, "\"") {
+			literal, err := strconv.Unquote(expr)
+			if err != nil {
+				return "", fmt.Errorf("invalid jsonpath literal %s: %s", expr, err)
+			}
+			sb.WriteString(literal)
+		} else {
+			values, err := evaluateJSONPath(expr, data)
+			if err != nil {
+				return "", err
+			}
+
+			strs := []string{}
+			for _, v := range values {
+				strs = append(strs, formatJSONPathValue(v))
+			}
+			sb.WriteString(strings.Join(strs, " "))
+		}
+
+		text = text[end+1:]
+	}
+
+	return sb.String(), nil
+}
+
+//evaluateJSONPath returns the values selected by an expression such as .field, [n], [n:m] or [*]. The fields and
+//indexes are selected by jq, [*] selects each item of a list.
+func evaluateJSONPath(expr string, data []byte) ([]interface{}, error) {
+	path := strings.TrimPrefix(expr, "$")
+
+	//jq separates all the selectors with dots, eg: .IPS.[0]
+	path = strings.ReplaceAll(path, "[", ".[")
+
+	current := [][]byte{data}
+
+	for i, part := range strings.Split(path, ".[*]") {
+		if i > 0 {
+			items := [][]byte{}
+			for _, c := range current {
+				list, err := scanner.AsArray(c, 0)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath %s: [*] can only select the items of a list", expr)
+				}
+				items = append(items, list...)
+			}
+			current = items
+		}
+
+		op, err := jq.Parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath %s: %s", expr, err)
+		}
+
+		next := [][]byte{}
+		for _, c := range current {
+			value, err := op.Apply(c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %s: %s", expr, err)
+			}
+			next = append(next, value)
+		}
+		current = next
+	}
+
+	values := []interface{}{}
+	for _, c := range current {
+		var v interface{}
+
+		decoder := json.NewDecoder(bytes.NewReader(c))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+//formatJSONPathValue returns strings and numbers as they are and other values as json
+func formatJSONPathValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case nil:
+		return ""
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(b)
+}
+
+//addTableFlags adds the flags that control the tables printed by the command
+func addTableFlags(c *Command) {
+	if v, ok := getOutputFormatFlag(c); !ok || !v.table {
+		return
+	}
+
+	if f := c.FlagSet.Lookup("columns"); f == nil {
+		c.Arguments["columns"] = c.FlagSet.String("columns", _nilDefaultStr, "Comma separated list of columns to show, in order. Column names are case insensitive and can be abbreviated if unambiguous. Eg: --columns id,status,serial,ipmi_host")
+	}
+	if f := c.FlagSet.Lookup("sort-by"); f == nil {
+		c.Arguments["sort_by"] = c.FlagSet.String("sort-by", _nilDefaultStr, "The column used to sort the table. Use column:desc for descending order. Eg: --sort-by status:desc")
+	}
+	if f := c.FlagSet.Lookup("no-headers"); f == nil {
+		c.Arguments["no_headers"] = c.FlagSet.Bool("no-headers", false, green("(Flag)")+" If set the header is not printed in the human readable and csv formats.")
+	}
+}
+
+//renderTable renders a table using the format, columns, sort order and headers requested by the command's flags
+func renderTable(c *Command, table tableformatter.Table, tableName string, topLine string) (string, error) {
+	format := getStringParam(c.Arguments["format"])
+
+	if sortBy, ok := getStringParamOk(c.Arguments["sort_by"]); ok {
+		if err := sortTable(&table, sortBy); err != nil {
+			return "", err
+		}
+	}
+
+	if columns, ok := getStringParamOk(c.Arguments["columns"]); ok {
+		if err := selectTableColumns(&table, strings.Split(columns, ",")); err != nil {
+			return "", err
+		}
+	}
+
+	if !getBoolParam(c.Arguments["no_headers"]) {
+		return table.RenderTable(tableName, topLine, format)
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		ret, err := table.RenderTable(tableName, topLine, format)
+		if err != nil {
+			return "", err
+		}
+		return ret[strings.Index(ret, "\n")+1:], nil
+
+	case "json", "yaml":
+		return table.RenderTable(tableName, topLine, format)
+	}
+
+	ret, err := table.RenderTable(tableName, "", format)
+	if err != nil {
+		return "", err
+	}
+
+	//the formatter always prints the header so only the lines of the rows are kept: the ones that
+	//start with the column delimiter, except the first one which is the header
+	rows := []string{}
+	header := true
+
+	for _, line := range strings.Split(ret, "\n") {
+		if !strings.HasPrefix(line, tableformatter.DefaultDelimiter) {
+			continue
+		}
+		if header {
+			header = false
+			continue
+		}
+		rows = append(rows, line)
+	}
+
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(rows, "\n") + "\n", nil
+}
+
+//normalizeColumnName converts names such as "SIZE (MB)" or "IPMI_HOST" to size_mb and ipmi_host
+func normalizeColumnName(name string) string {
+	var sb strings.Builder
+	separator := false
+
+	for _, r := range strings.ToLower(decolorize(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if separator && sb.Len() > 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(r)
+			separator = false
+			continue
+		}
+		separator = true
+	}
+
+	return sb.String()
+}
+
+//getTableColumnIndex returns the index of a column given its name or an unambiguous prefix of it
+func getTableColumnIndex(schema []tableformatter.SchemaField, name string) (int, error) {
+	n := normalizeColumnName(name)
+
+	available := []string{}
+	matches := []int{}
+
+	for i, f := range schema {
+		fn := normalizeColumnName(f.FieldName)
+		available = append(available, fn)
+
+		if fn == n {
+			return i, nil
+		}
+
+		if n != "" && strings.HasPrefix(fn, n) {
+			matches = append(matches, i)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	if len(matches) > 1 {
+		return 0, fmt.Errorf("column %s is ambiguous. Available columns: %s", name, strings.Join(available, ", "))
+	}
+
+	return 0, fmt.Errorf("column %s not found. Available columns: %s", name, strings.Join(available, ", "))
+}
+
+//selectTableColumns keeps only the given columns, in the given order
+func selectTableColumns(table *tableformatter.Table, columns []string) error {
+	indexes := []int{}
+
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		i, err := getTableColumnIndex(table.Schema, column)
+		if err != nil {
+			return err
+		}
+
+		indexes = append(indexes, i)
+	}
+
+	schema := []tableformatter.SchemaField{}
+	for _, i := range indexes {
+		schema = append(schema, table.Schema[i])
+	}
+
+	data := [][]interface{}{}
+	for _, row := range table.Data {
+		newRow := []interface{}{}
+		for _, i := range indexes {
+			newRow = append(newRow, row[i])
+		}
+		data = append(data, newRow)
+	}
+
+	table.Schema = schema
+	table.Data = data
+
+	return nil
+}
+
+//sortTable sorts the rows of a table by a column given as column or column:desc
+func sortTable(table *tableformatter.Table, sortBy string) error {
+	column := sortBy
+	desc := false
+
+	if i := strings.LastIndex(sortBy, ":"); i != -1 {
+		switch strings.ToLower(sortBy[i+1:]) {
+		case "desc":
+			desc = true
+		case "asc":
+		default:
+			return fmt.Errorf("invalid sort order %s. Supported values are 'asc' and 'desc'", sortBy[i+1:])
+		}
+		column = sortBy[:i]
+	}
+
+	index, err := getTableColumnIndex(table.Schema, column)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(table.Data, func(i, j int) bool {
+		a, b := table.Data[i][index], table.Data[j][index]
+		if desc {
+			a, b = b, a
+		}
+		return lessTableCell(a, b)
+	})
+
+	return nil
+}
+
+//lessTableCell compares two cells numerically if both are numbers and as text otherwise
+func lessTableCell(a interface{}, b interface{}) bool {
+	fa, aIsNumber := getCellNumber(a)
+	fb, bIsNumber := getCellNumber(b)
+
+	if aIsNumber && bIsNumber {
+		return fa < fb
+	}
+
+	return decolorize(fmt.Sprintf("%v", a)) < decolorize(fmt.Sprintf("%v", b))
+}
+
+func getCellNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+var colorCodesRegex = regexp.MustCompile(`\x1b\[[0-9;]*[mG]`)
+
+//decolorize removes the coloring characters from a string
+func decolorize(s string) string {
+	return colorCodesRegex.ReplaceAllLiteralString(s, "")
+}
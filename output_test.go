@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	"github.com/metalsoft-io/tableformatter"
+	. "github.com/onsi/gomega"
+)
+
+func TestEvaluateJSONPathTemplate(t *testing.T) {
+	RegisterTestingT(t)
+
+	data := []byte(`[
+		{"ID": 10, "LABEL": "server-a", "IPS": ["10.0.0.1", "10.0.0.2"], "META": {"rack": "r1"}},
+		{"ID": 11, "LABEL": "server-b", "IPS": [], "META": {"rack": "r2"}}
+	]`)
+
+	cases := []struct {
+		template string
+		expected string
+	}{
+		{"{[*].ID}", "10 11"},
+		{"[*].LABEL", "server-a server-b"},
+		{"{[1].META.rack}", "r2"},
+		{"{$[0].IPS[1]}", "10.0.0.2"},
+		{"{[0].IPS}", `["10.0.0.1","10.0.0.2"]`},
+		{"{[*].IPS[*]}", "10.0.0.1 10.0.0.2"},
+		{"{[0:1]}", `[{"ID":10,"IPS":["10.0.0.1","10.0.0.2"],"LABEL":"server-a","META":{"rack":"r1"}},{"ID":11,"IPS":[],"LABEL":"server-b","META":{"rack":"r2"}}]`},
+		{`id={[1].ID}{"\n"}`, "id=11\n"},
+	}
+
+	for _, c := range cases {
+		s, err := evaluateJSONPathTemplate(c.template, data)
+		Expect(err).To(BeNil(), c.template)
+		Expect(s).To(Equal(c.expected), c.template)
+	}
+
+	for _, template := range []string{"{[0].ID", "{[0.ID}", "{[a]}", "{ID}", `{"\x"}`, "{[5].ID}", "{[0].ID[*]}"} {
+		_, err := evaluateJSONPathTemplate(template, data)
+		Expect(err).NotTo(BeNil(), template)
+	}
+}
+
+func TestGetOutputNames(t *testing.T) {
+	RegisterTestingT(t)
+
+	s, err := getOutputNames([]interface{}{
+		map[string]interface{}{"ID": json.Number("1"), "LABEL": "a"},
+		map[string]interface{}{"ID": json.Number("2"), "NAME": "b"},
+		map[string]interface{}{"ID": json.Number("3"), "LABEL": ""},
+		map[string]interface{}{"server_id": json.Number("4")},
+	})
+	Expect(err).To(BeNil())
+	Expect(s).To(Equal("a\nb\n3\n4"))
+
+	_, err = getOutputNames([]interface{}{map[string]interface{}{"STATUS": "active"}})
+	Expect(err).NotTo(BeNil())
+}
+
+func TestExecuteCommandOutput(t *testing.T) {
+	RegisterTestingT(t)
+
+	newCommands := func() []Command {
+		return []Command{
+			{
+				Subject:      "tests",
+				AltSubject:   "s",
+				Predicate:    "list",
+				AltPredicate: "ls",
+				FlagSet:      flag.NewFlagSet("tests list", flag.ExitOnError),
+				InitFunc: func(c *Command) {
+					c.Arguments = map[string]interface{}{
+						"format": tableFormatFlag(c, _nilDefaultStr, "The output format. Supported values are 'json','csv','yaml'. The default format is human readable."),
+					}
+				},
+				ExecuteFunc: func(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+					table := tableformatter.Table{
+						Data: [][]interface{}{
+							{10, "server-a", "active"},
+							{11, "server-b", "used"},
+						},
+						Schema: []tableformatter.SchemaField{
+							{FieldName: "ID", FieldType: tableformatter.TypeInt, FieldSize: 6},
+							{FieldName: "LABEL", FieldType: tableformatter.TypeString, FieldSize: 6},
+							{FieldName: "STATUS", FieldType: tableformatter.TypeString, FieldSize: 6},
+						},
+					}
+					return table.RenderTable("Servers", "", getStringParam(c.Arguments["format"]))
+				},
+			},
+			{
+				Subject:      "tests",
+				AltSubject:   "s",
+				Predicate:    "create",
+				AltPredicate: "new",
+				FlagSet:      flag.NewFlagSet("tests create", flag.ExitOnError),
+				InitFunc: func(c *Command) {
+					c.Arguments = map[string]interface{}{}
+				},
+				ExecuteFunc: func(c *Command, client metalcloud.MetalCloudClient) (string, error) {
+					return "", nil
+				},
+			},
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+	clients := map[string]metalcloud.MetalCloudClient{
+		"": client,
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-o", "jsonpath={[*].ID}"}, "10 11\n"},
+		{[]string{"--output", "go-template={{range .}}{{.LABEL}}={{.STATUS}} {{end}}"}, "server-a=active server-b=used \n"},
+		{[]string{"-o", "name"}, "server-a\nserver-b\n"},
+		{[]string{"-o", "csv"}, "ID,LABEL,STATUS\n10,server-a,active\n11,server-b,used\n"},
+	}
+
+	defer SetConsoleIOChannel(os.Stdin, os.Stdout)
+
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		SetConsoleIOChannel(strings.NewReader(""), &stdout)
+
+		err := executeCommand(append([]string{"", "tests", "list"}, c.args...), newCommands(), clients)
+		Expect(err).To(BeNil(), strings.Join(c.args, " "))
+		Expect(stdout.String()).To(Equal(c.expected), strings.Join(c.args, " "))
+	}
+
+	for _, args := range [][]string{
+		{"tests", "list", "-o", "jsonpath"},
+		{"tests", "list", "-o", "table"},
+		{"tests", "create", "-o", "name"},
+	} {
+		err := executeCommand(append([]string{""}, args...), newCommands(), clients)
+		Expect(err).NotTo(BeNil(), strings.Join(args, " "))
+	}
+}
+
+func TestAddTableFlags(t *testing.T) {
+	RegisterTestingT(t)
+
+	register := map[string]func(c *Command) *string{
+		"table": func(c *Command) *string {
+			return tableFormatFlag(c, _nilDefaultStr, "The output format.")
+		},
+		"object": func(c *Command) *string {
+			return outputFormatFlag(c, _nilDefaultStr, "The output format.")
+		},
+		"input": func(c *Command) *string {
+			return c.FlagSet.String("format", "json", "The input format.")
+		},
+	}
+
+	for kind, f := range register {
+		c := Command{FlagSet: flag.NewFlagSet(kind, flag.ContinueOnError)}
+		c.Arguments = map[string]interface{}{"format": f(&c)}
+
+		addTableFlags(&c)
+
+		Expect(hasOutputFormatFlag(&c)).To(Equal(kind != "input"), kind)
+		Expect(c.FlagSet.Lookup("columns") != nil).To(Equal(kind == "table"), kind)
+		Expect(c.FlagSet.Lookup("no-headers") != nil).To(Equal(kind == "table"), kind)
+
+		Expect(c.FlagSet.Parse([]string{"-format", "yaml"})).To(BeNil())
+		Expect(getStringParam(c.Arguments["format"])).To(Equal("yaml"))
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	RegisterTestingT(t)
+	setColoringEnabled(false)
+
+	newTable := func() tableformatter.Table {
+		return tableformatter.Table{
+			Data: [][]interface{}{
+				{10, "used", "SN1", "10.0.0.10", 2.5},
+				{9, "available", "SN3", "10.0.0.9", 10.0},
+				{11, "available", "SN2", "10.0.0.11", 1.0},
+			},
+			Schema: []tableformatter.SchemaField{
+				{FieldName: "ID", FieldType: tableformatter.TypeInt, FieldSize: 6},
+				{FieldName: "STATUS", FieldType: tableformatter.TypeString, FieldSize: 5},
+				{FieldName: "SERIAL_NUMBER", FieldType: tableformatter.TypeString, FieldSize: 5},
+				{FieldName: "IPMI_HOST", FieldType: tableformatter.TypeString, FieldSize: 5},
+				{FieldName: "SIZE (TB)", FieldType: tableformatter.TypeFloat, FieldSize: 4, FieldPrecision: 1},
+			},
+		}
+	}
+
+	cases := []struct {
+		args     map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{"format": "csv", "columns": "serial,id"},
+			"SERIAL_NUMBER,ID\nSN1,10\nSN3,9\nSN2,11\n",
+		},
+		{
+			map[string]interface{}{"format": "csv", "columns": "id", "sort_by": "id"},
+			"ID\n9\n10\n11\n",
+		},
+		{
+			map[string]interface{}{"format": "csv", "columns": "ID,size_tb", "sort_by": "size (tb):desc"},
+			"ID,SIZE (TB)\n9,10.000000\n10,2.500000\n11,1.000000\n",
+		},
+		{
+			map[string]interface{}{"format": "csv", "columns": "id,status", "sort_by": "status:asc"},
+			"ID,STATUS\n9,available\n11,available\n10,used\n",
+		},
+		{
+			map[string]interface{}{"format": "csv", "columns": "ipmi_host", "no_headers": true},
+			"10.0.0.10\n10.0.0.9\n10.0.0.11\n",
+		},
+		{
+			map[string]interface{}{"columns": "id,serial", "sort_by": "serial", "no_headers": true},
+			"| 10    | SN1           |\n| 11    | SN2           |\n| 9     | SN3           |\n",
+		},
+	}
+
+	for _, c := range cases {
+		cmd := MakeCommand(c.args)
+		ret, err := renderTable(&cmd, newTable(), "Servers", "top line")
+		Expect(err).To(BeNil())
+		Expect(ret).To(Equal(c.expected))
+	}
+
+	cmd := MakeCommand(map[string]interface{}{"columns": "id,status"})
+	ret, err := renderTable(&cmd, newTable(), "Servers", "top line")
+	Expect(err).To(BeNil())
+	Expect(ret).To(ContainSubstring("top line"))
+	Expect(ret).To(ContainSubstring("| ID    | STATUS"))
+	Expect(ret).To(ContainSubstring("Total: 3 Servers"))
+
+	cmd = MakeCommand(map[string]interface{}{"no_headers": true})
+	ret, err = renderTable(&cmd, tableformatter.Table{Schema: newTable().Schema}, "Servers", "")
+	Expect(err).To(BeNil())
+	Expect(ret).To(Equal(""))
+
+	for _, args := range []map[string]interface{}{
+		{"columns": "id,unknown"},
+		{"columns": "s"},
+		{"sort_by": "id:up"},
+		{"sort_by": "unknown"},
+	} {
+		cmd := MakeCommand(args)
+		_, err := renderTable(&cmd, newTable(), "Servers", "")
+		Expect(err).NotTo(BeNil())
+	}
+}
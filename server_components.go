@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//serverComponentInfo is a hardware component of a server
+type serverComponentInfo struct {
+	id            int
+	componentType string
+	name          string
+	vendor        string
+	model         string
+	serial        string
+	version       string
+}
+
+//String returns the vendor, model and version of the component
+func (c serverComponentInfo) String() string {
+	parts := []string{}
+	for _, s := range []string{c.vendor, c.model, c.version} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+//serverComponentDetailsKeys are the keys of the component's firmware json that hold the vendor, model and serial number
+var serverComponentDetailsKeys = map[string][]string{
+	"vendor": {"manufacturer", "vendor"},
+	"model":  {"model"},
+	"serial": {"serialnumber", "serial_number", "serial"},
+}
+
+//getServerComponentDetails returns the vendor, model and serial number found in the firmware json of a component.
+//Values that are missing or cannot be parsed are returned empty.
+func getServerComponentDetails(firmwareJSON string) map[string]string {
+	ret := map[string]string{}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(firmwareJSON), &m); err != nil {
+		return ret
+	}
+
+	values := map[string]string{}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			values[strings.ToLower(k)] = strings.TrimSpace(s)
+		}
+	}
+
+	for detail, keys := range serverComponentDetailsKeys {
+		for _, k := range keys {
+			if v, ok := values[k]; ok && v != "" {
+				ret[detail] = v
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+//getServerInterfaceComponents returns the network interfaces of the server as components named by their index.
+//The capacity of an interface, if known, is used as its model and its mac address as its serial number.
+func getServerInterfaceComponents(server *metalcloud.Server) []serverComponentInfo {
+	ret := []serverComponentInfo{}
+
+	for i, s := range server.ServerInterfaces {
+		model := ""
+		for _, d := range server.NICDetails {
+			if strings.EqualFold(d.ServerInterfaceMACAddress, s.ServerInterfaceMACAddress) && d.ServerInterfaceCapacityMBPs != 0 {
+				model = fmt.Sprintf("%d Gbps", int(d.ServerInterfaceCapacityMBPs/1000))
+			}
+		}
+
+		ret = append(ret, serverComponentInfo{
+			id:            i,
+			componentType: "interface",
+			name:          fmt.Sprintf("interface %d", i),
+			model:         model,
+			serial:        s.ServerInterfaceMACAddress,
+		})
+	}
+
+	return ret
+}
+
+//getServerComponentInventory returns the components of the server, its disks and its network interfaces, sorted by type and name.
+//Memory modules and power supplies are included only if the server reports them as firmware components.
+func getServerComponentInventory(server *metalcloud.Server, client metalcloud.MetalCloudClient) ([]serverComponentInfo, error) {
+	list, err := client.ServerComponents(server.ServerID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []serverComponentInfo{}
+
+	for _, c := range *list {
+		details := getServerComponentDetails(c.ServerComponentFirmwareJSON)
+
+		ret = append(ret, serverComponentInfo{
+			id:            c.ServerComponentID,
+			componentType: c.ServerComponentType,
+			name:          c.ServerComponentName,
+			vendor:        details["vendor"],
+			model:         details["model"],
+			serial:        details["serial"],
+			version:       c.ServerComponentFirmwareVersion,
+		})
+	}
+
+	for _, d := range server.ServerDisks {
+		ret = append(ret, serverComponentInfo{
+			id:            d.ServerDiskID,
+			componentType: "disk",
+			name:          fmt.Sprintf("%s %d GB", d.ServerDiskType, d.ServerDiskSizeGB),
+			vendor:        d.ServerDiskVendor,
+			model:         d.ServerDiskModel,
+			serial:        d.ServerDiskSerial,
+		})
+	}
+
+	ret = append(ret, getServerInterfaceComponents(server)...)
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].componentType != ret[j].componentType {
+			return ret[i].componentType < ret[j].componentType
+		}
+		if ret[i].name != ret[j].name {
+			return ret[i].name < ret[j].name
+		}
+		return ret[i].id < ret[j].id
+	})
+
+	return ret, nil
+}
+
+//serverComponentDifference is the result of comparing a component, or one of its properties, of two servers.
+//The values of a component missing on one of the servers are empty. The key identifies the compared component.
+type serverComponentDifference struct {
+	key           string
+	status        string
+	componentType string
+	name          string
+	property      string
+	first         string
+	second        string
+}
+
+//getServerComponentKeys returns the keys used to pair the components of two servers.
+//Components are paired by type and name. Components with the same type and name are paired in order.
+func getServerComponentKeys(components []serverComponentInfo) []string {
+	occurrences := map[string]int{}
+	keys := []string{}
+
+	for _, c := range components {
+		k := fmt.Sprintf("%s/%s", strings.ToLower(c.componentType), strings.ToLower(c.name))
+		keys = append(keys, fmt.Sprintf("%s/%d", k, occurrences[k]))
+		occurrences[k]++
+	}
+
+	return keys
+}
+
+//diffServerComponents compares the components of the second server against those of the first one.
+//Components are same, different (one row per property), missing (only on the first server) or extra (only on the second server).
+//Serial numbers are not compared.
+func diffServerComponents(first []serverComponentInfo, second []serverComponentInfo) []serverComponentDifference {
+	firstKeys := getServerComponentKeys(first)
+	secondKeys := getServerComponentKeys(second)
+
+	secondComponents := map[string]serverComponentInfo{}
+	for i, c := range second {
+		secondComponents[secondKeys[i]] = c
+	}
+
+	paired := map[string]bool{}
+	ret := []serverComponentDifference{}
+
+	for i, a := range first {
+		b, ok := secondComponents[firstKeys[i]]
+		if !ok {
+			ret = append(ret, serverComponentDifference{
+				key:           firstKeys[i],
+				status:        "missing",
+				componentType: a.componentType,
+				name:          a.name,
+				first:         a.String(),
+			})
+			continue
+		}
+		paired[firstKeys[i]] = true
+
+		properties := []struct {
+			name   string
+			first  string
+			second string
+		}{
+			{"vendor", a.vendor, b.vendor},
+			{"model", a.model, b.model},
+			{"version", a.version, b.version},
+		}
+
+		same := true
+		for _, p := range properties {
+			if p.first == p.second {
+				continue
+			}
+			same = false
+
+			ret = append(ret, serverComponentDifference{
+				key:           firstKeys[i],
+				status:        "different",
+				componentType: a.componentType,
+				name:          a.name,
+				property:      p.name,
+				first:         p.first,
+				second:        p.second,
+			})
+		}
+
+		if same {
+			ret = append(ret, serverComponentDifference{
+				key:           firstKeys[i],
+				status:        "same",
+				componentType: a.componentType,
+				name:          a.name,
+				first:         a.String(),
+				second:        b.String(),
+			})
+		}
+	}
+
+	for i, b := range second {
+		if paired[secondKeys[i]] {
+			continue
+		}
+
+		ret = append(ret, serverComponentDifference{
+			key:           secondKeys[i],
+			status:        "extra",
+			componentType: b.componentType,
+			name:          b.name,
+			second:        b.String(),
+		})
+	}
+
+	return ret
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGetServerComponentDetails(t *testing.T) {
+	RegisterTestingT(t)
+
+	details := getServerComponentDetails(`{"Manufacturer":"Mellanox","Model":"ConnectX-5","SerialNumber":"MT1234","Slot":3}`)
+	Expect(details["vendor"]).To(Equal("Mellanox"))
+	Expect(details["model"]).To(Equal("ConnectX-5"))
+	Expect(details["serial"]).To(Equal("MT1234"))
+
+	details = getServerComponentDetails(`{"vendor":"Samsung","serial_number":""}`)
+	Expect(details["vendor"]).To(Equal("Samsung"))
+	Expect(details).NotTo(HaveKey("serial"))
+
+	Expect(getServerComponentDetails("")).To(BeEmpty())
+	Expect(getServerComponentDetails("not json")).To(BeEmpty())
+}
+
+func TestDiffServerComponents(t *testing.T) {
+	RegisterTestingT(t)
+
+	first := []serverComponentInfo{
+		{id: 1, componentType: "bios", name: "BIOS", vendor: "Dell", version: "2.10.1"},
+		{id: 2, componentType: "disk", name: "SSD 960 GB", vendor: "Samsung", model: "PM883", serial: "S1"},
+		{id: 3, componentType: "disk", name: "SSD 960 GB", vendor: "Samsung", model: "PM883", serial: "S2"},
+		{id: 4, componentType: "nic", name: "NIC.Slot.1", vendor: "Mellanox", model: "ConnectX-5", version: "16.28"},
+	}
+
+	second := []serverComponentInfo{
+		{id: 11, componentType: "bios", name: "BIOS", vendor: "Dell", version: "2.9.3"},
+		{id: 12, componentType: "disk", name: "SSD 960 GB", vendor: "Samsung", model: "PM883", serial: "S3"},
+		{id: 14, componentType: "nic", name: "NIC.Slot.1", vendor: "Mellanox", model: "ConnectX-5", version: "16.28"},
+		{id: 15, componentType: "psu", name: "PSU.Slot.1", vendor: "Dell", version: "00.1D.7D"},
+	}
+
+	cases := []struct {
+		status   string
+		name     string
+		property string
+		first    string
+		second   string
+	}{
+		{"different", "BIOS", "version", "2.10.1", "2.9.3"},
+		{"same", "SSD 960 GB", "", "Samsung PM883", "Samsung PM883"},
+		{"missing", "SSD 960 GB", "", "Samsung PM883", ""},
+		{"same", "NIC.Slot.1", "", "Mellanox ConnectX-5 16.28", "Mellanox ConnectX-5 16.28"},
+		{"extra", "PSU.Slot.1", "", "", "Dell 00.1D.7D"},
+	}
+
+	ret := diffServerComponents(first, second)
+	Expect(ret).To(HaveLen(len(cases)))
+
+	for i, c := range cases {
+		d := ret[i]
+		if d.status != c.status || d.name != c.name || d.property != c.property || d.first != c.first || d.second != c.second {
+			t.Errorf("case %d: expected %+v got %+v", i, c, d)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+)
+
+//serverRegistrationColumns are the columns of the file used by server register-bulk
+var serverRegistrationColumns = []string{"datacenter", "vendor", "mgmt_address", "mgmt_user", "mgmt_pass"}
+
+//serverRegistrationResultColumns are the columns of the results file written by server register-bulk
+var serverRegistrationResultColumns = []string{"row", "mgmt_address", "server_id", "error"}
+
+//serverRegistration is a row of the file used by server register-bulk
+type serverRegistration struct {
+	row    int
+	server metalcloud.ServerCreateAndRegister
+}
+
+//serverRegistrationResult is the outcome of a registration. The server id is 0 if the registration failed.
+type serverRegistrationResult struct {
+	row         int
+	mgmtAddress string
+	serverID    int
+	err         string
+}
+
+//parseServerRegistrations reads the servers to register. The first line must be a header with the serverRegistrationColumns in any order.
+//Rows are numbered from 1, without the header. Management addresses must be unique as they identify the rows when resuming.
+func parseServerRegistrations(content []byte) ([]serverRegistration, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !stringInSlice(name, serverRegistrationColumns) {
+			return nil, fmt.Errorf("unknown column %s. Supported columns are %s", name, strings.Join(serverRegistrationColumns, ","))
+		}
+		columns[name] = i
+	}
+
+	for _, name := range serverRegistrationColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is required", name)
+		}
+	}
+
+	registrations := []serverRegistration{}
+	addresses := map[string]int{}
+
+	for i, record := range records[1:] {
+		row := i + 1
+
+		values := map[string]string{}
+		for _, name := range serverRegistrationColumns {
+			values[name] = strings.TrimSpace(record[columns[name]])
+			if values[name] == "" {
+				return nil, fmt.Errorf("row %d: %s cannot be empty", row, name)
+			}
+		}
+
+		address := strings.ToLower(values["mgmt_address"])
+		if prev, ok := addresses[address]; ok {
+			return nil, fmt.Errorf("row %d: management address %s is also used on row %d", row, values["mgmt_address"], prev)
+		}
+		addresses[address] = row
+
+		registrations = append(registrations, serverRegistration{
+			row: row,
+			server: metalcloud.ServerCreateAndRegister{
+				DatacenterName:           values["datacenter"],
+				ServerVendor:             values["vendor"],
+				ServerManagementAddress:  values["mgmt_address"],
+				ServerManagementUser:     values["mgmt_user"],
+				ServerManagementPassword: values["mgmt_pass"],
+			},
+		})
+	}
+
+	return registrations, nil
+}
+
+//parseServerRegistrationResults reads a results file written by server register-bulk
+func parseServerRegistrationResults(content []byte) ([]serverRegistrationResult, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(serverRegistrationResultColumns, ",") {
+		return nil, fmt.Errorf("not a results file of server register-bulk")
+	}
+
+	results := []serverRegistrationResult{}
+	for i, record := range records[1:] {
+		row, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid row %s", i+2, record[0])
+		}
+
+		serverID := 0
+		if record[2] != "" {
+			serverID, err = strconv.Atoi(record[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid server id %s", i+2, record[2])
+			}
+		}
+
+		results = append(results, serverRegistrationResult{
+			row:         row,
+			mgmtAddress: record[1],
+			serverID:    serverID,
+			err:         record[3],
+		})
+	}
+
+	return results, nil
+}
+
+//writeServerRegistrationResult writes a result and flushes it so that the file is usable with --resume even if the command is interrupted
+func writeServerRegistrationResult(w *csv.Writer, r serverRegistrationResult) error {
+	serverID := ""
+	if r.serverID != 0 {
+		serverID = fmt.Sprintf("%d", r.serverID)
+	}
+
+	w.Write([]string{fmt.Sprintf("%d", r.row), r.mgmtAddress, serverID, r.err})
+	w.Flush()
+
+	return w.Error()
+}
+
+//registerServers registers the servers using at most concurrency parallel calls.
+//Each result is passed to onResult as soon as it is available, from the calling goroutine.
+//If onResult fails no other registration is started and the ones in progress are waited for.
+func registerServers(registrations []serverRegistration, concurrency int, client metalcloud.MetalCloudClient, onResult func(serverRegistrationResult) error) error {
+	jobs := make(chan serverRegistration)
+	results := make(chan serverRegistrationResult)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				result := serverRegistrationResult{
+					row:         r.row,
+					mgmtAddress: r.server.ServerManagementAddress,
+				}
+
+				id, err := client.ServerCreateAndRegister(r.server)
+				if err != nil {
+					result.err = err.Error()
+				} else {
+					result.serverID = id
+				}
+
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for _, r := range registrations {
+			select {
+			case jobs <- r:
+			case <-stop:
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var ret error
+	for r := range results {
+		//the results of the registrations in progress are still read so that the workers can finish
+		if ret == nil {
+			ret = onResult(r)
+			if ret != nil {
+				close(stop)
+			}
+		}
+	}
+
+	return ret
+}
+
+//openServerRegistrationResults creates the results file. When resuming, the successful registrations
+//of the existing file are kept and returned, indexed by management address. They are written to a
+//temporary file that replaces the existing one so that they are not lost if writing fails.
+func openServerRegistrationResults(path string, resume bool) (*os.File, *csv.Writer, map[string]serverRegistrationResult, error) {
+	registered := map[string]serverRegistrationResult{}
+
+	content, err := readInputFromFile(path)
+	switch {
+	case err == nil && !resume:
+		return nil, nil, nil, fmt.Errorf("results file %s already exists. Use --resume to skip the servers it lists as registered", path)
+	case err == nil:
+		previous, err := parseServerRegistrationResults(content)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %s", path, err)
+		}
+		for _, r := range previous {
+			if r.serverID != 0 {
+				registered[strings.ToLower(r.mgmtAddress)] = r
+			}
+		}
+	case !os.IsNotExist(err):
+		return nil, nil, nil, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = writeKeptServerRegistrationResults(tmp, registered)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, nil, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return f, csv.NewWriter(f), registered, nil
+}
+
+//writeKeptServerRegistrationResults writes the header and the kept registrations ordered by row
+func writeKeptServerRegistrationResults(f *os.File, registered map[string]serverRegistrationResult) error {
+	w := csv.NewWriter(f)
+	w.Write(serverRegistrationResultColumns)
+
+	kept := []serverRegistrationResult{}
+	for _, r := range registered {
+		kept = append(kept, r)
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].row < kept[j].row })
+
+	for _, r := range kept {
+		if err := writeServerRegistrationResult(w, r); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
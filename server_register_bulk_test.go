@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	metalcloud "github.com/metalsoft-io/metal-cloud-sdk-go/v2"
+	mock_metalcloud "github.com/metalsoft-io/metalcloud-cli/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseServerRegistrations(t *testing.T) {
+	RegisterTestingT(t)
+
+	cases := []struct {
+		name    string
+		content string
+		rows    int
+		good    bool
+	}{
+		{
+			name:    "good",
+			content: "datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass\ndc1,dell,10.0.0.1,root,calvin\ndc1,hpe,10.0.0.2,admin,pass\n",
+			rows:    2,
+			good:    true,
+		},
+		{
+			name:    "columns in another order",
+			content: "MGMT_ADDRESS, Datacenter, vendor, mgmt_pass, mgmt_user\n10.0.0.1,dc1,dell,calvin,root\n",
+			rows:    1,
+			good:    true,
+		},
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name:    "missing column",
+			content: "datacenter,vendor,mgmt_address,mgmt_user\ndc1,dell,10.0.0.1,root\n",
+		},
+		{
+			name:    "unknown column",
+			content: "datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass,rack\ndc1,dell,10.0.0.1,root,calvin,R1\n",
+		},
+		{
+			name:    "empty value",
+			content: "datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass\ndc1,,10.0.0.1,root,calvin\n",
+		},
+		{
+			name:    "duplicate address",
+			content: "datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass\ndc1,dell,bmc1.example.com,root,calvin\ndc1,dell,BMC1.example.com,root,calvin\n",
+		},
+	}
+
+	for _, c := range cases {
+		ret, err := parseServerRegistrations([]byte(c.content))
+		if !c.good {
+			if err == nil {
+				t.Errorf("case %s: expected error", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("case %s: unexpected error %s", c.name, err)
+			continue
+		}
+
+		Expect(ret).To(HaveLen(c.rows))
+		Expect(ret[0].row).To(Equal(1))
+		Expect(ret[0].server.ServerManagementAddress).To(Equal("10.0.0.1"))
+		Expect(ret[0].server.ServerManagementPassword).To(Equal("calvin"))
+	}
+}
+
+func TestParseServerRegistrationResults(t *testing.T) {
+	RegisterTestingT(t)
+
+	ret, err := parseServerRegistrationResults([]byte("row,mgmt_address,server_id,error\n1,10.0.0.1,100,\n2,10.0.0.2,,\"timeout, retry\"\n"))
+	Expect(err).To(BeNil())
+	Expect(ret).To(Equal([]serverRegistrationResult{
+		{row: 1, mgmtAddress: "10.0.0.1", serverID: 100},
+		{row: 2, mgmtAddress: "10.0.0.2", err: "timeout, retry"},
+	}))
+
+	_, err = parseServerRegistrationResults([]byte("datacenter,vendor,mgmt_address,mgmt_user,mgmt_pass\n"))
+	Expect(err).NotTo(BeNil())
+
+	_, err = parseServerRegistrationResults([]byte("row,mgmt_address,server_id,error\n1,10.0.0.1,abc,\n"))
+	Expect(err).NotTo(BeNil())
+}
+
+func TestRegisterServersStopsOnError(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctrl := gomock.NewController(t)
+	client := mock_metalcloud.NewMockMetalCloudClient(ctrl)
+
+	registrations := []serverRegistration{}
+	for i := 1; i <= 10; i++ {
+		registrations = append(registrations, serverRegistration{
+			row:    i,
+			server: metalcloud.ServerCreateAndRegister{ServerManagementAddress: fmt.Sprintf("10.0.0.%d", i)},
+		})
+	}
+
+	//with one worker at most the registration handed over before the failure is started
+	client.EXPECT().
+		ServerCreateAndRegister(gomock.Any()).
+		Return(100, nil).
+		MinTimes(1).
+		MaxTimes(2)
+
+	results := 0
+	err := registerServers(registrations, 1, client, func(r serverRegistrationResult) error {
+		results++
+		return fmt.Errorf("disk full")
+	})
+	Expect(err).NotTo(BeNil())
+	Expect(results).To(Equal(1))
+}
@@ -37,3 +37,13 @@ func wrapToLength(s string, length int) string {
 
 	return sb.String()
 }
+
+//stringInSlice returns true if s is one of the elements of list
+func stringInSlice(s string, list []string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}